@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/solatis/trapperkeeper/internal/core/config"
+	"github.com/solatis/trapperkeeper/internal/core/db"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply or roll back database schema migrations",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	RunE:  runMigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "Roll back the n most recently applied migrations (default 1)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runMigrateDown,
+}
+
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo [id]",
+	Short: "Roll back and reapply a migration (default: the most recently applied one)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runMigrateRedo,
+}
+
+var migrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "Migrate up or down until <version> is the most recently applied migration",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateTo,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of every embedded migration",
+	RunE:  runMigrateStatus,
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateRedoCmd, migrateToCmd, migrateStatusCmd)
+}
+
+func runMigrateUp(cmd *cobra.Command, args []string) error {
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := openMigrateDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	policy := db.MigrationLockPolicy{
+		Timeout:       cfg.MigrationLockTimeout,
+		RetryInterval: cfg.MigrationLockRetryInterval,
+	}
+	if err := db.MigrateUpWithLock(database, policy); err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+	return nil
+}
+
+func runMigrateDown(cmd *cobra.Command, args []string) error {
+	steps := 1
+	if len(args) == 1 {
+		if _, err := fmt.Sscanf(args[0], "%d", &steps); err != nil {
+			return fmt.Errorf("invalid step count %q: %w", args[0], err)
+		}
+	}
+
+	database, err := openMigrateDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := db.MigrateDown(database, steps); err != nil {
+		return fmt.Errorf("migrate down: %w", err)
+	}
+	return nil
+}
+
+func runMigrateRedo(cmd *cobra.Command, args []string) error {
+	var id string
+	if len(args) == 1 {
+		id = args[0]
+	}
+
+	database, err := openMigrateDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := db.MigrateRedo(database, id); err != nil {
+		return fmt.Errorf("migrate redo: %w", err)
+	}
+	return nil
+}
+
+func runMigrateTo(cmd *cobra.Command, args []string) error {
+	database, err := openMigrateDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	if err := db.MigrateTo(database, args[0]); err != nil {
+		return fmt.Errorf("migrate to %s: %w", args[0], err)
+	}
+	return nil
+}
+
+func runMigrateStatus(cmd *cobra.Command, args []string) error {
+	database, err := openMigrateDB()
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	statuses, err := db.MigrateStatus(database)
+	if err != nil {
+		return fmt.Errorf("migrate status: %w", err)
+	}
+
+	for _, s := range statuses {
+		state := "pending"
+		if s.Applied {
+			state = "applied"
+		}
+		fmt.Printf("%-40s %s\n", s.ID, state)
+	}
+	return nil
+}
+
+// openMigrateDB opens --db-url the same way every other subcommand that
+// touches the database does.
+func openMigrateDB() (*sqlx.DB, error) {
+	if dbURL == "" {
+		return nil, fmt.Errorf("--db-url required")
+	}
+	return db.Open(dbURL)
+}
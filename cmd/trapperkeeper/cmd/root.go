@@ -5,10 +5,12 @@ import (
 )
 
 var (
-	configFile string
-	dbURL      string
-	logLevel   string
-	logFormat  string
+	configFile               string
+	dbURL                    string
+	logLevel                 string
+	logFormat                string
+	masterKey                string
+	insecurePlaintextSecrets bool
 )
 
 var rootCmd = &cobra.Command{
@@ -22,6 +24,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&dbURL, "db-url", "", "database connection URL (sqlite://path or postgres://...)")
 	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level (debug, info, warn, error)")
 	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "json", "log format (json, text)")
+	rootCmd.PersistentFlags().StringVar(&masterKey, "master-key", "", "base64-encoded master key for encrypting secrets at rest (or set TRAPPERKEEPER_MASTER_KEY)")
+	rootCmd.PersistentFlags().BoolVar(&insecurePlaintextSecrets, "insecure-plaintext-secrets", false, "allow storing/loading secrets unencrypted when no master key is configured (development only)")
 }
 
 func Execute() error {
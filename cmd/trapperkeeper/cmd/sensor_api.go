@@ -8,12 +8,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/solatis/trapperkeeper/internal/core/api"
 	"github.com/solatis/trapperkeeper/internal/core/auth"
 	"github.com/solatis/trapperkeeper/internal/core/config"
 	"github.com/solatis/trapperkeeper/internal/core/db"
 	"github.com/solatis/trapperkeeper/internal/core/server"
+	"github.com/solatis/trapperkeeper/internal/observability"
 	"github.com/solatis/trapperkeeper/internal/rules"
 	"github.com/spf13/cobra"
 )
@@ -73,24 +75,124 @@ func runSensorAPI(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load queries: %w", err)
 	}
 
-	secrets, err := config.HMACSecrets()
+	masterKeySource, err := auth.MasterKeyFromFlagOrEnv(masterKey)
+	if err != nil {
+		return err
+	}
+	if masterKeySource == nil && !insecurePlaintextSecrets {
+		return auth.ErrNoMasterKey
+	}
+
+	secretProvider, err := config.NewSecretProvider()
 	if err != nil {
 		return fmt.Errorf("failed to load HMAC secrets: %w", err)
 	}
+	secrets := secretProvider.Current().Secrets(time.Now())
 	if len(secrets) == 0 {
-		return fmt.Errorf("no HMAC secrets configured (set TK_HMAC_SECRET environment variable)")
+		return fmt.Errorf("no HMAC secrets configured (set TK_HMAC_SECRET or TK_HMAC_SECRETS_FILE)")
 	}
 
 	authenticator := auth.NewAuthenticator(secrets, queries)
 
+	// masterKeySource is a typed nil (*auth.StaticMasterKey) when unset -
+	// pass it through a plain var of the interface type so KeyVault's own
+	// "master == nil" checks (the insecure-plaintext path) see a true
+	// nil interface instead of a non-nil interface wrapping a nil pointer.
+	var keyVaultMaster auth.MasterKeySource
+	if masterKeySource != nil {
+		keyVaultMaster = masterKeySource
+	}
+	authenticator.SetKeyVault(auth.NewKeyVault(keyVaultMaster, queries, insecurePlaintextSecrets))
+
+	var metrics *observability.Metrics
+	if cfg.Observability.MetricsEnabled {
+		metrics = observability.NewMetrics()
+	}
+
+	go func() {
+		for snapshot := range secretProvider.Subscribe() {
+			authenticator.SetSecrets(snapshot.Secrets(time.Now()))
+			if metrics != nil {
+				metrics.RecordSecretRotation(secretProviderSource(secretProvider))
+			}
+		}
+	}()
+
 	rulesEngine := rules.NewEngine()
+	rulesEngine.AdaptiveReordering = cfg.Rules.AdaptiveReordering
+	rulesEngine.ReorderEvery = cfg.Rules.ReorderEvery
 
 	service, err := api.NewSensorAPIService(database, rulesEngine, cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create service: %w", err)
 	}
+	defer func() {
+		if err := service.Close(); err != nil {
+			log.Printf("service shutdown: %v", err)
+		}
+	}()
 
-	grpcServer, err := server.NewGRPCServer(cfg, service, authenticator)
+	auditSink, err := observability.NewSinkFromConfig(cfg.Observability, database)
+	if err != nil {
+		return fmt.Errorf("failed to configure audit sink: %w", err)
+	}
+	authenticator.SetAuditSink(auditSink)
+
+	if metrics != nil {
+		authenticator.SetMetrics(metrics)
+		service.SetMetrics(metrics)
+
+		go func() {
+			if err := metrics.Serve(cfg.Observability.MetricsAddr); err != nil {
+				log.Printf("metrics listener stopped: %v", err)
+			}
+		}()
+	}
+
+	if cfg.Observability.TracingEnabled {
+		shutdownTracer, err := observability.InitTracer(ctx, cfg.Observability.ServiceName, cfg.Observability.OTLPEndpoint)
+		if err != nil {
+			return fmt.Errorf("failed to init tracer: %w", err)
+		}
+		defer func() {
+			if err := shutdownTracer(context.Background()); err != nil {
+				log.Printf("tracer shutdown: %v", err)
+			}
+		}()
+	}
+
+	// JWT is an optional peer to HMAC API keys (cfg.JWT.Enabled), the same
+	// opt-in-per-sensor pattern cfg.Observability.* uses above. When
+	// enabled, a ChainAuthenticator tries HMAC first (the existing
+	// default) and falls back to the JWT bearer token, so sensors can
+	// migrate from one to the other gradually rather than all at once.
+	var interceptor auth.Interceptor = authenticator
+	if cfg.JWT.Enabled {
+		jwksRefresh := cfg.JWT.JWKSRefreshInterval
+		if jwksRefresh == 0 {
+			jwksRefresh = config.DefaultJWTJWKSRefreshInterval
+		}
+		jwks, err := auth.NewJWKSCache(cfg.JWT.JWKSURL, jwksRefresh)
+		if err != nil {
+			return fmt.Errorf("failed to fetch JWKS: %w", err)
+		}
+		defer jwks.Close()
+
+		jwtAuthenticator, err := auth.NewJWTAuthenticator(auth.JWTAuthenticatorConfig{
+			Issuer:          cfg.JWT.Issuer,
+			Audience:        cfg.JWT.Audience,
+			JWKS:            jwks,
+			TenantClaimPath: cfg.JWT.TenantClaimPath,
+			Leeway:          cfg.JWT.Leeway,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create JWT authenticator: %w", err)
+		}
+
+		interceptor = auth.NewChainAuthenticator(authenticator, jwtAuthenticator)
+	}
+
+	grpcServer, err := server.NewGRPCServer(cfg, service, interceptor)
 	if err != nil {
 		return fmt.Errorf("failed to create server: %w", err)
 	}
@@ -112,3 +214,17 @@ func runSensorAPI(cmd *cobra.Command, args []string) error {
 		return grpcServer.Shutdown(ctx)
 	}
 }
+
+// secretProviderSource labels a config.SecretProvider for
+// Metrics.RecordSecretRotation, distinguishing a SIGHUP-triggered
+// EnvProvider reload from an fsnotify-triggered FileProvider one.
+func secretProviderSource(p config.SecretProvider) string {
+	switch p.(type) {
+	case *config.FileProvider:
+		return "file"
+	case *config.EnvProvider:
+		return "env"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
+	"github.com/solatis/trapperkeeper/internal/rules"
+	"github.com/solatis/trapperkeeper/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This handler assumes ExplainRuleRequest carries rule (*pb.Rule) and an
+// optional sample_event (bytes, raw JSON), and that ExplainRuleResponse
+// mirrors rules.ExplainResult - priority/or_penalty/sample_penalty plus
+// repeated or_groups of conditions, each with field_path/operator/
+// field_type, a cost breakdown (lookup_cost/op_cost/type_multiplier/
+// wildcard_exec_mult), and - when sample_event was supplied - evaluated/
+// matched/short_circuited/coerced_value/policy - extending the proto
+// definitions that would generate pb.ExplainRuleRequest/
+// ExplainRuleResponse. internal/protobuf/trapperkeeper/sensor/v1 isn't
+// checked into this tree (see the same gap noted in sync_rules.go), so
+// those fields can't actually be added or regenerated here; this file is
+// written against the request/response shape they'd produce once that
+// package exists.
+
+// ExplainRule runs rules.ExplainRule against req.Rule (and, when supplied,
+// req.SampleEvent) and returns the resulting plan - see
+// internal/rules/explain.go for what that plan contains and why it
+// doesn't reuse rules.Evaluate directly.
+func (s *SensorAPIService) ExplainRule(ctx context.Context, req *pb.ExplainRuleRequest) (*pb.ExplainRuleResponse, error) {
+	if req.Rule == nil {
+		return nil, status.Error(codes.InvalidArgument, "rule required")
+	}
+
+	rule := pbRuleToTypesRule(req.Rule)
+
+	result, err := rules.ExplainRule(ctx, rule, req.SampleEvent)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return explainResultToPB(result), nil
+}
+
+// pbRuleToTypesRule converts a request's *pb.Rule into the types.Rule
+// rules.Compile/rules.ExplainRule operate on - the reverse of
+// rowToPBRule's conversion (sync_rules.go), which only ever runs one
+// direction (database row -> wire). pb.Rule/pb.OrGroup/pb.Condition are
+// assumed structurally equivalent to their types.* counterparts, the same
+// assumption rowToPBRule's JSON round-trip through the expression column
+// already relies on.
+func pbRuleToTypesRule(r *pb.Rule) *types.Rule {
+	orGroups := make([]types.OrGroup, len(r.OrGroups))
+	for i, g := range r.OrGroups {
+		conditions := make([]types.Condition, len(g.Conditions))
+		for j, c := range g.Conditions {
+			conditions[j] = types.Condition{
+				FieldPath:        pbPathToTypesPath(c.FieldPath),
+				FieldRef:         pbPathToTypesPath(c.FieldRef),
+				Operator:         int(c.Operator),
+				FieldType:        int(c.FieldType),
+				Value:            c.Value,
+				Values:           c.Values,
+				OnMissingField:   int(c.OnMissingField),
+				OnCoercionFail:   int(c.OnCoercionFail),
+				PreserveIntegers: c.PreserveIntegers,
+				Quantifier:       pbQuantifierToTypes(c.Quantifier),
+			}
+		}
+		orGroups[i] = types.OrGroup{Conditions: conditions}
+	}
+
+	return &types.Rule{
+		RuleID:           types.RuleID(r.RuleId),
+		Name:             r.Name,
+		SampleRate:       r.SampleRate,
+		OrGroups:         orGroups,
+		Action:           int(r.Action),
+		SamplingStrategy: int(r.SamplingStrategy),
+		SampleKeyPath:    pbPathToTypesPath(r.SampleKeyPath),
+	}
+}
+
+// pbPathToTypesPath converts a repeated pb.PathSegment to []types.PathSegment.
+func pbPathToTypesPath(segs []*pb.PathSegment) []types.PathSegment {
+	if segs == nil {
+		return nil
+	}
+	out := make([]types.PathSegment, len(segs))
+	for i, seg := range segs {
+		out[i] = types.PathSegment{
+			Key:      seg.Key,
+			Index:    int(seg.Index),
+			IsIndex:  seg.IsIndex,
+			Wildcard: seg.Wildcard,
+		}
+	}
+	return out
+}
+
+// pbQuantifierToTypes converts an optional pb.QuantifierClause to its
+// types.QuantifierClause equivalent, returning nil for a condition with no
+// quantifier (ANY_OF/ALL_OF/COUNT/NONE_OF are the only operators that set one).
+func pbQuantifierToTypes(q *pb.QuantifierClause) *types.QuantifierClause {
+	if q == nil {
+		return nil
+	}
+	return &types.QuantifierClause{
+		Operator:      int(q.Operator),
+		Value:         q.Value,
+		CountOperator: int(q.CountOperator),
+		CountValue:    q.CountValue,
+	}
+}
+
+// explainResultToPB converts rules.ExplainResult to its proto response
+// shape (see the gap note above this file's ExplainRule).
+func explainResultToPB(result *rules.ExplainResult) *pb.ExplainRuleResponse {
+	orGroups := make([]*pb.ExplainedOrGroup, len(result.OrGroups))
+	for i, g := range result.OrGroups {
+		conditions := make([]*pb.ExplainedCondition, len(g.Conditions))
+		for j, c := range g.Conditions {
+			conditions[j] = &pb.ExplainedCondition{
+				FieldPath:        pathToPB(c.Path),
+				Operator:         pb.Operator(c.Operator),
+				FieldType:        pb.FieldType(c.FieldType),
+				LookupCost:       int32(c.Cost.LookupCost),
+				OpCost:           int32(c.Cost.OpCost),
+				TypeMultiplier:   int32(c.Cost.TypeMultiplier),
+				WildcardExecMult: int32(c.Cost.WildcardExecMult),
+				TotalCost:        int32(c.Cost.Total),
+				Evaluated:        c.Evaluated,
+				Matched:          c.Matched,
+				ShortCircuited:   c.ShortCircuited,
+				CoercedValueJson: coercedValueJSON(c.CoercedValue),
+				Policy:           c.Policy,
+			}
+		}
+		orGroups[i] = &pb.ExplainedOrGroup{Conditions: conditions}
+	}
+
+	return &pb.ExplainRuleResponse{
+		Priority:      int32(result.Priority),
+		OrPenalty:     int32(result.OrPenalty),
+		SamplePenalty: int32(result.SamplePenalty),
+		OrGroups:      orGroups,
+		Matched:       result.Matched,
+	}
+}
+
+// coercedValueJSON renders a traced condition's coerced value as JSON for
+// ExplainedCondition.CoercedValueJson - pb has no dynamic-value type (no
+// google.protobuf.Value) in this tree to carry an `any` natively, and the
+// coerced value can be a plain JSON type, a time.Time, or a netip.Addr/
+// Prefix (see internal/rules/coercion.go), none of which marshal usefully
+// through fmt.Sprint alone. A marshal failure (e.g. an unexpected type)
+// falls back to fmt.Sprint rather than dropping the value entirely.
+func coercedValueJSON(v any) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// pathToPB converts []types.PathSegment to its repeated pb.PathSegment form.
+func pathToPB(path []types.PathSegment) []*pb.PathSegment {
+	if path == nil {
+		return nil
+	}
+	out := make([]*pb.PathSegment, len(path))
+	for i, seg := range path {
+		out[i] = &pb.PathSegment{
+			Key:      seg.Key,
+			Index:    int32(seg.Index),
+			IsIndex:  seg.IsIndex,
+			Wildcard: seg.Wildcard,
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/solatis/trapperkeeper/internal/core/auth"
+	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// This handler assumes GetEventByHashRequest carries payload_hash (string)
+// and GetEventByHashResponse carries found (bool), file_path (string), and
+// file_offset (int64) - extending the proto definitions that would
+// generate pb.GetEventByHashRequest/GetEventByHashResponse.
+// internal/protobuf/trapperkeeper/sensor/v1 isn't checked into this tree
+// (see the same gap noted in sync_rules.go), so those fields can't
+// actually be added or regenerated here; this file is written against the
+// request/response shape they'd produce once that package exists.
+
+// eventLocationRow is the database projection GetEventByHash converts to
+// its response.
+type eventLocationRow struct {
+	FilePath   string `db:"file_path"`
+	FileOffset int64  `db:"file_offset"`
+}
+
+// GetEventByHash looks up the JSONL location of a previously ingested
+// event by its content hash (see hashEventPayload in report_events.go),
+// so a sensor that isn't sure whether a ReportEvents call landed - e.g.
+// after a timeout with no response - can confirm delivery without
+// resubmitting the event and relying on ReportEvents' own dedup path.
+func (s *SensorAPIService) GetEventByHash(ctx context.Context, req *pb.GetEventByHashRequest) (*pb.GetEventByHashResponse, error) {
+	tenantID := auth.TenantIDFromContext(ctx)
+	if tenantID == "" {
+		return nil, status.Error(codes.Internal, "missing tenant_id in context")
+	}
+	if req.GetPayloadHash() == "" {
+		return nil, status.Error(codes.InvalidArgument, "payload_hash required")
+	}
+
+	var row eventLocationRow
+	query := `
+		SELECT file_path, file_offset
+		FROM events
+		WHERE tenant_id = ? AND payload_hash = ?
+	`
+
+	start := time.Now()
+	err := s.db.GetContext(ctx, &row, s.db.Rebind(query), tenantID, req.GetPayloadHash())
+	if s.metrics != nil {
+		s.metrics.RecordDBQueryDuration(time.Since(start))
+	}
+	if err == sql.ErrNoRows {
+		return &pb.GetEventByHashResponse{Found: false}, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, fmt.Sprintf("failed to query event by hash: %v", err))
+	}
+
+	return &pb.GetEventByHashResponse{
+		Found:      true,
+		FilePath:   row.FilePath,
+		FileOffset: row.FileOffset,
+	}, nil
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultJSONLSyncInterval and DefaultJSONLMaxBufferedBytes are
+// jsonlWriter's tuning defaults when NewSensorAPIService is given a zero
+// value for either (see config.SensorAPIConfig.JSONLSyncInterval/
+// JSONLMaxBufferedBytes).
+const (
+	DefaultJSONLSyncInterval     = 1 * time.Second
+	DefaultJSONLMaxBufferedBytes = 256 * 1024
+)
+
+// jsonlAppendResult is delivered back to Append once its event has been
+// written to the buffered writer (not necessarily Sync'd to disk yet).
+type jsonlAppendResult struct {
+	offset int64
+	err    error
+}
+
+type jsonlAppendRequest struct {
+	filename string
+	event    any
+	result   chan jsonlAppendResult
+}
+
+// jsonlWriter replaces the previous per-event open/encode/close (guarded
+// by a per-file mutex) with one long-lived buffered *os.File per daily
+// filename, appended to by a single background goroutine, flushed and
+// Sync'd periodically (or sooner, once syncInterval's buffer fills past
+// maxBufferedBytes) instead of on every event. The caller still picks
+// which daily file an event belongs to (ReportEvents computes it once per
+// batch, same as before, so a batch spanning UTC midnight stays in one
+// file) - jsonlWriter just rotates to whatever filename the next request
+// names, closing the previous file first.
+type jsonlWriter struct {
+	syncInterval time.Duration
+	maxBuffered  int
+
+	requests chan jsonlAppendRequest
+	done     chan struct{}
+	closed   chan struct{}
+
+	mu      sync.Mutex
+	closing bool
+}
+
+// newJSONLWriter starts the background writer goroutine. Call Close to
+// flush and stop it.
+func newJSONLWriter(syncInterval time.Duration, maxBufferedBytes int) *jsonlWriter {
+	if syncInterval <= 0 {
+		syncInterval = DefaultJSONLSyncInterval
+	}
+	if maxBufferedBytes <= 0 {
+		maxBufferedBytes = DefaultJSONLMaxBufferedBytes
+	}
+
+	w := &jsonlWriter{
+		syncInterval: syncInterval,
+		maxBuffered:  maxBufferedBytes,
+		requests:     make(chan jsonlAppendRequest, 256),
+		done:         make(chan struct{}),
+		closed:       make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Append enqueues event as one JSON line in filename and blocks until
+// it's been written to the buffered writer, returning the byte offset its
+// line starts at so the caller's events.file_offset column can point at
+// the exact line. A write failure mid-batch poisons only that one
+// request; the writer keeps serving subsequent requests (possibly against
+// a freshly reopened file on the next append).
+//
+// The closing check and the send to w.requests happen under the same
+// lock Close takes to set closing, so once Close returns no Append can
+// still be in flight toward w.requests - run's drain loop (see run) can
+// then safely empty whatever's left in the channel without racing a
+// straggler send.
+func (w *jsonlWriter) Append(filename string, event any) (int64, error) {
+	w.mu.Lock()
+	if w.closing {
+		w.mu.Unlock()
+		return 0, fmt.Errorf("jsonlWriter: closed")
+	}
+	result := make(chan jsonlAppendResult, 1)
+	w.requests <- jsonlAppendRequest{filename: filename, event: event, result: result}
+	w.mu.Unlock()
+
+	r := <-result
+	return r.offset, r.err
+}
+
+// Close flushes, Syncs, and closes the current file, then stops the
+// background goroutine. Safe to call more than once; only the first call
+// does the work.
+func (w *jsonlWriter) Close() error {
+	w.mu.Lock()
+	if w.closing {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closing = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.closed
+	return nil
+}
+
+// jsonlFileState is the background goroutine's private per-file state -
+// never touched outside run(), so it needs no locking of its own.
+type jsonlFileState struct {
+	filename string
+	f        *os.File
+	buf      *bufio.Writer
+	offset   int64
+}
+
+func openJSONLFile(filename string) (*jsonlFileState, error) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &jsonlFileState{
+		filename: filename,
+		f:        f,
+		buf:      bufio.NewWriter(f),
+		offset:   info.Size(),
+	}, nil
+}
+
+func (w *jsonlWriter) run() {
+	defer close(w.closed)
+
+	var state *jsonlFileState
+	ticker := time.NewTicker(w.syncInterval)
+	defer ticker.Stop()
+
+	flushAndSync := func() {
+		if state == nil {
+			return
+		}
+		_ = state.buf.Flush()
+		_ = state.f.Sync()
+	}
+	closeState := func() {
+		if state == nil {
+			return
+		}
+		flushAndSync()
+		_ = state.f.Close()
+		state = nil
+	}
+	defer closeState()
+
+	for {
+		select {
+		case req := <-w.requests:
+			if state == nil || state.filename != req.filename {
+				closeState()
+				st, err := openJSONLFile(req.filename)
+				if err != nil {
+					req.result <- jsonlAppendResult{err: err}
+					continue
+				}
+				state = st
+			}
+
+			line, err := json.Marshal(req.event)
+			if err != nil {
+				req.result <- jsonlAppendResult{err: err}
+				continue
+			}
+			line = append(line, '\n')
+
+			offset := state.offset
+			if _, err := state.buf.Write(line); err != nil {
+				req.result <- jsonlAppendResult{err: err}
+				continue
+			}
+			state.offset += int64(len(line))
+
+			if state.buf.Buffered() >= w.maxBuffered {
+				flushAndSync()
+			}
+
+			req.result <- jsonlAppendResult{offset: offset}
+
+		case <-ticker.C:
+			flushAndSync()
+
+		case <-w.done:
+			// Append won't send anything new once closing is set (see
+			// Append), but a request sent just before that may already be
+			// sitting in the channel - drain it so its Append doesn't
+			// block forever waiting on a result nobody will send.
+			for {
+				select {
+				case req := <-w.requests:
+					req.result <- jsonlAppendResult{err: fmt.Errorf("jsonlWriter: closed")}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
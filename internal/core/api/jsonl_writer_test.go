@@ -0,0 +1,107 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestJSONLWriter_CloseDuringConcurrentAppend reproduces the race where
+// Close's close(w.done) could race run's own select, leaving an
+// already-enqueued Append blocked forever on a result nobody would ever
+// send. Every Append launched here must return (with either a write or a
+// "closed" error), never hang.
+func TestJSONLWriter_CloseDuringConcurrentAppend(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "events.jsonl")
+
+	w := newJSONLWriter(time.Hour, 1<<20)
+
+	const n = 200
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			_, _ = w.Append(filename, map[string]int{"i": i})
+		}(i)
+	}
+
+	// Close races the in-flight Appends above; none of them should block
+	// forever waiting on a result.
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Append(s) still blocked after Close returned")
+	}
+
+	if _, err := w.Append(filename, map[string]int{"i": -1}); err == nil {
+		t.Fatal("Append after Close: expected error, got nil")
+	}
+}
+
+// TestJSONLWriter_AppendThenClose confirms the ordinary path still works:
+// lines written before Close land in the file, in order, with increasing
+// offsets.
+func TestJSONLWriter_AppendThenClose(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "events.jsonl")
+
+	w := newJSONLWriter(time.Hour, 1<<20)
+
+	var offsets []int64
+	for i := 0; i < 5; i++ {
+		offset, err := w.Append(filename, map[string]int{"i": i})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+		offsets = append(offsets, offset)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	var lines int
+	for scanner.Scan() {
+		var event map[string]int
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Unmarshal line %d: %v", lines, err)
+		}
+		if event["i"] != lines {
+			t.Fatalf("line %d: got i=%d, want %d", lines, event["i"], lines)
+		}
+		lines++
+	}
+	if lines != 5 {
+		t.Fatalf("got %d lines, want 5", lines)
+	}
+	if offsets[0] != 0 {
+		t.Fatalf("first offset = %d, want 0", offsets[0])
+	}
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] <= offsets[i-1] {
+			t.Fatalf("offsets not increasing: %v", offsets)
+		}
+	}
+}
@@ -2,22 +2,72 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"os"
 	"path/filepath"
-	"sync"
+	"strings"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/solatis/trapperkeeper/internal/core/auth"
 	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// preparedEvent holds one event's validated fields, content hash, and JSONL
+// placement, computed during ReportEvents' first pass, ahead of the
+// duplicate pre-check and the single batched INSERT built by
+// insertEventBatch.
+type preparedEvent struct {
+	event            *pb.Event
+	tenantID         string
+	clientTimestamp  string
+	serverReceivedAt time.Time
+	payloadHash      string
+	jsonlFilename    string
+	fileOffset       int64
+}
+
+// hashEventPayload derives an event's content-addressable identity: a
+// SHA-256 hex digest over its deterministic proto encoding. pb.Event in
+// this tree carries no server-assigned fields (no server_received_at,
+// no row id) that would need excluding first - every field on it is
+// client-supplied, so the wire bytes already are the content to hash.
+// Deterministic marshaling is required so two semantically identical
+// events (e.g. a sensor's retried batch) always hash the same way
+// regardless of map/field encoding order.
+func hashEventPayload(event *pb.Event) (string, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("marshal event for hashing: %w", err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // ReportEvents ingests event batch from sensor.
-// Per-event transactions enable partial batch success.
 // JSONL output is best-effort debugging aid, not authoritative.
+// Database persistence is a single batched INSERT per call rather than one
+// transaction per event; insertEventBatch falls back to per-event inserts
+// only when the batch fails on what looks like a constraint violation, so
+// partial batch success is still possible without paying N round trips on
+// the common all-succeed path.
+//
+// Events are deduplicated by content hash (see hashEventPayload) within a
+// tenant. lookupExistingPayloadHashes batches one pre-check query ahead of
+// any JSONL append, so a sensor retrying an already-accepted batch
+// short-circuits before its events are written to disk a second time
+// rather than merely failing a later INSERT. A duplicate detected only at
+// INSERT time (two concurrent requests racing the same event past the
+// pre-check) is still accepted rather than erroring - see
+// isPayloadHashViolation.
+//
+// This relies on the UNIQUE INDEX idx_events_tenant_payload_hash on
+// events(tenant_id, payload_hash), created by
+// migrations/{sqlite,postgres}/0001_init.up.sql.
 func (s *SensorAPIService) ReportEvents(ctx context.Context, req *pb.ReportEventsRequest) (*pb.ReportEventsResponse, error) {
 	tenantID := auth.TenantIDFromContext(ctx)
 	if tenantID == "" {
@@ -34,14 +84,94 @@ func (s *SensorAPIService) ReportEvents(ctx context.Context, req *pb.ReportEvent
 	// All events in batch written to same file even if processing spans midnight
 	now := time.Now().UTC()
 	jsonlFilename := filepath.Join(s.cfg.DataDir, "events", now.Format("2006-01-02.jsonl"))
-	jsonlMutex := s.getJSONLMutex(jsonlFilename)
 
 	results := make([]*pb.EventResult, len(req.Events))
-	acceptedCount := int32(0)
+	prepared := make([]*preparedEvent, len(req.Events))
 
+	// Pass 1: validate and hash. No JSONL append yet - whether an event is
+	// written at all depends on the duplicate pre-check below.
+	hashes := make([]string, 0, len(req.Events))
 	for i, event := range req.Events {
-		result := s.processEvent(ctx, tenantID, event, jsonlFilename, jsonlMutex)
-		results[i] = result
+		if event.EventId == "" {
+			results[i] = &pb.EventResult{
+				EventId:      event.EventId,
+				Status:       pb.EventStatus_EVENT_STATUS_REJECTED,
+				ErrorMessage: "event_id required",
+			}
+			continue
+		}
+
+		payloadHash, err := hashEventPayload(event)
+		if err != nil {
+			results[i] = &pb.EventResult{
+				EventId:      event.EventId,
+				Status:       pb.EventStatus_EVENT_STATUS_REJECTED,
+				ErrorMessage: fmt.Sprintf("hash event: %v", err),
+			}
+			continue
+		}
+
+		serverReceivedAt := time.Now().UTC()
+		var clientTimestamp string
+		if event.ClientTimestamp != nil {
+			clientTimestamp = event.ClientTimestamp.AsTime().UTC().Format(time.RFC3339)
+		} else {
+			clientTimestamp = serverReceivedAt.Format(time.RFC3339)
+		}
+
+		prepared[i] = &preparedEvent{
+			event:            event,
+			tenantID:         tenantID,
+			clientTimestamp:  clientTimestamp,
+			serverReceivedAt: serverReceivedAt,
+			payloadHash:      payloadHash,
+			jsonlFilename:    jsonlFilename,
+		}
+		hashes = append(hashes, payloadHash)
+	}
+
+	existing, err := s.lookupExistingPayloadHashes(ctx, tenantID, hashes)
+	if err != nil {
+		// The pre-check is an optimization, not a correctness requirement -
+		// isPayloadHashViolation still catches duplicates at INSERT time.
+		// Treat a failed lookup as "nothing found" rather than erroring the
+		// whole batch over what insertEventBatch can still resolve.
+		existing = map[string]bool{}
+	}
+
+	// Pass 2: append non-duplicate events to JSONL (the short-circuit),
+	// skipping both hashes already in the database and hashes repeated
+	// earlier in this same batch.
+	seenInBatch := make(map[string]bool, len(hashes))
+	var duplicatesSuppressed int32
+	for i, p := range prepared {
+		if p == nil {
+			continue
+		}
+		if existing[p.payloadHash] || seenInBatch[p.payloadHash] {
+			results[i] = &pb.EventResult{
+				EventId: p.event.EventId,
+				Status:  pb.EventStatus_EVENT_STATUS_ACCEPTED,
+			}
+			duplicatesSuppressed++
+			prepared[i] = nil
+			continue
+		}
+		seenInBatch[p.payloadHash] = true
+
+		// JSONL is a debugging aid, not authoritative - a write failure
+		// here loses the offset but doesn't reject the event.
+		fileOffset, err := s.jsonl.Append(p.jsonlFilename, p.event)
+		if err != nil {
+			fileOffset = 0
+		}
+		p.fileOffset = fileOffset
+	}
+
+	s.insertEventBatch(ctx, prepared, results, &duplicatesSuppressed)
+
+	acceptedCount := int32(0)
+	for _, result := range results {
 		if result.Status == pb.EventStatus_EVENT_STATUS_ACCEPTED {
 			acceptedCount++
 		}
@@ -53,66 +183,177 @@ func (s *SensorAPIService) ReportEvents(ctx context.Context, req *pb.ReportEvent
 	}, nil
 }
 
-// processEvent validates, persists, and logs single event in own transaction.
-// Per-event transactions enable partial batch success when some events fail.
-func (s *SensorAPIService) processEvent(ctx context.Context, tenantID string, event *pb.Event, jsonlFilename string, jsonlMutex *sync.Mutex) *pb.EventResult {
-	// Validate event structure
-	if event.EventId == "" {
-		return &pb.EventResult{
-			EventId:      event.EventId,
-			Status:       pb.EventStatus_EVENT_STATUS_REJECTED,
-			ErrorMessage: "event_id required",
+// lookupExistingPayloadHashes returns the subset of hashes already stored
+// for tenantID, batched into one query via sqlx.In rather than one SELECT
+// per event (see (*db.Queries).In for this tree's established sqlx.In
+// usage). An empty hashes slice short-circuits before the query -
+// sqlx.In errors on an empty IN-list.
+func (s *SensorAPIService) lookupExistingPayloadHashes(ctx context.Context, tenantID string, hashes []string) (map[string]bool, error) {
+	if len(hashes) == 0 {
+		return map[string]bool{}, nil
+	}
+
+	query, args, err := sqlx.In("SELECT payload_hash FROM events WHERE tenant_id = ? AND payload_hash IN (?)", tenantID, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("build payload hash lookup query: %w", err)
+	}
+
+	var found []string
+	if err := s.db.SelectContext(ctx, &found, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("lookup existing payload hashes: %w", err)
+	}
+
+	result := make(map[string]bool, len(found))
+	for _, h := range found {
+		result[h] = true
+	}
+	return result, nil
+}
+
+// insertEventBatch inserts every non-nil entry of prepared in one
+// multi-row INSERT, writing each row's outcome into the matching slot of
+// results. On a likely constraint violation it retries row-by-row via
+// insertSingleEvent so a single duplicate event_id or payload_hash doesn't
+// fail its batch-mates; any other database error (e.g. a dropped
+// connection) marks the whole batch EVENT_STATUS_ERROR without the extra
+// round trips. duplicatesSuppressed is incremented for any row that turns
+// out to be a payload_hash duplicate caught only here (the
+// lookupExistingPayloadHashes pre-check missed it because a concurrent
+// request inserted it in between).
+func (s *SensorAPIService) insertEventBatch(ctx context.Context, prepared []*preparedEvent, results []*pb.EventResult, duplicatesSuppressed *int32) {
+	rows := make([]*preparedEvent, 0, len(prepared))
+	for _, p := range prepared {
+		if p != nil {
+			rows = append(rows, p)
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	query, args := buildBatchInsertQuery(rows)
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...)
+	if err == nil {
+		for i, p := range prepared {
+			if p != nil {
+				results[i] = &pb.EventResult{
+					EventId: p.event.EventId,
+					Status:  pb.EventStatus_EVENT_STATUS_ACCEPTED,
+				}
+			}
+		}
+		return
+	}
+
+	if !isConstraintViolation(err) {
+		for i, p := range prepared {
+			if p != nil {
+				results[i] = &pb.EventResult{
+					EventId:      p.event.EventId,
+					Status:       pb.EventStatus_EVENT_STATUS_ERROR,
+					ErrorMessage: fmt.Sprintf("database error: %v", err),
+				}
+			}
+		}
+		return
+	}
+
+	for i, p := range prepared {
+		if p != nil {
+			var duplicate bool
+			results[i], duplicate = s.insertSingleEvent(ctx, p)
+			if duplicate {
+				*duplicatesSuppressed++
+			}
 		}
 	}
+}
 
-	// Insert to database (own transaction)
-	// JSONL may contain events not in database (if DB insert failed)
-	// Database is source of truth, JSONL is debugging aid
-	insertQuery := `
-		INSERT INTO events (event_id, tenant_id, client_timestamp, server_received_at, file_path, file_offset, payload_hash, matched_rule_count, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	serverReceivedAt := time.Now().UTC()
-
-	// Convert proto timestamp to RFC3339 string for database
-	var clientTimestamp string
-	if event.ClientTimestamp != nil {
-		clientTimestamp = event.ClientTimestamp.AsTime().UTC().Format(time.RFC3339)
-	} else {
-		clientTimestamp = serverReceivedAt.Format(time.RFC3339)
-	}
-
-	_, err := s.db.ExecContext(ctx, s.db.Rebind(insertQuery),
-		event.EventId,
-		tenantID,
-		clientTimestamp,
-		serverReceivedAt.Format(time.RFC3339),
-		jsonlFilename,
-		0, // file_offset computed during JSONL write
-		"", // payload_hash: empty (content-addressable indexing not implemented)
-		0,  // matched_rule_count: computed during evaluation
-		serverReceivedAt.Format(time.RFC3339),
-	)
+// insertSingleEvent inserts one event on its own. Used as insertEventBatch's
+// fallback once a multi-row INSERT has failed on a constraint violation and
+// the offending row(s) need isolating. A payload_hash violation here means
+// a concurrent request won the race to insert this exact content after
+// ReportEvents' own pre-check ran - that's still a successful delivery
+// from the sensor's point of view, so it's reported ACCEPTED rather than
+// ERROR, the same as a duplicate caught by the pre-check. The returned bool
+// reports whether the event was such a payload_hash duplicate.
+func (s *SensorAPIService) insertSingleEvent(ctx context.Context, p *preparedEvent) (*pb.EventResult, bool) {
+	query, args := buildBatchInsertQuery([]*preparedEvent{p})
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...)
 	if err != nil {
+		if isPayloadHashViolation(err) {
+			return &pb.EventResult{
+				EventId: p.event.EventId,
+				Status:  pb.EventStatus_EVENT_STATUS_ACCEPTED,
+			}, true
+		}
 		return &pb.EventResult{
-			EventId:      event.EventId,
+			EventId:      p.event.EventId,
 			Status:       pb.EventStatus_EVENT_STATUS_ERROR,
 			ErrorMessage: fmt.Sprintf("database error: %v", err),
+		}, false
+	}
+	return &pb.EventResult{
+		EventId: p.event.EventId,
+		Status:  pb.EventStatus_EVENT_STATUS_ACCEPTED,
+	}, false
+}
+
+// buildBatchInsertQuery renders one multi-row INSERT covering all of rows,
+// in "?"-placeholder form for s.db.Rebind to translate to the driver's
+// native placeholder syntax.
+func buildBatchInsertQuery(rows []*preparedEvent) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO events (event_id, tenant_id, client_timestamp, server_received_at, file_path, file_offset, payload_hash, matched_rule_count, created_at) VALUES ")
+
+	args := make([]interface{}, 0, len(rows)*9)
+	for i, p := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
 		}
+		sb.WriteString("(?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			p.event.EventId,
+			p.tenantID,
+			p.clientTimestamp,
+			p.serverReceivedAt.Format(time.RFC3339),
+			p.jsonlFilename,
+			p.fileOffset,
+			p.payloadHash,
+			0, // matched_rule_count: computed during evaluation
+			p.serverReceivedAt.Format(time.RFC3339),
+		)
 	}
 
-	// Write to JSONL (best-effort, with mutex protection)
-	jsonlMutex.Lock()
-	defer jsonlMutex.Unlock()
-	f, err := os.OpenFile(jsonlFilename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err == nil {
-		defer f.Close()
-		encoder := json.NewEncoder(f)
-		_ = encoder.Encode(event)
+	return sb.String(), args
+}
+
+// isConstraintViolation reports whether err looks like a unique/primary
+// key constraint failure rather than some other database error. A
+// multi-row INSERT failure doesn't reliably expose which VALUES(...) tuple
+// was at fault in a driver-agnostic way, so this is a substring match
+// against sqlite3/postgres/mysql's wording rather than a parse of the
+// offending event_id.
+func isConstraintViolation(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, substr := range []string{"unique constraint", "constraint failed", "duplicate key", "duplicate entry"} {
+		if strings.Contains(msg, substr) {
+			return true
+		}
 	}
+	return false
+}
 
-	return &pb.EventResult{
-		EventId: event.EventId,
-		Status:  pb.EventStatus_EVENT_STATUS_ACCEPTED,
+// isPayloadHashViolation narrows isConstraintViolation's match to
+// specifically the events(tenant_id, payload_hash) unique index, by
+// looking for that index's name (or column name) in the driver's error
+// message. Like isConstraintViolation, this is a substring match rather
+// than a driver-specific error type assertion, consistent with this
+// file's existing driver-agnostic error handling.
+func isPayloadHashViolation(err error) bool {
+	if err == nil {
+		return false
 	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "idx_events_tenant_payload_hash") || strings.Contains(msg, "payload_hash")
 }
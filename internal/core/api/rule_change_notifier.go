@@ -0,0 +1,92 @@
+// internal/core/api/rule_change_notifier.go
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+ * RuleChangeNotifier is the per-tenant pub/sub backing SyncRules' long-poll
+ * support: a tenant's rule CRUD path calls Notify(tenantID) whenever it
+ * commits a create/update/delete, and a blocked SyncRules call waiting on
+ * Wait(ctx, tenantID, timeout) wakes immediately instead of polling.
+ *
+ * Nothing in this package calls Notify: its handlers (SyncRules,
+ * ReportEvents, GetEventByHash, ExplainRule) never change rules. Without a
+ * caller RuleChangeNotifier only ever times out a waiter, which is exactly
+ * the safe degenerate case (SyncRules falls back to its existing
+ * unchanged-ETag response).
+ */
+
+// RuleChangeNotifier fans out a tenant's rule-change signal to every
+// SyncRules call currently long-polling for that tenant. The zero value is
+// not usable; construct with NewRuleChangeNotifier.
+type RuleChangeNotifier struct {
+	mu   sync.Mutex
+	subs map[string][]chan struct{}
+}
+
+// NewRuleChangeNotifier returns a ready-to-use RuleChangeNotifier.
+func NewRuleChangeNotifier() *RuleChangeNotifier {
+	return &RuleChangeNotifier{subs: make(map[string][]chan struct{})}
+}
+
+// Notify wakes every call currently blocked in Wait for tenantID. Safe to
+// call whether or not anyone is waiting.
+func (n *RuleChangeNotifier) Notify(tenantID string) {
+	n.mu.Lock()
+	chans := n.subs[tenantID]
+	delete(n.subs, tenantID)
+	n.mu.Unlock()
+
+	for _, ch := range chans {
+		close(ch)
+	}
+}
+
+// subscribe registers ch to be closed by the next Notify(tenantID).
+func (n *RuleChangeNotifier) subscribe(tenantID string, ch chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subs[tenantID] = append(n.subs[tenantID], ch)
+}
+
+// unsubscribe removes ch from tenantID's waiter list, used once a Wait call
+// returns via ctx/timeout rather than Notify so its channel isn't held
+// (and closed a second time) by a future Notify call.
+func (n *RuleChangeNotifier) unsubscribe(tenantID string, ch chan struct{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	chans := n.subs[tenantID]
+	for i, c := range chans {
+		if c == ch {
+			n.subs[tenantID] = append(chans[:i], chans[i+1:]...)
+			break
+		}
+	}
+}
+
+// Wait blocks until tenantID's rules change (Notify is called), timeout
+// elapses, or ctx is canceled - whichever comes first. Returns true only
+// when woken by Notify, so SyncRules knows whether to recompute its
+// response or fall back to the unchanged-ETag reply.
+func (n *RuleChangeNotifier) Wait(ctx context.Context, tenantID string, timeout time.Duration) bool {
+	ch := make(chan struct{})
+	n.subscribe(tenantID, ch)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-ch:
+		return true
+	case <-timer.C:
+		n.unsubscribe(tenantID, ch)
+		return false
+	case <-ctx.Done():
+		n.unsubscribe(tenantID, ch)
+		return false
+	}
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sync"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/solatis/trapperkeeper/internal/core/config"
+	"github.com/solatis/trapperkeeper/internal/observability"
 	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
 	"github.com/solatis/trapperkeeper/internal/rules"
 )
@@ -17,15 +17,18 @@ import (
 // Thin orchestration layer delegating to auth, rules, and database packages.
 type SensorAPIService struct {
 	pb.UnimplementedSensorAPIServer
-	db           *sqlx.DB
-	rulesEngine  *rules.Engine
-	cfg          *config.SensorAPIConfig
-	jsonlMutexes map[string]*sync.Mutex
-	mutexLock    sync.Mutex
+	db          *sqlx.DB
+	rulesEngine *rules.Engine
+	cfg         *config.SensorAPIConfig
+	jsonl       *jsonlWriter
+	ruleChanges *RuleChangeNotifier
+
+	metrics *observability.Metrics
 }
 
 // NewSensorAPIService creates service instance with dependencies.
-// Auto-creates events directory if not exists.
+// Auto-creates events directory if not exists, and starts the long-lived
+// jsonlWriter ReportEvents appends through (see jsonl_writer.go).
 func NewSensorAPIService(db *sqlx.DB, rulesEngine *rules.Engine, cfg *config.SensorAPIConfig) (*SensorAPIService, error) {
 	if db == nil {
 		return nil, fmt.Errorf("db cannot be nil")
@@ -43,22 +46,24 @@ func NewSensorAPIService(db *sqlx.DB, rulesEngine *rules.Engine, cfg *config.Sen
 	}
 
 	return &SensorAPIService{
-		db:           db,
-		rulesEngine:  rulesEngine,
-		cfg:          cfg,
-		jsonlMutexes: make(map[string]*sync.Mutex),
+		db:          db,
+		rulesEngine: rulesEngine,
+		cfg:         cfg,
+		jsonl:       newJSONLWriter(cfg.JSONLSyncInterval, cfg.JSONLMaxBufferedBytes),
+		ruleChanges: NewRuleChangeNotifier(),
 	}, nil
 }
 
-// getJSONLMutex returns mutex for given filename, creating if not exists.
-// Per-file mutex protects concurrent writes to same daily JSONL file.
-// Mutex map grows by ~1 entry/day (acceptable memory footprint for annual lifecycle).
-func (s *SensorAPIService) getJSONLMutex(filename string) *sync.Mutex {
-	s.mutexLock.Lock()
-	defer s.mutexLock.Unlock()
+// SetMetrics wires Prometheus sync_rules_bytes/db_query_duration_seconds
+// recording. A nil *Metrics (the default) means SyncRules and its query
+// helpers skip recording rather than panic.
+func (s *SensorAPIService) SetMetrics(m *observability.Metrics) {
+	s.metrics = m
+}
 
-	if _, ok := s.jsonlMutexes[filename]; !ok {
-		s.jsonlMutexes[filename] = &sync.Mutex{}
-	}
-	return s.jsonlMutexes[filename]
+// Close flushes and stops the service's jsonlWriter. Callers should defer
+// this once after NewSensorAPIService succeeds, the same way they defer
+// db.Close().
+func (s *SensorAPIService) Close() error {
+	return s.jsonl.Close()
 }
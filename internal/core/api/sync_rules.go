@@ -9,124 +9,259 @@ import (
 	"time"
 
 	"github.com/solatis/trapperkeeper/internal/core/auth"
+	"github.com/solatis/trapperkeeper/internal/observability"
 	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// SyncRules returns rules matching requested tags.
-// ETAG-based caching minimizes bandwidth when rules unchanged.
-// Returns up to 10,000 rules per tenant.
+// This handler assumes SyncRulesRequest carries if_none_match (string),
+// since_created_at (timestamp), and long_poll_timeout (duration), and that
+// SyncRulesResponse carries tombstones (repeated string) - extending the
+// proto definitions that generate pb.SyncRulesRequest/SyncRulesResponse.
+// internal/protobuf/trapperkeeper/sensor/v1 isn't checked into this tree
+// (see the expr.go note in internal/rules for the same gap), so those
+// fields can't actually be added or regenerated here; this file is written
+// against the request/response shape they'd produce once that package
+// exists.
+
+// ruleRow is the database projection SyncRules converts to pb.Rule. A
+// separate type (rather than anonymous structs per call site, as before)
+// so queryRules and computeETAG share one definition.
+type ruleRow struct {
+	RuleID     string  `db:"rule_id"`
+	Name       string  `db:"name"`
+	State      string  `db:"state"`
+	Action     string  `db:"action"`
+	Expression string  `db:"expression"`
+	SampleRate float64 `db:"sample_rate"`
+	ScopeTags  string  `db:"scope_tags"`
+	CreatedAt  string  `db:"created_at"`
+}
+
+// SyncRules returns rules matching requested tags, with conditional and
+// incremental fetch support:
+//
+//   - if_none_match: when it matches the freshly computed ETag and
+//     long_poll_timeout is zero, the call returns immediately with
+//     codes.Aborted rather than re-shipping an unchanged rule set.
+//   - long_poll_timeout: when if_none_match matches and this is > 0, the
+//     call blocks (via the per-tenant RuleChangeNotifier, woken by its
+//     Notify) until a change arrives or the timeout elapses. A change is
+//     answered with a freshly recomputed response; a timeout returns the
+//     unchanged response computed before waiting, not codes.Aborted.
+//   - since_created_at: when set, only rules created after it are
+//     returned, turning a re-sync into an incremental delta instead of the
+//     full (up to 10,000-rule) set.
+//
+// ETAG-based caching minimizes bandwidth when rules are unchanged; it is
+// content-addressable (computeETAG), so identical rule sets - even across
+// different tenants - map to identical ETags deterministically.
 func (s *SensorAPIService) SyncRules(ctx context.Context, req *pb.SyncRulesRequest) (*pb.SyncRulesResponse, error) {
+	ctx, span := observability.StartSpan(ctx, "SyncRules")
+	defer span.End()
+
 	tenantID := auth.TenantIDFromContext(ctx)
 	if tenantID == "" {
 		return nil, status.Error(codes.Internal, "missing tenant_id in context")
 	}
 
-	// Query rules by tags
-	// If no tags specified, return all rules for tenant
-	var rules []struct {
-		RuleID     string  `db:"rule_id"`
-		Name       string  `db:"name"`
-		State      string  `db:"state"`
-		Action     string  `db:"action"`
-		Expression string  `db:"expression"`
-		SampleRate float64 `db:"sample_rate"`
-		ScopeTags  string  `db:"scope_tags"`
-		CreatedAt  string  `db:"created_at"`
+	resp, etag, err := s.buildSyncRulesResponse(ctx, tenantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	longPoll := req.GetLongPollTimeout().AsDuration()
+	if req.GetIfNoneMatch() != "" && req.GetIfNoneMatch() == etag {
+		if longPoll <= 0 {
+			return nil, status.Error(codes.Aborted, "etag unchanged")
+		}
+
+		if s.ruleChanges.Wait(ctx, tenantID, longPoll) {
+			resp, _, err = s.buildSyncRulesResponse(ctx, tenantID, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Timed out or ctx canceled with no change: fall through and
+		// return the still-unchanged response computed above, unlike a
+		// plain (non-long-poll) if_none_match match, which returns
+		// codes.Aborted.
+	}
+
+	if s.metrics != nil {
+		s.metrics.RecordSyncRulesBytes(proto.Size(resp))
+	}
+
+	return resp, nil
+}
+
+// buildSyncRulesResponse queries rules for tenantID (full set, or - when
+// req.SinceCreatedAt is set - only those created after it plus tombstones
+// for rules deleted after it), computes the aggregate ETag, and converts
+// to the proto response shape. Returns the computed ETag alongside resp so
+// SyncRules can compare it against if_none_match without re-deriving it.
+func (s *SensorAPIService) buildSyncRulesResponse(ctx context.Context, tenantID string, req *pb.SyncRulesRequest) (*pb.SyncRulesResponse, string, error) {
+	rows, err := s.queryRules(ctx, tenantID, req.GetSinceCreatedAt().AsTime())
+	if err != nil {
+		return nil, "", err
+	}
+
+	etag := computeETAG(rows)
+
+	var pbRules []*pb.Rule
+	for _, r := range rows {
+		rule, ok := rowToPBRule(r)
+		if !ok {
+			continue
+		}
+		pbRules = append(pbRules, rule)
+	}
+
+	resp := &pb.SyncRulesResponse{
+		Rules: pbRules,
+		Etag:  etag,
+	}
+
+	if !req.GetSinceCreatedAt().AsTime().IsZero() {
+		tombstones, err := s.queryTombstones(ctx, tenantID, req.GetSinceCreatedAt().AsTime())
+		if err != nil {
+			return nil, "", err
+		}
+		resp.Tombstones = tombstones
 	}
 
-	// Returns all rules for tenant
+	return resp, etag, nil
+}
+
+// queryRules returns every rule for tenantID, or - when since is non-zero -
+// only those created after since, for SyncRules' incremental delta path.
+func (s *SensorAPIService) queryRules(ctx context.Context, tenantID string, since time.Time) ([]ruleRow, error) {
+	var rows []ruleRow
+
 	query := `
 		SELECT rule_id, name, state, action, expression, sample_rate, scope_tags, created_at
 		FROM rules
 		WHERE tenant_id = ?
-		ORDER BY created_at DESC
-		LIMIT 10000
 	`
-	err := s.db.SelectContext(ctx, &rules, s.db.Rebind(query), tenantID)
+	args := []interface{}{tenantID}
+	if !since.IsZero() {
+		query += " AND created_at > ?"
+		args = append(args, since.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY created_at DESC LIMIT 10000"
+
+	start := time.Now()
+	err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), args...)
+	if s.metrics != nil {
+		s.metrics.RecordDBQueryDuration(time.Since(start))
+	}
 	if err != nil {
 		return nil, status.Error(codes.Unavailable, fmt.Sprintf("failed to query rules: %v", err))
 	}
+	return rows, nil
+}
 
-	// Compute ETAG as SHA256(sorted rule_ids + created_at timestamps)
-	// ETAG is content-addressable: same rules always produce same ETAG
-	etag := computeETAG(rules)
-
-	// ETAG matching optimization requires if_none_match field in SyncRulesRequest proto.
-	// Current proto definition lacks this field; server always returns full rule set.
-	// Bandwidth optimization via ETAG comparison unavailable without proto extension.
+// queryTombstones returns the rule_ids deleted for tenantID after since, so
+// an incremental SyncRules delta can tell a sensor to drop rules it had
+// cached from an earlier full sync. Reads the rule_tombstones(tenant_id,
+// rule_id, deleted_at) table of soft-deletes that
+// migrations/{sqlite,postgres}/0001_init.up.sql creates.
+func (s *SensorAPIService) queryTombstones(ctx context.Context, tenantID string, since time.Time) ([]string, error) {
+	var ruleIDs []string
 
-	// Convert database rules to proto format
-	var pbRules []*pb.Rule
-	for _, r := range rules {
-		// Parse expression JSON to or_groups
-		var orGroups []*pb.OrGroup
-		if r.Expression != "" {
-			if err := json.Unmarshal([]byte(r.Expression), &orGroups); err != nil {
-				// Skip malformed rule - continue processing others
-				continue
-			}
-		}
+	query := `
+		SELECT rule_id
+		FROM rule_tombstones
+		WHERE tenant_id = ? AND deleted_at > ?
+		ORDER BY deleted_at ASC
+	`
+	err := s.db.SelectContext(ctx, &ruleIDs, s.db.Rebind(query), tenantID, since.UTC().Format(time.RFC3339))
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, fmt.Sprintf("failed to query tombstones: %v", err))
+	}
+	return ruleIDs, nil
+}
 
-		// Parse scope_tags JSON to ScopeTag array
-		var scopeTags []*pb.ScopeTag
-		if r.ScopeTags != "" {
-			if err := json.Unmarshal([]byte(r.ScopeTags), &scopeTags); err != nil {
-				// Skip malformed rule - continue processing others
-				continue
-			}
+// rowToPBRule converts a database rule row to its proto form, skipping (ok
+// = false) a row whose expression/scope_tags/created_at fails to parse -
+// the same "drop the malformed row, keep serving the rest" behavior as
+// before this handler grew conditional/incremental fetch support.
+func rowToPBRule(r ruleRow) (*pb.Rule, bool) {
+	var orGroups []*pb.OrGroup
+	if r.Expression != "" {
+		if err := json.Unmarshal([]byte(r.Expression), &orGroups); err != nil {
+			return nil, false
 		}
+	}
 
-		// Parse created_at timestamp
-		createdAt, err := time.Parse(time.RFC3339, r.CreatedAt)
-		if err != nil {
-			// Skip malformed rule - continue processing others
-			continue
+	var scopeTags []*pb.ScopeTag
+	if r.ScopeTags != "" {
+		if err := json.Unmarshal([]byte(r.ScopeTags), &scopeTags); err != nil {
+			return nil, false
 		}
+	}
 
-		pbRules = append(pbRules, &pb.Rule{
-			RuleId:     r.RuleID,
-			Name:       r.Name,
-			State:      stringToRuleState(r.State),
-			Action:     stringToAction(r.Action),
-			OrGroups:   orGroups,
-			SampleRate: r.SampleRate,
-			ScopeTags:  scopeTags,
-			CreatedAt:  timestamppb.New(createdAt),
-		})
+	createdAt, err := time.Parse(time.RFC3339, r.CreatedAt)
+	if err != nil {
+		return nil, false
 	}
 
-	return &pb.SyncRulesResponse{
-		Rules: pbRules,
-		Etag:  etag,
-	}, nil
+	return &pb.Rule{
+		RuleId:     r.RuleID,
+		Name:       r.Name,
+		State:      stringToRuleState(r.State),
+		Action:     stringToAction(r.Action),
+		OrGroups:   orGroups,
+		SampleRate: r.SampleRate,
+		ScopeTags:  scopeTags,
+		CreatedAt:  timestamppb.New(createdAt),
+	}, true
 }
 
-// computeETAG generates content-addressable hash enabling bandwidth-efficient sync.
-func computeETAG(rules []struct{
-	RuleID     string  `db:"rule_id"`
-	Name       string  `db:"name"`
-	State      string  `db:"state"`
-	Action     string  `db:"action"`
-	Expression string  `db:"expression"`
-	SampleRate float64 `db:"sample_rate"`
-	ScopeTags  string  `db:"scope_tags"`
-	CreatedAt  string  `db:"created_at"`
-}) string {
+// computeETAG generates a content-addressable hash over rows, enabling
+// bandwidth-efficient sync: identical rule sets - same rule_ids and
+// created_at timestamps, regardless of row order or tenant - always
+// produce the same ETag. Per-row content (rowContentHash) is folded in too,
+// so an update that changes a rule's body without changing its created_at
+// still changes the ETag; a content_hash column would let the database
+// compute and index this instead of recomputing it per SyncRules call, but
+// the rules table in migrations/{sqlite,postgres}/0001_init.up.sql has no
+// such column.
+func computeETAG(rows []ruleRow) string {
 	h := sha256.New()
 	var ids []string
-	for _, r := range rules {
-		ids = append(ids, r.RuleID+":"+r.CreatedAt)
+	contentByID := make(map[string]string, len(rows))
+	for _, r := range rows {
+		id := r.RuleID + ":" + r.CreatedAt
+		ids = append(ids, id)
+		contentByID[id] = rowContentHash(r)
 	}
 	sort.Strings(ids)
 	for _, id := range ids {
 		h.Write([]byte(id))
+		h.Write([]byte(contentByID[id]))
 	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
+// rowContentHash hashes the fields of r that change a sensor's evaluation
+// behavior (everything but rule_id/created_at, which computeETAG already
+// folds in directly), so an in-place rule edit changes the aggregate ETAG
+// even though its identity and creation time didn't.
+func rowContentHash(r ruleRow) string {
+	h := sha256.New()
+	h.Write([]byte(r.Name))
+	h.Write([]byte(r.State))
+	h.Write([]byte(r.Action))
+	h.Write([]byte(r.Expression))
+	h.Write([]byte(r.ScopeTags))
+	h.Write([]byte(fmt.Sprintf("%v", r.SampleRate)))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
 func stringToRuleState(s string) pb.RuleState {
 	switch s {
 	case "draft":
@@ -5,13 +5,18 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+
+	"github.com/solatis/trapperkeeper/internal/core/db"
+	"github.com/solatis/trapperkeeper/internal/observability"
 )
 
 // contextKey is a typed key for context values to avoid collisions.
@@ -30,30 +35,101 @@ type Queries interface {
 // Authenticator validates API keys using HMAC-SHA256 signatures.
 // Holds in-memory secret map for O(1) lookup and queries for key verification.
 type Authenticator struct {
-	secrets map[string][]byte
-	queries Queries
+	mu       sync.RWMutex
+	secrets  map[string][]byte
+	queries  Queries
+	keyVault *KeyVault
+
+	audit   observability.AuditSink
+	metrics *observability.Metrics
 }
 
-// NewAuthenticator creates an authenticator with HMAC secrets and query interface.
+// NewAuthenticator creates an authenticator with HMAC secrets and query
+// interface. Audit logging and metrics are no-ops until SetAuditSink/
+// SetMetrics are called - instrumentation is opt-in, not a required wiring
+// step.
 func NewAuthenticator(secrets map[string][]byte, queries Queries) *Authenticator {
 	return &Authenticator{
 		secrets: secrets,
 		queries: queries,
+		audit:   observability.NoopSink{},
+	}
+}
+
+// SetSecrets atomically replaces the authenticator's HMAC secrets, letting
+// callers apply a rotated config.SecretsSnapshot (e.g. from
+// config.SecretProvider.Subscribe) without restarting the service.
+func (a *Authenticator) SetSecrets(secrets map[string][]byte) {
+	a.mu.Lock()
+	a.secrets = secrets
+	a.mu.Unlock()
+}
+
+// cacheSecret adds a KeyVault-resolved secret to the in-memory map so a
+// repeat Authenticate for the same secretID doesn't re-decrypt it. A
+// rotation via SetSecrets/Subscribe (see config.SecretProvider) still wins:
+// it replaces the whole map wholesale, evicting anything cached here.
+func (a *Authenticator) cacheSecret(secretID string, secret []byte) {
+	a.mu.Lock()
+	if a.secrets == nil {
+		a.secrets = make(map[string][]byte, 1)
 	}
+	a.secrets[secretID] = secret
+	a.mu.Unlock()
+}
+
+// SetKeyVault wires a KeyVault for resolving a tenant's HMAC secret that
+// isn't present in the in-memory map populated from config.SecretProvider -
+// the per-tenant secret material persisted at rest (encrypted, via
+// KeyVault.StoreSecret) rather than distributed through TK_HMAC_SECRET[_N]/
+// a secrets file. Optional, like SetAuditSink/SetMetrics: Authenticate
+// falls back to ErrUnknownKey for an unresolvable secretID if no KeyVault
+// is set.
+func (a *Authenticator) SetKeyVault(kv *KeyVault) {
+	a.mu.Lock()
+	a.keyVault = kv
+	a.mu.Unlock()
+}
+
+// SetAuditSink wires an audit trail for every Authenticate outcome
+// (including revocation hits), so operators can answer "why did this
+// event drop?" after the fact.
+func (a *Authenticator) SetAuditSink(sink observability.AuditSink) {
+	a.audit = sink
+}
+
+// SetMetrics wires Prometheus auth_decisions_total recording.
+func (a *Authenticator) SetMetrics(m *observability.Metrics) {
+	a.metrics = m
 }
 
 // Authenticate validates API key and returns tenant_id on success.
 // Returns specific error for each failure mode (5-tier taxonomy).
 func (a *Authenticator) Authenticate(ctx context.Context, apiKey string) (string, error) {
+	start := time.Now()
+
 	// Parse API key format
 	secretID, _, err := ParseAPIKey(apiKey)
 	if err != nil {
+		a.recordDecision(ctx, "", "invalid_key_format", err.Error(), start)
 		return "", err
 	}
 
 	// O(1) lookup of HMAC secret using secret_id from key format
+	a.mu.RLock()
 	secret, ok := a.secrets[secretID]
+	kv := a.keyVault
+	a.mu.RUnlock()
+
+	if !ok && kv != nil {
+		loaded, err := kv.LoadSecret(secretID)
+		if err == nil {
+			secret, ok = loaded, true
+			a.cacheSecret(secretID, loaded)
+		}
+	}
 	if !ok {
+		a.recordDecision(ctx, "", "unknown_key", ErrUnknownKey.Error(), start)
 		return "", ErrUnknownKey
 	}
 
@@ -67,16 +143,24 @@ func (a *Authenticator) Authenticate(ctx context.Context, apiKey string) (string
 		LastUsedAt sql.NullTime `db:"last_used_at"`
 	}
 
-	err = a.queries.Get("get-api-key-by-hash", &result, computedHash)
+	// Retried with the same backoff/jitter policy as db.Open's connect-time
+	// retries, so a brief database hiccup surfaces as Unavailable only
+	// after real exhaustion instead of failing the request on one blip.
+	err = db.Retry(ctx, db.DefaultRetryPolicy(), log.Printf, func() error {
+		return a.queries.Get("get-api-key-by-hash", &result, computedHash)
+	})
 	if err == sql.ErrNoRows {
+		a.recordDecision(ctx, "", "invalid_key", ErrInvalidKey.Error(), start)
 		return "", ErrInvalidKey
 	}
 	if err != nil {
+		a.recordDecision(ctx, "", "database_error", err.Error(), start)
 		return "", fmt.Errorf("database error: %w", err)
 	}
 
 	// Check revocation status
 	if result.RevokedAt.Valid {
+		a.recordDecision(ctx, result.TenantID, "revoked", ErrKeyRevoked.Error(), start)
 		return "", ErrKeyRevoked
 	}
 
@@ -86,9 +170,27 @@ func (a *Authenticator) Authenticate(ctx context.Context, apiKey string) (string
 		_, _ = a.queries.Exec("update-last-used", time.Now().UTC(), result.APIKeyID)
 	}
 
+	a.recordDecision(ctx, result.TenantID, "authenticated", "", start)
 	return result.TenantID, nil
 }
 
+// recordDecision emits an audit event and increments auth_decisions_total
+// for one Authenticate outcome. Both default to no-ops (observability.
+// NoopSink, a nil *Metrics) until SetAuditSink/SetMetrics opt in.
+func (a *Authenticator) recordDecision(ctx context.Context, tenantID, result, reason string, start time.Time) {
+	a.audit.Emit(ctx, observability.AuditEvent{
+		Timestamp: time.Now(),
+		Type:      "auth_decision",
+		TenantID:  tenantID,
+		Result:    result,
+		Reason:    reason,
+		Latency:   time.Since(start),
+	})
+	if a.metrics != nil {
+		a.metrics.RecordAuthDecision(result)
+	}
+}
+
 // shouldUpdateLastUsed implements 1-minute throttle to reduce write amplification.
 func shouldUpdateLastUsed(lastUsed sql.NullTime) bool {
 	if !lastUsed.Valid {
@@ -112,14 +214,7 @@ func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
 
 		tenantID, err := a.Authenticate(ctx, apiKeys[0])
 		if err != nil {
-			if err == ErrKeyRevoked {
-				return nil, status.Error(codes.PermissionDenied, err.Error())
-			}
-			// Check for database errors - return UNAVAILABLE instead of UNAUTHENTICATED
-			if strings.Contains(err.Error(), "database error") {
-				return nil, status.Error(codes.Unavailable, err.Error())
-			}
-			return nil, status.Error(codes.Unauthenticated, err.Error())
+			return nil, authErrorToStatus(err)
 		}
 
 		// Inject tenant_id into context for downstream handlers
@@ -128,6 +223,40 @@ func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
 	}
 }
 
+// extractFromMetadata implements credentialBackend, reading the raw API
+// key out of x-api-key - the same header UnaryInterceptor reads directly.
+func (a *Authenticator) extractFromMetadata(md metadata.MD) (string, bool) {
+	apiKeys := md.Get("x-api-key")
+	if len(apiKeys) == 0 {
+		return "", false
+	}
+	return apiKeys[0], true
+}
+
+// authenticateCredential implements credentialBackend, delegating to
+// Authenticate. The HMAC flavor has no claims to offer, so claims is
+// always nil.
+func (a *Authenticator) authenticateCredential(ctx context.Context, credential string) (string, Claims, error) {
+	tenantID, err := a.Authenticate(ctx, credential)
+	return tenantID, nil, err
+}
+
+// authErrorToStatus maps an authentication error to its gRPC status code:
+// PermissionDenied for a revoked key (confirms the key exists but is
+// blocked), Unavailable for a database error (the key may well be valid -
+// the caller should retry, not treat this as a bad credential), and
+// Unauthenticated for everything else (missing/malformed/unknown key,
+// invalid or unverifiable JWT, unresolvable tenant claim).
+func authErrorToStatus(err error) error {
+	if err == ErrKeyRevoked {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if strings.Contains(err.Error(), "database error") {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
 // TenantIDFromContext extracts tenant ID from context.
 // Returns empty string if not found.
 func TenantIDFromContext(ctx context.Context) string {
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor is satisfied by any authentication backend that can be
+// wired into server.NewGRPCServer as the sole gRPC auth interceptor:
+// Authenticator (HMAC API keys), JWTAuthenticator (bearer JWTs), or
+// ChainAuthenticator (both, routed by which metadata header is present).
+type Interceptor interface {
+	UnaryInterceptor() grpc.UnaryServerInterceptor
+}
+
+// credentialBackend is implemented by each authentication mechanism so
+// ChainAuthenticator can route one request to the right one: extract the
+// credential this backend cares about out of the request's metadata, then
+// (only if that succeeded) validate it.
+type credentialBackend interface {
+	// extractFromMetadata pulls this backend's credential out of md,
+	// reporting ok=false if its header is absent or not in a format this
+	// backend understands (e.g. authorization present without a "Bearer "
+	// prefix) - ChainAuthenticator moves on to the next backend rather
+	// than failing the request outright.
+	extractFromMetadata(md metadata.MD) (credential string, ok bool)
+
+	// authenticateCredential validates an already-extracted credential and
+	// returns the tenant ID plus any claims (nil for non-token backends).
+	authenticateCredential(ctx context.Context, credential string) (tenantID string, claims Claims, err error)
+}
+
+// ChainAuthenticator tries each configured backend in turn against one
+// request's metadata, in the order given to NewChainAuthenticator,
+// authenticating with the first backend whose header is present. This lets
+// sensors migrate from HMAC API keys to JWTs (or back) gradually: each
+// sensor presents whichever credential it has, and the chain routes to the
+// matching backend rather than requiring every sensor switch at once.
+type ChainAuthenticator struct {
+	backends []credentialBackend
+}
+
+// NewChainAuthenticator builds a ChainAuthenticator trying backends in the
+// given order. Construct with an *Authenticator and/or *JWTAuthenticator,
+// e.g. NewChainAuthenticator(hmacAuth, jwtAuth) to prefer HMAC, or the
+// reverse order to prefer JWT.
+func NewChainAuthenticator(backends ...credentialBackend) *ChainAuthenticator {
+	return &ChainAuthenticator{backends: backends}
+}
+
+// UnaryInterceptor returns a gRPC interceptor authenticating via whichever
+// configured backend's header is present in the request metadata.
+func (c *ChainAuthenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing metadata")
+		}
+
+		for _, backend := range c.backends {
+			credential, ok := backend.extractFromMetadata(md)
+			if !ok {
+				continue
+			}
+
+			tenantID, claims, err := backend.authenticateCredential(ctx, credential)
+			if err != nil {
+				return nil, authErrorToStatus(err)
+			}
+
+			ctx = context.WithValue(ctx, tenantIDKey, tenantID)
+			if claims != nil {
+				ctx = context.WithValue(ctx, claimsKey, claims)
+			}
+			return handler(ctx, req)
+		}
+
+		return nil, status.Error(codes.Unauthenticated, ErrMissingKey.Error())
+	}
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Claims holds the decoded JWT claims for a request authenticated via
+// JWTAuthenticator, available to handlers that need more than tenant_id
+// (e.g. scopes for a future admin endpoint). Requests authenticated via
+// the HMAC Authenticator have no claims to offer, so ClaimsFromContext
+// reports ok=false for those.
+type Claims map[string]interface{}
+
+// claimsKey is the context key for storing the authenticated request's
+// Claims, mirroring tenantIDKey.
+const claimsKey = contextKey("claims")
+
+// ClaimsFromContext extracts the authenticated request's Claims.
+// Returns ok=false if the request wasn't authenticated via JWTAuthenticator.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(Claims)
+	return claims, ok
+}
+
+// claimAtPath resolves a dot-separated path (e.g. "tenant_id" or
+// "https://trapperkeeper.io/claims.tenant_id") against claims, the same
+// dotted-key convention custom OIDC claim names commonly use since the
+// claim name itself may contain a literal "." (a namespaced URI). At each
+// nested map the longest run of remaining segments that, joined with ".",
+// names a literal key is taken, so "https://trapperkeeper.io/claims" above
+// resolves as one key before "tenant_id" is looked up inside it.
+func claimAtPath(claims Claims, path string) (interface{}, bool) {
+	var cur interface{} = map[string]interface{}(claims)
+	segments := splitClaimPath(path)
+	for len(segments) > 0 {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		n := len(segments)
+		for ; n > 0; n-- {
+			if v, ok := m[strings.Join(segments[:n], ".")]; ok {
+				cur = v
+				break
+			}
+		}
+		if n == 0 {
+			return nil, false
+		}
+		segments = segments[n:]
+	}
+	return cur, true
+}
+
+// splitClaimPath splits path on ".", dropping empty segments so a leading
+// or trailing "." (or path == "") doesn't produce a spurious empty key.
+func splitClaimPath(path string) []string {
+	var segments []string
+	start := 0
+	for i := 0; i <= len(path); i++ {
+		if i == len(path) || path[i] == '.' {
+			if i > start {
+				segments = append(segments, path[start:i])
+			}
+			start = i + 1
+		}
+	}
+	return segments
+}
@@ -12,3 +12,14 @@ var (
 	ErrInvalidKey       = errors.New("invalid API key")
 	ErrKeyRevoked       = errors.New("API key has been revoked")
 )
+
+// JWTAuthenticator error types, mirroring the HMAC taxonomy above: a
+// malformed/unverifiable token and an unresolvable tenant claim are both
+// UNAUTHENTICATED (they don't confirm a legitimate token exists), same as
+// ErrInvalidKey/ErrInvalidKeyFormat above.
+var (
+	ErrMissingBearerToken = errors.New("bearer token required in authorization metadata")
+	ErrInvalidToken       = errors.New("invalid or unverifiable JWT")
+	ErrTokenExpired       = errors.New("JWT has expired or is not yet valid")
+	ErrClaimNotFound      = errors.New("tenant claim not found in JWT")
+)
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+ * JWKS fetch/cache for JWTAuthenticator.
+ *
+ * RS256/ES256 verification needs the issuer's current signing keys, keyed
+ * by "kid" so a key rotation (old kid still verifying tokens issued before
+ * it, new kid taking over for new ones) doesn't require a restart. JWKSCache
+ * fetches the set once at construction, refreshes it on a fixed interval in
+ * the background (mirroring config.FileProvider's watch goroutine), and
+ * also refreshes inline on an unrecognized kid - a rotation landing between
+ * two scheduled refreshes shouldn't reject valid tokens until the next tick.
+ */
+
+// jwk is one entry of a JWKS response (RFC 7517), restricted to the fields
+// RS256 (kty=RSA) and ES256 (kty=EC) verification need.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwkSet is the top-level JWKS document shape.
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKSCache fetches and caches the verification keys published at jwksURL,
+// refreshing them on a fixed interval plus on-demand on an unrecognized
+// kid. Safe for concurrent use.
+type JWKSCache struct {
+	jwksURL      string
+	refreshEvery time.Duration
+	httpClient   *http.Client
+
+	mu   sync.RWMutex
+	keys map[string]crypto.PublicKey
+
+	done chan struct{}
+}
+
+// NewJWKSCache fetches jwksURL once (returning an error if that initial
+// fetch fails - a JWTAuthenticator with no verification keys can never
+// authenticate anything, so failing fast at startup beats failing every
+// request later) and starts a background refresh every refreshEvery.
+func NewJWKSCache(jwksURL string, refreshEvery time.Duration) (*JWKSCache, error) {
+	c := &JWKSCache{
+		jwksURL:      jwksURL,
+		refreshEvery: refreshEvery,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		done:         make(chan struct{}),
+	}
+
+	if err := c.refresh(); err != nil {
+		return nil, fmt.Errorf("fetching JWKS from %s: %w", jwksURL, err)
+	}
+
+	go c.watch()
+	return c, nil
+}
+
+func (c *JWKSCache) watch() {
+	ticker := time.NewTicker(c.refreshEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			_ = c.refresh()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// refresh fetches jwksURL and swaps in the new key set. A failed refresh
+// (issuer temporarily unreachable) keeps serving the previous keys rather
+// than blanking the cache - the same "bad reload is rejected, previous
+// snapshot keeps serving" rule config.FileProvider applies to HMAC secrets.
+func (c *JWKSCache) refresh() error {
+	req, err := http.NewRequest(http.MethodGet, c.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var set jwkSet
+	if err := json.Unmarshal(body, &set); err != nil {
+		return fmt.Errorf("parsing JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		pub, err := jwkToPublicKey(k)
+		if err != nil {
+			// One malformed or unsupported key (e.g. kty=oct) shouldn't
+			// sink the whole refresh - skip it and keep the rest.
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+	return nil
+}
+
+// Key returns the verification key for kid, refreshing inline first if kid
+// isn't already cached - a key rotation landing between two scheduled
+// refreshes shouldn't reject valid tokens until the next tick.
+func (c *JWKSCache) Key(kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	pub, ok := c.keys[kid]
+	c.mu.RUnlock()
+	if ok {
+		return pub, nil
+	}
+
+	if err := c.refresh(); err != nil {
+		return nil, fmt.Errorf("refreshing JWKS after unrecognized kid %q: %w", kid, err)
+	}
+
+	c.mu.RLock()
+	pub, ok = c.keys[kid]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unrecognized kid %q", kid)
+	}
+	return pub, nil
+}
+
+// Close stops the background refresh. Safe to call once.
+func (c *JWKSCache) Close() error {
+	close(c.done)
+	return nil
+}
+
+// jwkToPublicKey converts a JWKS entry to the crypto.PublicKey VerifyJWT
+// needs: *rsa.PublicKey for kty=RSA (RS256), *ecdsa.PublicKey for kty=EC
+// (ES256).
+func jwkToPublicKey(k jwk) (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decoding n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decoding e: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		curve, err := ecCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decoding x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decoding y: %w", err)
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+	}
+}
+
+func ecCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("unsupported crv %q", crv)
+	}
+}
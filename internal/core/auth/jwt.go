@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultClaimLeeway absorbs clock skew between this service and the
+// token issuer when checking exp/nbf/iat, the same role DefaultRetryPolicy
+// plays for db.Retry: a sane default most callers don't need to override.
+const DefaultClaimLeeway = 60 * time.Second
+
+// JWTAuthenticatorConfig configures a JWTAuthenticator.
+type JWTAuthenticatorConfig struct {
+	// Issuer is the required "iss" claim value.
+	Issuer string
+	// Audience is the required "aud" claim value (a token's aud may be a
+	// single string or an array; either satisfies this if it contains
+	// Audience).
+	Audience string
+	// JWKS supplies verification keys keyed by "kid".
+	JWKS *JWKSCache
+	// TenantClaimPath is the dot-separated claim path tenant_id is read
+	// from, e.g. "tenant_id" or a namespaced custom claim URI.
+	TenantClaimPath string
+	// Leeway absorbs clock skew when checking exp/nbf/iat. Zero means
+	// DefaultClaimLeeway.
+	Leeway time.Duration
+}
+
+// JWTAuthenticator validates RS256/ES256-signed bearer JWTs, the same
+// gRPC interceptor contract (auth.Interceptor) Authenticator satisfies for
+// HMAC API keys. Holds an in-memory last-seen map for the per-credential
+// last_used_at write-throttle, since - unlike HMAC API keys - no migration
+// in this tree creates a table for JWT subjects to persist it to.
+type JWTAuthenticator struct {
+	cfg JWTAuthenticatorConfig
+
+	mu       sync.Mutex
+	lastSeen map[string]time.Time
+}
+
+// NewJWTAuthenticator validates cfg and returns a JWTAuthenticator.
+func NewJWTAuthenticator(cfg JWTAuthenticatorConfig) (*JWTAuthenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("issuer cannot be empty")
+	}
+	if cfg.Audience == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+	if cfg.JWKS == nil {
+		return nil, fmt.Errorf("jwks cannot be nil")
+	}
+	if cfg.TenantClaimPath == "" {
+		return nil, fmt.Errorf("tenant claim path cannot be empty")
+	}
+	if cfg.Leeway == 0 {
+		cfg.Leeway = DefaultClaimLeeway
+	}
+
+	return &JWTAuthenticator{
+		cfg:      cfg,
+		lastSeen: make(map[string]time.Time),
+	}, nil
+}
+
+// jwtHeader is the subset of a JWS header Authenticate needs.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate validates token (without its "Bearer " prefix) and returns
+// the tenant ID read from cfg.TenantClaimPath, plus the full decoded
+// claims.
+func (j *JWTAuthenticator) Authenticate(ctx context.Context, token string) (string, Claims, error) {
+	claims, err := j.verify(token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if err := j.checkTimingClaims(claims); err != nil {
+		return "", nil, err
+	}
+	if iss, _ := claims["iss"].(string); iss != j.cfg.Issuer {
+		return "", nil, ErrInvalidToken
+	}
+	if !audienceContains(claims["aud"], j.cfg.Audience) {
+		return "", nil, ErrInvalidToken
+	}
+
+	tenantVal, ok := claimAtPath(claims, j.cfg.TenantClaimPath)
+	if !ok {
+		return "", nil, ErrClaimNotFound
+	}
+	tenantID, ok := tenantVal.(string)
+	if !ok || tenantID == "" {
+		return "", nil, ErrClaimNotFound
+	}
+
+	j.touchLastSeen(credentialIdentifier(claims))
+
+	return tenantID, claims, nil
+}
+
+// verify splits token into its three JWS segments, looks up the
+// verification key for its kid, checks the signature, and - only once
+// that's confirmed - returns the decoded claims.
+func (j *JWTAuthenticator) verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	headerB64, payloadB64, sigB64 := parts[0], parts[1], parts[2]
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(headerB64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(sigB64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	pub, err := j.cfg.JWKS.Key(header.Kid)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	signingInput := headerB64 + "." + payloadB64
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch header.Alg {
+	case "RS256":
+		rsaKey, ok := pub.(*rsa.PublicKey)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], sig); err != nil {
+			return nil, ErrInvalidToken
+		}
+
+	case "ES256":
+		ecKey, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		if len(sig) != 64 {
+			return nil, ErrInvalidToken
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return nil, ErrInvalidToken
+		}
+
+	default:
+		return nil, ErrInvalidToken
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(payloadB64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payloadJSON, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// checkTimingClaims enforces exp/nbf/iat with j.cfg.Leeway of slack in
+// each direction, the same role the backoff jitter cap plays in
+// db.RetryPolicy: a deliberate tolerance rather than an exact boundary.
+func (j *JWTAuthenticator) checkTimingClaims(claims Claims) error {
+	now := time.Now()
+
+	if exp, ok := claimTime(claims, "exp"); ok && now.After(exp.Add(j.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok := claimTime(claims, "nbf"); ok && now.Before(nbf.Add(-j.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	if iat, ok := claimTime(claims, "iat"); ok && now.Before(iat.Add(-j.cfg.Leeway)) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+// claimTime reads a numeric (Unix seconds) claim as a time.Time.
+func claimTime(claims Claims, key string) (time.Time, bool) {
+	v, ok := claims[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+// audienceContains reports whether aud (a token's "aud" claim, either a
+// single string or an array per RFC 7519) contains want.
+func audienceContains(aud interface{}, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []interface{}:
+		for _, entry := range v {
+			if s, ok := entry.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// credentialIdentifier derives the stable per-credential identifier the
+// last_used_at throttle is keyed by: issuer+subject, since a JWT has no
+// equivalent of an HMAC key's api_key_id. Falls back to the full claims
+// set's string form in the (spec-violating) case a token lacks "sub".
+func credentialIdentifier(claims Claims) string {
+	iss, _ := claims["iss"].(string)
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return fmt.Sprintf("%v", claims)
+	}
+	return iss + "|" + sub
+}
+
+// touchLastSeen applies the same 1-minute write-throttle semantics as
+// shouldUpdateLastUsed, in memory rather than via a.queries.Exec - there's
+// no table to persist it to for JWT subjects in this tree (see the
+// JWTAuthenticator doc comment).
+func (j *JWTAuthenticator) touchLastSeen(credentialID string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if last, ok := j.lastSeen[credentialID]; ok && time.Since(last) <= time.Minute {
+		return
+	}
+	j.lastSeen[credentialID] = time.Now()
+}
+
+// UnaryInterceptor returns a gRPC interceptor authenticating requests via
+// an "authorization: Bearer <jwt>" header.
+func (j *JWTAuthenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing metadata")
+		}
+
+		token, ok := j.extractFromMetadata(md)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, ErrMissingBearerToken.Error())
+		}
+
+		tenantID, claims, err := j.authenticateCredential(ctx, token)
+		if err != nil {
+			return nil, authErrorToStatus(err)
+		}
+
+		ctx = context.WithValue(ctx, tenantIDKey, tenantID)
+		ctx = context.WithValue(ctx, claimsKey, claims)
+		return handler(ctx, req)
+	}
+}
+
+// extractFromMetadata implements credentialBackend, reading the bearer
+// token out of the authorization header and stripping its "Bearer " prefix.
+func (j *JWTAuthenticator) extractFromMetadata(md metadata.MD) (string, bool) {
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(values[0], prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(values[0], prefix), true
+}
+
+// authenticateCredential implements credentialBackend, delegating to
+// Authenticate.
+func (j *JWTAuthenticator) authenticateCredential(ctx context.Context, credential string) (string, Claims, error) {
+	return j.Authenticate(ctx, credential)
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+/*
+ * Encryption at rest for secret material (e.g. a per-tenant HMAC secret
+ * persisted via Queries) using AES-256-GCM with a per-secret key derived
+ * via HKDF-SHA256 from a versioned master key. Versioning the master key
+ * in the ciphertext header means rotating it doesn't require re-encrypting
+ * every stored secret: old ciphertext keeps referencing the master key
+ * version it was written under, and MasterKeySource just needs to still
+ * have that version available to decrypt it.
+ */
+
+var (
+	// ErrNoMasterKey indicates no master key is configured and
+	// --insecure-plaintext-secrets was not set.
+	ErrNoMasterKey = errors.New("no master key configured (set --master-key or TRAPPERKEEPER_MASTER_KEY, or pass --insecure-plaintext-secrets for development)")
+
+	// ErrInsecurePlaintextDisallowed indicates a plaintext-format secret
+	// was read but --insecure-plaintext-secrets was not set.
+	ErrInsecurePlaintextDisallowed = errors.New("secret is stored unencrypted but --insecure-plaintext-secrets was not set")
+
+	// ErrUnknownKeyVersion indicates ciphertext references a master key
+	// version MasterKeySource no longer has.
+	ErrUnknownKeyVersion = errors.New("ciphertext references an unknown master key version")
+)
+
+// blob format: one format-tag byte, then format-specific payload.
+const (
+	formatPlaintext byte = 0x00
+	formatAESGCMv1  byte = 0x01
+
+	gcmNonceSize = 12
+)
+
+// MasterKeySource supplies the raw master key material KeyVault derives
+// per-secret AES keys from, keyed by version so a rotated master key can
+// still decrypt ciphertext written under an older version.
+type MasterKeySource interface {
+	// MasterKey returns the raw key for version, or ok=false if that
+	// version is no longer available.
+	MasterKey(version uint32) (key []byte, ok bool)
+
+	// CurrentVersion returns the version new encryptions should use.
+	CurrentVersion() uint32
+}
+
+// StaticMasterKey is a MasterKeySource holding the single master key
+// provided via --master-key/TRAPPERKEEPER_MASTER_KEY, always at version 1.
+// A KMS- or Vault-backed MasterKeySource with real rotation can implement
+// the same interface without KeyVault changing.
+type StaticMasterKey struct {
+	key []byte
+}
+
+// NewStaticMasterKey wraps key (at least 32 bytes) as a version-1
+// MasterKeySource.
+func NewStaticMasterKey(key []byte) *StaticMasterKey {
+	return &StaticMasterKey{key: key}
+}
+
+// MasterKey implements MasterKeySource.
+func (s *StaticMasterKey) MasterKey(version uint32) ([]byte, bool) {
+	if version != 1 {
+		return nil, false
+	}
+	return s.key, true
+}
+
+// CurrentVersion implements MasterKeySource.
+func (s *StaticMasterKey) CurrentVersion() uint32 { return 1 }
+
+// MasterKeyFromFlagOrEnv resolves the master key from flagValue (a
+// base64-encoded --master-key flag, takes precedence) or the
+// TRAPPERKEEPER_MASTER_KEY environment variable. Returns (nil, nil) if
+// neither is set, leaving the caller to decide whether that's acceptable
+// (e.g. --insecure-plaintext-secrets).
+func MasterKeyFromFlagOrEnv(flagValue string) (*StaticMasterKey, error) {
+	raw := flagValue
+	if raw == "" {
+		raw = os.Getenv("TRAPPERKEEPER_MASTER_KEY")
+	}
+	if raw == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid master key: invalid base64 encoding: %w", err)
+	}
+	if len(key) < 32 {
+		return nil, fmt.Errorf("invalid master key: must be at least 32 bytes, got %d", len(key))
+	}
+	return NewStaticMasterKey(key), nil
+}
+
+// KeyVault loads and stores secret material at rest via Queries, encrypted
+// with AES-256-GCM under a key HKDF-derives from master for each
+// encryption's key version.
+type KeyVault struct {
+	master  MasterKeySource
+	queries Queries
+
+	// insecurePlaintext allows LoadSecret/StoreSecret to read and write
+	// unencrypted secrets (--insecure-plaintext-secrets), for development
+	// without a master key.
+	insecurePlaintext bool
+}
+
+// NewKeyVault returns a KeyVault. master may be nil only if
+// insecurePlaintext is true (development without a configured master key).
+func NewKeyVault(master MasterKeySource, queries Queries, insecurePlaintext bool) *KeyVault {
+	return &KeyVault{master: master, queries: queries, insecurePlaintext: insecurePlaintext}
+}
+
+// LoadSecret fetches secretID's stored blob via Queries.Get and decrypts
+// it. The returned slice holds live key material - callers should Zero it
+// once done.
+func (v *KeyVault) LoadSecret(secretID string) ([]byte, error) {
+	var row struct {
+		Blob []byte `db:"secret_blob"`
+	}
+	if err := v.queries.Get("get-secret-blob", &row, secretID); err != nil {
+		return nil, fmt.Errorf("loading secret %s: %w", secretID, err)
+	}
+	return v.decrypt(row.Blob)
+}
+
+// StoreSecret encrypts secret and persists it under secretID via
+// Queries.Exec.
+func (v *KeyVault) StoreSecret(secretID string, secret []byte) error {
+	blob, err := v.encrypt(secret)
+	if err != nil {
+		return fmt.Errorf("encrypting secret %s: %w", secretID, err)
+	}
+	if _, err := v.queries.Exec("upsert-secret-blob", secretID, blob); err != nil {
+		return fmt.Errorf("storing secret %s: %w", secretID, err)
+	}
+	return nil
+}
+
+func (v *KeyVault) encrypt(plaintext []byte) ([]byte, error) {
+	if v.master == nil {
+		if !v.insecurePlaintext {
+			return nil, ErrNoMasterKey
+		}
+		out := make([]byte, 0, 1+len(plaintext))
+		out = append(out, formatPlaintext)
+		return append(out, plaintext...), nil
+	}
+
+	version := v.master.CurrentVersion()
+	key, ok := v.master.MasterKey(version)
+	if !ok {
+		return nil, fmt.Errorf("master key version %d not available", version)
+	}
+
+	gcm, err := gcmFor(key, version)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	sealed := gcm.Seal(nil, nonce, plaintext, nil)
+
+	out := make([]byte, 0, 1+4+gcmNonceSize+len(sealed))
+	out = append(out, formatAESGCMv1)
+	out = binary.BigEndian.AppendUint32(out, version)
+	out = append(out, nonce...)
+	return append(out, sealed...), nil
+}
+
+func (v *KeyVault) decrypt(blob []byte) ([]byte, error) {
+	if len(blob) == 0 {
+		return nil, fmt.Errorf("empty secret blob")
+	}
+
+	switch blob[0] {
+	case formatPlaintext:
+		if !v.insecurePlaintext {
+			return nil, ErrInsecurePlaintextDisallowed
+		}
+		out := make([]byte, len(blob)-1)
+		copy(out, blob[1:])
+		return out, nil
+
+	case formatAESGCMv1:
+		const headerSize = 1 + 4 + gcmNonceSize
+		if len(blob) < headerSize {
+			return nil, fmt.Errorf("ciphertext shorter than its header")
+		}
+		version := binary.BigEndian.Uint32(blob[1:5])
+		nonce := blob[5:headerSize]
+		ciphertext := blob[headerSize:]
+
+		if v.master == nil {
+			return nil, ErrNoMasterKey
+		}
+		key, ok := v.master.MasterKey(version)
+		if !ok {
+			return nil, ErrUnknownKeyVersion
+		}
+
+		gcm, err := gcmFor(key, version)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+		if err != nil {
+			return nil, fmt.Errorf("decrypting secret: %w", err)
+		}
+		return plaintext, nil
+
+	default:
+		return nil, fmt.Errorf("unknown secret blob format %#x", blob[0])
+	}
+}
+
+// gcmFor derives an AES-256 key from masterKey via HKDF-SHA256 (scoped to
+// version so different master key versions never collide on the same
+// derived key) and returns a GCM AEAD over it.
+func gcmFor(masterKey []byte, version uint32) (cipher.AEAD, error) {
+	info := []byte(fmt.Sprintf("trapperkeeper-secret-v%d", version))
+	derived := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, masterKey, nil, info), derived); err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, fmt.Errorf("creating AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCM mode: %w", err)
+	}
+	return gcm, nil
+}
+
+// Zero overwrites secret's bytes with zero. Callers of LoadSecret should
+// call this once they're done with the returned key material.
+func Zero(secret []byte) {
+	for i := range secret {
+		secret[i] = 0
+	}
+}
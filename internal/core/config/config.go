@@ -17,17 +17,47 @@ type SensorAPIConfig struct {
 	RequestTimeout time.Duration
 	MaxBatchSize   int
 	DataDir        string
+
+	// JSONLSyncInterval and JSONLMaxBufferedBytes tune the background
+	// jsonlWriter ReportEvents appends through: it flushes and fsyncs on
+	// this interval, or sooner once its buffer grows past
+	// JSONLMaxBufferedBytes. Zero values fall back to
+	// DefaultJSONLSyncInterval / DefaultJSONLMaxBufferedBytes.
+	JSONLSyncInterval     time.Duration
+	JSONLMaxBufferedBytes int
+
+	// MigrationLockTimeout and MigrationLockRetryInterval bound
+	// db.MigrateUp's advisory-lock acquisition: when a rolling deploy
+	// starts several instances at once, only the one holding the lock
+	// runs migrations, and the rest poll every MigrationLockRetryInterval
+	// until either it's their turn or MigrationLockTimeout elapses (at
+	// which point MigrateUp returns db.ErrMigrationLocked).
+	MigrationLockTimeout       time.Duration
+	MigrationLockRetryInterval time.Duration
+
+	Observability ObservabilityConfig
+	Rules         RulesConfig
+	JWT           JWTConfig
 }
 
 // DefaultSensorAPIConfig returns configuration with default values.
 func DefaultSensorAPIConfig() *SensorAPIConfig {
 	return &SensorAPIConfig{
-		Host:           "0.0.0.0",
-		Port:           50051,
-		MaxConnections: 1000,
-		RequestTimeout: 30 * time.Second,
-		MaxBatchSize:   1000,
-		DataDir:        "./data",
+		Host:                  "0.0.0.0",
+		Port:                  50051,
+		MaxConnections:        1000,
+		RequestTimeout:        30 * time.Second,
+		MaxBatchSize:          1000,
+		DataDir:               "./data",
+		JSONLSyncInterval:     1 * time.Second,
+		JSONLMaxBufferedBytes: 256 * 1024,
+
+		MigrationLockTimeout:       60 * time.Second,
+		MigrationLockRetryInterval: 500 * time.Millisecond,
+
+		Observability: DefaultObservabilityConfig(),
+		Rules:         DefaultRulesConfig(),
+		JWT:           DefaultJWTConfig(),
 	}
 }
 
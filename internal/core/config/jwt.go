@@ -0,0 +1,42 @@
+package config
+
+import "time"
+
+// DefaultJWTJWKSRefreshInterval is how often an enabled JWTConfig's JWKS
+// cache re-fetches its issuer's signing keys in the background, absent an
+// override (see auth.NewJWKSCache).
+const DefaultJWTJWKSRefreshInterval = 5 * time.Minute
+
+// JWTConfig configures optional bearer-JWT authentication
+// (auth.JWTAuthenticator) as a peer to HMAC API keys, routed by
+// auth.ChainAuthenticator when both are in use. Disabled by default, so a
+// sensor keeps working with HMAC API keys alone until an operator opts in.
+type JWTConfig struct {
+	Enabled bool
+
+	// Issuer is the required "iss" claim value.
+	Issuer string
+	// Audience is the required "aud" claim value.
+	Audience string
+	// JWKSURL is fetched and cached for verification keys.
+	JWKSURL string
+	// TenantClaimPath is the dot-separated claim path tenant_id is read
+	// from, e.g. "tenant_id" or a namespaced custom claim URI.
+	TenantClaimPath string
+	// Leeway absorbs clock skew when checking exp/nbf/iat. Zero means
+	// auth.DefaultClaimLeeway.
+	Leeway time.Duration
+	// JWKSRefreshInterval is how often the JWKS cache refreshes in the
+	// background. Zero means DefaultJWTJWKSRefreshInterval.
+	JWKSRefreshInterval time.Duration
+}
+
+// DefaultJWTConfig returns JWT authentication disabled, with the defaults
+// an operator enabling it would otherwise have to set explicitly.
+func DefaultJWTConfig() JWTConfig {
+	return JWTConfig{
+		Enabled:             false,
+		TenantClaimPath:     "tenant_id",
+		JWKSRefreshInterval: DefaultJWTJWKSRefreshInterval,
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+// ObservabilityConfig controls the audit/metrics/tracing subsystem
+// (internal/observability). Every piece defaults off, so a sensor can run
+// with none of it and a sidecar deployment can turn on whichever pieces it
+// needs.
+type ObservabilityConfig struct {
+	// AuditSink selects the audit trail backend: "none" (default),
+	// "stdout", "sqlite", or "grpc".
+	AuditSink string
+	// AuditCollectorAddr is the collector address for AuditSink "grpc".
+	AuditCollectorAddr string
+
+	// MetricsEnabled starts the Prometheus /metrics listener on MetricsAddr.
+	MetricsEnabled bool
+	MetricsAddr    string
+
+	// TracingEnabled starts the OpenTelemetry tracer, exporting to
+	// OTLPEndpoint via OTLP/gRPC.
+	TracingEnabled bool
+	OTLPEndpoint   string
+	ServiceName    string
+}
+
+// DefaultObservabilityConfig returns every piece disabled.
+func DefaultObservabilityConfig() ObservabilityConfig {
+	return ObservabilityConfig{
+		AuditSink:      "none",
+		MetricsEnabled: false,
+		MetricsAddr:    "0.0.0.0:9090",
+		TracingEnabled: false,
+		OTLPEndpoint:   "localhost:4317",
+		ServiceName:    "trapperkeeper-sensor-api",
+	}
+}
+
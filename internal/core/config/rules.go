@@ -0,0 +1,23 @@
+package config
+
+// RulesConfig controls rules.Engine's evaluation behavior.
+// AdaptiveReordering defaults off, so a sensor's condition order stays
+// exactly what Compile's static cost model produced until an operator
+// opts in.
+type RulesConfig struct {
+	// AdaptiveReordering enables Engine.Evaluate's runtime-statistics-based
+	// condition reordering (see internal/rules/adaptive.go) in place of
+	// Compile's cost-model-only static order.
+	AdaptiveReordering bool
+	// ReorderEvery is how many evaluations an OrGroup accumulates between
+	// re-sorts. 0 uses rules.DefaultReorderEvery.
+	ReorderEvery uint64
+}
+
+// DefaultRulesConfig returns adaptive reordering disabled.
+func DefaultRulesConfig() RulesConfig {
+	return RulesConfig{
+		AdaptiveReordering: false,
+		ReorderEvery:       0,
+	}
+}
@@ -0,0 +1,484 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+ * HMAC secret providers.
+ *
+ * HMACSecrets() reads TK_HMAC_SECRET[_N] once at process start, which
+ * forces a restart to add, retire, or rotate a key. SecretProvider
+ * abstracts "where do the HMAC secrets come from" behind Current()/
+ * Subscribe() so callers (the authenticator, a future signer) don't care
+ * whether the answer is "parsed at startup" (EnvProvider) or "reloaded
+ * whenever the file on disk changes" (FileProvider).
+ */
+
+// SecretProvider supplies the HMAC secrets used to sign and verify API
+// keys.
+type SecretProvider interface {
+	// Current returns the provider's latest snapshot. Never nil once the
+	// provider has been constructed successfully.
+	Current() *SecretsSnapshot
+
+	// Subscribe returns a channel that receives the new snapshot each time
+	// Current changes, so consumers can react to rotation instead of
+	// polling Current on a timer. EnvProvider sends only after a
+	// SIGHUP-triggered reload.
+	Subscribe() <-chan *SecretsSnapshot
+}
+
+// SecretEntry is one HMAC key plus its rotation metadata.
+type SecretEntry struct {
+	SecretID  string
+	Secret    []byte
+	NotBefore time.Time // zero value means "valid from the start"
+	NotAfter  time.Time // zero value means "never expires"
+	Primary   bool
+}
+
+// validAt reports whether e may be used to sign or verify at t.
+func (e SecretEntry) validAt(t time.Time) bool {
+	if !e.NotBefore.IsZero() && t.Before(e.NotBefore) {
+		return false
+	}
+	if !e.NotAfter.IsZero() && !t.Before(e.NotAfter) {
+		return false
+	}
+	return true
+}
+
+// SecretsSnapshot is an immutable set of HMAC secrets at a point in time.
+// Providers swap in a new *SecretsSnapshot on reload rather than mutating
+// one in place, so a reader holding a snapshot never observes a partial
+// update.
+type SecretsSnapshot struct {
+	entries   map[string]SecretEntry
+	primaryID string
+}
+
+// newSecretsSnapshot validates entries and builds a snapshot, applying the
+// same checks HMACSecrets applies to env vars (32-char hex secret_id, no
+// duplicates, minimum secret length), plus the rotation invariant that
+// exactly one entry is marked primary whenever entries is non-empty.
+func newSecretsSnapshot(entries []SecretEntry) (*SecretsSnapshot, error) {
+	snap := &SecretsSnapshot{entries: make(map[string]SecretEntry, len(entries))}
+
+	for _, e := range entries {
+		if len(e.SecretID) != 32 {
+			return nil, fmt.Errorf("secret_id %q must be 32 hex chars (UUIDv7 without hyphens)", e.SecretID)
+		}
+		for _, c := range e.SecretID {
+			if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+				return nil, fmt.Errorf("secret_id %q must be hex chars only", e.SecretID)
+			}
+		}
+		if _, exists := snap.entries[e.SecretID]; exists {
+			return nil, fmt.Errorf("duplicate secret_id %q", e.SecretID)
+		}
+		if len(e.Secret) < 32 {
+			return nil, fmt.Errorf("secret %q must be at least 32 bytes, got %d", e.SecretID, len(e.Secret))
+		}
+		if e.Primary {
+			if snap.primaryID != "" {
+				return nil, fmt.Errorf("more than one secret marked primary (%q and %q)", snap.primaryID, e.SecretID)
+			}
+			snap.primaryID = e.SecretID
+		}
+		snap.entries[e.SecretID] = e
+	}
+
+	if len(snap.entries) > 0 && snap.primaryID == "" {
+		return nil, fmt.Errorf("exactly one secret must be marked primary (the current signing key)")
+	}
+
+	return snap, nil
+}
+
+// Lookup returns the secret for secretID if it exists and is valid at now.
+// Any non-expired key is accepted here, not just the primary one, so
+// verification keeps working against keys signed before a rotation.
+func (s *SecretsSnapshot) Lookup(secretID string, now time.Time) ([]byte, bool) {
+	e, ok := s.entries[secretID]
+	if !ok || !e.validAt(now) {
+		return nil, false
+	}
+	return e.Secret, true
+}
+
+// Signing returns the current signing key - the entry marked primary - if
+// it is valid at now.
+func (s *SecretsSnapshot) Signing(now time.Time) (secretID string, secret []byte, ok bool) {
+	if s.primaryID == "" {
+		return "", nil, false
+	}
+	e := s.entries[s.primaryID]
+	if !e.validAt(now) {
+		return "", nil, false
+	}
+	return e.SecretID, e.Secret, true
+}
+
+// Secrets returns every secret valid at now as a map, the shape
+// auth.NewAuthenticator already expects.
+func (s *SecretsSnapshot) Secrets(now time.Time) map[string][]byte {
+	out := make(map[string][]byte, len(s.entries))
+	for id, e := range s.entries {
+		if e.validAt(now) {
+			out[id] = e.Secret
+		}
+	}
+	return out
+}
+
+// subscriberHub fans a new snapshot out to every channel returned by a
+// past Subscribe call. Shared by EnvProvider and FileProvider so both
+// providers' hot-reload paths (SIGHUP and fsnotify, respectively) publish
+// the same way.
+type subscriberHub struct {
+	mu          sync.Mutex
+	subscribers []chan *SecretsSnapshot
+}
+
+func (h *subscriberHub) subscribe() <-chan *SecretsSnapshot {
+	ch := make(chan *SecretsSnapshot, 1)
+	h.mu.Lock()
+	h.subscribers = append(h.subscribers, ch)
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *subscriberHub) publish(snap *SecretsSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subscribers {
+		select {
+		case ch <- snap:
+		default:
+			// Slow subscriber: drop rather than block reload for everyone
+			// else. Current() always has the latest snapshot regardless.
+		}
+	}
+}
+
+// EnvProvider implements SecretProvider over TK_HMAC_SECRET[_N]. Unlike a
+// file mount, env vars can't be watched for changes, but an operator can
+// still update a running process' environment out-of-band (e.g. rewriting
+// /proc/<pid>/environ via an orchestrator, or more commonly just updating
+// the secret store a wrapper script re-exports before signaling) and ask
+// it to pick the change up: SIGHUP re-reads TK_HMAC_SECRET[_N] and swaps
+// in the result, logging the rotation and publishing it to subscribers the
+// same way FileProvider's fsnotify-triggered reload does.
+type EnvProvider struct {
+	mu       sync.RWMutex
+	snapshot *SecretsSnapshot
+
+	hub   subscriberHub
+	sigCh chan os.Signal
+	done  chan struct{}
+}
+
+// NewEnvProvider reads TK_HMAC_SECRET[_N] (marking the secret named by
+// TK_HMAC_ACTIVE_ID as primary, or - if that's unset - the first secret
+// encountered: TK_HMAC_SECRET, else the lowest-numbered
+// TK_HMAC_SECRET_N) and starts a goroutine that re-reads them on SIGHUP.
+func NewEnvProvider() (*EnvProvider, error) {
+	snap, err := parseEnvSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &EnvProvider{
+		snapshot: snap,
+		sigCh:    make(chan os.Signal, 1),
+		done:     make(chan struct{}),
+	}
+	signal.Notify(p.sigCh, syscall.SIGHUP)
+	go p.watch()
+	return p, nil
+}
+
+// parseEnvSecrets reads TK_HMAC_SECRET[_N] into a SecretsSnapshot. The
+// entry whose secret_id matches TK_HMAC_ACTIVE_ID, if set, is marked
+// primary (the current signing key), overriding the otherwise-implicit
+// "first one encountered is primary" default - this is what lets an
+// operator promote an already-deployed secondary key to primary on
+// rotation without having to also reorder env vars. An ACTIVE_ID that
+// doesn't match any parsed secret is an error: silently falling back would
+// leave the wrong key signing.
+func parseEnvSecrets() (*SecretsSnapshot, error) {
+	var entries []SecretEntry
+
+	if val := os.Getenv("TK_HMAC_SECRET"); val != "" {
+		id, secret, err := ParseHMACSecretWithID(val)
+		if err != nil {
+			return nil, fmt.Errorf("TK_HMAC_SECRET: %w", err)
+		}
+		entries = append(entries, SecretEntry{SecretID: id, Secret: secret, Primary: true})
+	}
+
+	for i := 1; ; i++ {
+		key := fmt.Sprintf("TK_HMAC_SECRET_%d", i)
+		val := os.Getenv(key)
+		if val == "" {
+			break
+		}
+		id, secret, err := ParseHMACSecretWithID(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		entries = append(entries, SecretEntry{SecretID: id, Secret: secret, Primary: len(entries) == 0})
+	}
+
+	if activeID := os.Getenv("TK_HMAC_ACTIVE_ID"); activeID != "" {
+		found := false
+		for i := range entries {
+			entries[i].Primary = entries[i].SecretID == activeID
+			found = found || entries[i].Primary
+		}
+		if !found {
+			return nil, fmt.Errorf("TK_HMAC_ACTIVE_ID %q does not match any TK_HMAC_SECRET[_N] secret_id", activeID)
+		}
+	}
+
+	return newSecretsSnapshot(entries)
+}
+
+func (p *EnvProvider) watch() {
+	for {
+		select {
+		case <-p.sigCh:
+			p.reload()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *EnvProvider) reload() {
+	snap, err := parseEnvSecrets()
+	if err != nil {
+		// Invalid reload: keep serving the previous snapshot rather than
+		// taking the sensor API down because an operator's env edit typo'd
+		// a secret_id or hex encoding.
+		log.Printf("hmac secret rotation (env, SIGHUP): rejected, previous secrets unchanged: %v", err)
+		return
+	}
+	p.mu.Lock()
+	p.snapshot = snap
+	p.mu.Unlock()
+	log.Printf("hmac secret rotation (env, SIGHUP): applied, %d secret(s) loaded", len(snap.entries))
+	p.hub.publish(snap)
+}
+
+// Current implements SecretProvider.
+func (p *EnvProvider) Current() *SecretsSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.snapshot
+}
+
+// Subscribe implements SecretProvider.
+func (p *EnvProvider) Subscribe() <-chan *SecretsSnapshot {
+	return p.hub.subscribe()
+}
+
+// Close stops listening for SIGHUP. Safe to call once.
+func (p *EnvProvider) Close() error {
+	signal.Stop(p.sigCh)
+	close(p.done)
+	return nil
+}
+
+// fileSecretEntry is the on-disk JSON/YAML shape of one secrets file
+// entry.
+type fileSecretEntry struct {
+	SecretID  string     `json:"secret_id" yaml:"secret_id"`
+	SecretB64 string     `json:"secret_b64" yaml:"secret_b64"`
+	NotBefore *time.Time `json:"not_before,omitempty" yaml:"not_before,omitempty"`
+	NotAfter  *time.Time `json:"not_after,omitempty" yaml:"not_after,omitempty"`
+	Primary   bool       `json:"primary" yaml:"primary"`
+}
+
+// FileProvider implements SecretProvider over a JSON or YAML file (format
+// chosen by extension) holding a list of fileSecretEntry. It re-parses the
+// file on every fsnotify event and swaps in the new snapshot only if it
+// parses and validates; a bad edit is rejected and the previous snapshot
+// keeps serving, mirroring HMACSecrets' duplicate-id and length checks.
+type FileProvider struct {
+	path string
+
+	mu       sync.RWMutex
+	snapshot *SecretsSnapshot
+
+	hub subscriberHub
+
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+}
+
+// NewFileProvider loads path and starts watching it for changes. Close
+// stops the watcher; callers that want hot reload for the life of the
+// process can simply never call it.
+func NewFileProvider(path string) (*FileProvider, error) {
+	snap, err := loadSecretsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating file watcher: %w", err)
+	}
+	// Watch the containing directory rather than path itself: editors and
+	// orchestrator secret mounts (e.g. a Kubernetes configmap/secret
+	// volume) commonly replace the file via rename rather than an in-place
+	// write, which fsnotify only reports against the directory entry.
+	dir := filepath.Dir(path)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	p := &FileProvider{
+		path:     path,
+		snapshot: snap,
+		watcher:  watcher,
+		done:     make(chan struct{}),
+	}
+	go p.watch()
+	return p, nil
+}
+
+func (p *FileProvider) watch() {
+	for {
+		select {
+		case event, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(p.path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			p.reload()
+		case _, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *FileProvider) reload() {
+	snap, err := loadSecretsFile(p.path)
+	if err != nil {
+		// Invalid reload: keep serving the previous snapshot rather than
+		// taking the sensor API down on an operator typo.
+		log.Printf("hmac secret rotation (file, %s): rejected, previous secrets unchanged: %v", p.path, err)
+		return
+	}
+	p.mu.Lock()
+	p.snapshot = snap
+	p.mu.Unlock()
+	log.Printf("hmac secret rotation (file, %s): applied, %d secret(s) loaded", p.path, len(snap.entries))
+	p.hub.publish(snap)
+}
+
+// Current implements SecretProvider.
+func (p *FileProvider) Current() *SecretsSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.snapshot
+}
+
+// Subscribe implements SecretProvider.
+func (p *FileProvider) Subscribe() <-chan *SecretsSnapshot {
+	return p.hub.subscribe()
+}
+
+// Close stops watching path. Safe to call once.
+func (p *FileProvider) Close() error {
+	close(p.done)
+	return p.watcher.Close()
+}
+
+func loadSecretsFile(path string) (*SecretsSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var raw []fileSecretEntry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing %s as JSON: %w", path, err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing %s as YAML: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported secrets file extension %q (use .json, .yaml, or .yml)", filepath.Ext(path))
+	}
+
+	entries := make([]SecretEntry, 0, len(raw))
+	for _, r := range raw {
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(r.SecretB64))
+		if err != nil {
+			return nil, fmt.Errorf("secret_id %q: invalid base64 encoding: %w", r.SecretID, err)
+		}
+		e := SecretEntry{
+			SecretID: r.SecretID,
+			Secret:   secret,
+			Primary:  r.Primary,
+		}
+		if r.NotBefore != nil {
+			e.NotBefore = *r.NotBefore
+		}
+		if r.NotAfter != nil {
+			e.NotAfter = *r.NotAfter
+		}
+		entries = append(entries, e)
+	}
+
+	return newSecretsSnapshot(entries)
+}
+
+// NewSecretProvider returns the SecretProvider selected via
+// TK_HMAC_SECRET_SOURCE ("vault://<kv-v2-path>" or "dir://<path>", see
+// secrets_remote.go), falling back to TK_HMAC_SECRETS_FILE (a
+// FileProvider) and then TK_HMAC_SECRET[_N] (an EnvProvider) for backward
+// compatibility. Like those, TK_HMAC_SECRET_SOURCE is read directly from
+// the environment rather than through viper - see
+// validateNoSecretsInConfig. A bare sensor_api.hmac_secret_source entry in
+// a config file is fine (it's a reference, not a secret), but nothing
+// reads it today - the secret source is selected by environment alone.
+func NewSecretProvider() (SecretProvider, error) {
+	if source := os.Getenv("TK_HMAC_SECRET_SOURCE"); source != "" {
+		return newSecretProviderFromSource(source)
+	}
+	if path := os.Getenv("TK_HMAC_SECRETS_FILE"); path != "" {
+		return NewFileProvider(path)
+	}
+	return NewEnvProvider()
+}
@@ -0,0 +1,373 @@
+// internal/core/config/secrets_remote.go
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+/*
+ * Remote secret sources.
+ *
+ * TK_HMAC_SECRETS_FILE/TK_HMAC_SECRET[_N] cover a single operator
+ * hand-managing secrets on one machine. TK_HMAC_SECRET_SOURCE generalizes
+ * that to a URL so a fleet can point at a directory of key files shared
+ * over a mounted volume (dir://) or a HashiCorp Vault KV v2 mount
+ * (vault://) instead - both still publish through the same
+ * SecretProvider.Current()/Subscribe() interface EnvProvider and
+ * FileProvider do, so the authenticator and rotation machinery don't need
+ * to know which source is in effect, and a remote-driven rotation reaches
+ * them the same way a SIGHUP or a file edit does.
+ */
+
+// DirProvider implements SecretProvider over a directory of
+// "<secret_id>.key" files holding raw (not base64) secret bytes - the
+// layout a mounted Kubernetes Secret or Vault Agent template commonly
+// produces. Like FileProvider it watches the directory via fsnotify and
+// reloads on any change.
+type DirProvider struct {
+	mu       sync.RWMutex
+	dir      string
+	watcher  *fsnotify.Watcher
+	snapshot *SecretsSnapshot
+
+	hub  subscriberHub
+	done chan struct{}
+}
+
+// NewDirProvider loads every "<secret_id>.key" file in dir and starts
+// watching the directory for changes. Close stops the watcher.
+func NewDirProvider(dir string) (*DirProvider, error) {
+	snap, err := loadSecretsDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating directory watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	p := &DirProvider{
+		dir:      dir,
+		watcher:  watcher,
+		snapshot: snap,
+		done:     make(chan struct{}),
+	}
+	go p.watch()
+	return p, nil
+}
+
+func (p *DirProvider) watch() {
+	for {
+		select {
+		case _, ok := <-p.watcher.Events:
+			if !ok {
+				return
+			}
+			p.reload()
+		case err, ok := <-p.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("config: directory secret watcher error: %v", err)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *DirProvider) reload() {
+	snap, err := loadSecretsDir(p.dir)
+	if err != nil {
+		log.Printf("config: reloading %s: %v", p.dir, err)
+		return
+	}
+	p.mu.Lock()
+	p.snapshot = snap
+	p.mu.Unlock()
+	p.hub.publish(snap)
+}
+
+// Current implements SecretProvider.
+func (p *DirProvider) Current() *SecretsSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.snapshot
+}
+
+// Subscribe implements SecretProvider.
+func (p *DirProvider) Subscribe() <-chan *SecretsSnapshot {
+	return p.hub.subscribe()
+}
+
+func (p *DirProvider) Close() error {
+	close(p.done)
+	return p.watcher.Close()
+}
+
+// loadSecretsDir reads every "<secret_id>.key" file in dir into a
+// SecretsSnapshot. Unlike loadSecretsFile's JSON/YAML, each file holds the
+// raw secret bytes directly with no rotation metadata - NotBefore/NotAfter
+// are always zero (always valid), and which entry is primary comes from
+// applyActiveID (TK_HMAC_ACTIVE_ID), the same selection EnvProvider applies
+// to TK_HMAC_SECRET[_N].
+func loadSecretsDir(dir string) (*SecretsSnapshot, error) {
+	entriesOnDisk, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var entries []SecretEntry
+	for _, de := range entriesOnDisk {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".key") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, de.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", de.Name(), err)
+		}
+		entries = append(entries, SecretEntry{
+			SecretID: strings.TrimSuffix(de.Name(), ".key"),
+			Secret:   data,
+		})
+	}
+
+	if err := applyActiveID(entries); err != nil {
+		return nil, err
+	}
+
+	return newSecretsSnapshot(entries)
+}
+
+// applyActiveID marks one of entries primary in place, for sources
+// (DirProvider, VaultProvider) that have no file-level "primary" field and
+// no TK_HMAC_SECRET/_N-style implicit ordering the way EnvProvider does.
+// TK_HMAC_ACTIVE_ID, if set, names the secret_id to promote - the same env
+// var parseEnvSecrets applies to TK_HMAC_SECRET[_N] - and it is an error
+// for it to name a secret_id not present in entries. If unset, the
+// lexically-first secret_id is primary, so the choice is deterministic
+// despite directory listings and Vault's KV v2 response giving no other
+// notion of "first".
+func applyActiveID(entries []SecretEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].SecretID < entries[j].SecretID })
+
+	activeID := os.Getenv("TK_HMAC_ACTIVE_ID")
+	if activeID == "" {
+		entries[0].Primary = true
+		return nil
+	}
+
+	found := false
+	for i := range entries {
+		entries[i].Primary = entries[i].SecretID == activeID
+		found = found || entries[i].Primary
+	}
+	if !found {
+		return fmt.Errorf("TK_HMAC_ACTIVE_ID %q does not match any loaded secret_id", activeID)
+	}
+	return nil
+}
+
+// vaultPollInterval is how often VaultProvider re-reads its KV v2 path and
+// renews its token, absent any push-based notification - Vault's KV v2
+// engine has no watch API, so polling is the only option.
+const vaultPollInterval = 30 * time.Second
+
+// VaultProvider implements SecretProvider over a HashiCorp Vault KV v2
+// mount. It polls the mount for changes and renews its own token on the
+// same interval, so a long-running process doesn't have its access
+// revoked out from under it between polls.
+type VaultProvider struct {
+	mu       sync.RWMutex
+	addr     string
+	token    string
+	path     string // e.g. "secret/data/trapperkeeper/hmac"
+	client   *http.Client
+	snapshot *SecretsSnapshot
+
+	hub  subscriberHub
+	done chan struct{}
+}
+
+// NewVaultProvider reads secretPath (a KV v2 "secret/data/..." path) from
+// the Vault at addr, authenticating with token, and starts polling it
+// every vaultPollInterval. addr and token come from TK_VAULT_ADDR/
+// TK_VAULT_TOKEN - environment-only, same as every other HMAC secret
+// source - see NewSecretProvider.
+func NewVaultProvider(addr, token, secretPath string) (*VaultProvider, error) {
+	p := &VaultProvider{
+		addr:   strings.TrimRight(addr, "/"),
+		token:  token,
+		path:   secretPath,
+		client: &http.Client{Timeout: 10 * time.Second},
+		done:   make(chan struct{}),
+	}
+
+	snap, err := p.fetch()
+	if err != nil {
+		return nil, err
+	}
+	p.snapshot = snap
+
+	go p.watch()
+	return p, nil
+}
+
+func (p *VaultProvider) watch() {
+	ticker := time.NewTicker(vaultPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.renewToken(); err != nil {
+				log.Printf("config: renewing vault token: %v", err)
+			}
+			snap, err := p.fetch()
+			if err != nil {
+				log.Printf("config: reloading vault secret %s: %v", p.path, err)
+				continue
+			}
+			p.mu.Lock()
+			p.snapshot = snap
+			p.mu.Unlock()
+			p.hub.publish(snap)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// vaultKVv2Response is the subset of Vault's KV v2 read response this
+// provider needs. Data.Data maps secret_id to a base64-encoded secret,
+// matching the convention TK_HMAC_SECRET[_N] and FileProvider's
+// secret_b64 field already use.
+type vaultKVv2Response struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+func (p *VaultProvider) fetch() (*SecretsSnapshot, error) {
+	req, err := http.NewRequest(http.MethodGet, p.addr+"/v1/"+p.path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault secret %s: %w", p.path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("reading vault secret %s: vault returned %d: %s", p.path, resp.StatusCode, string(body))
+	}
+
+	var parsed vaultKVv2Response
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decoding vault response for %s: %w", p.path, err)
+	}
+
+	entries := make([]SecretEntry, 0, len(parsed.Data.Data))
+	for secretID, b64 := range parsed.Data.Data {
+		secret, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("vault secret %s: entry %q: invalid base64: %w", p.path, secretID, err)
+		}
+		entries = append(entries, SecretEntry{SecretID: secretID, Secret: secret})
+	}
+
+	if err := applyActiveID(entries); err != nil {
+		return nil, err
+	}
+
+	return newSecretsSnapshot(entries)
+}
+
+// renewToken extends this provider's token's TTL so polling doesn't
+// eventually start failing with permission-denied once the token's
+// original lease expires. Vault rejects renewal for non-renewable tokens
+// (e.g. root tokens) with a 400, which renewToken treats as a no-op
+// rather than an error.
+func (p *VaultProvider) renewToken() error {
+	req, err := http.NewRequest(http.MethodPost, p.addr+"/v1/auth/token/renew-self", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("vault returned %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// Current implements SecretProvider.
+func (p *VaultProvider) Current() *SecretsSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.snapshot
+}
+
+// Subscribe implements SecretProvider.
+func (p *VaultProvider) Subscribe() <-chan *SecretsSnapshot {
+	return p.hub.subscribe()
+}
+
+func (p *VaultProvider) Close() error {
+	close(p.done)
+	return nil
+}
+
+// newSecretProviderFromSource parses a TK_HMAC_SECRET_SOURCE URL and
+// constructs the provider it names. See NewSecretProvider.
+func newSecretProviderFromSource(source string) (SecretProvider, error) {
+	switch {
+	case strings.HasPrefix(source, "vault://"):
+		addr := os.Getenv("TK_VAULT_ADDR")
+		if addr == "" {
+			return nil, fmt.Errorf("TK_HMAC_SECRET_SOURCE=%s requires TK_VAULT_ADDR", source)
+		}
+		token := os.Getenv("TK_VAULT_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("TK_HMAC_SECRET_SOURCE=%s requires TK_VAULT_TOKEN", source)
+		}
+		return NewVaultProvider(addr, token, strings.TrimPrefix(source, "vault://"))
+	case strings.HasPrefix(source, "dir://"):
+		return NewDirProvider(strings.TrimPrefix(source, "dir://"))
+	case strings.HasPrefix(source, "awskms://"):
+		return nil, fmt.Errorf("TK_HMAC_SECRET_SOURCE scheme \"awskms\" is not implemented yet (vault:// and dir:// are)")
+	default:
+		return nil, fmt.Errorf("TK_HMAC_SECRET_SOURCE: unrecognized scheme in %q", source)
+	}
+}
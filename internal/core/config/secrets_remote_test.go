@@ -0,0 +1,217 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyFile(t *testing.T, dir, secretID string, secret []byte) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, secretID+".key"), secret, 0o600); err != nil {
+		t.Fatalf("writing key file: %v", err)
+	}
+}
+
+func TestDirProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("loads every *.key file", func(t *testing.T) {
+		sub := filepath.Join(dir, "load")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+
+		p, err := NewDirProvider(sub)
+		if err != nil {
+			t.Fatalf("NewDirProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		if _, ok := p.Current().Lookup(testSecretIDA, time.Now()); !ok {
+			t.Errorf("Lookup(%q) = not found, want found", testSecretIDA)
+		}
+	})
+
+	t.Run("hot-add picks up a new key file without reconstructing the provider", func(t *testing.T) {
+		sub := filepath.Join(dir, "hot-add")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+
+		p, err := NewDirProvider(sub)
+		if err != nil {
+			t.Fatalf("NewDirProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		ch := p.Subscribe()
+		writeKeyFile(t, sub, testSecretIDB, []byte("secret-b-bytes-x0123456789abcdef"))
+
+		select {
+		case snap := <-ch:
+			if _, ok := snap.Lookup(testSecretIDB, time.Now()); !ok {
+				t.Errorf("Lookup(%q) after hot-add = not found, want found", testSecretIDB)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for DirProvider to pick up hot-add")
+		}
+	})
+
+	t.Run("TK_HMAC_ACTIVE_ID promotes the named secret to primary", func(t *testing.T) {
+		sub := filepath.Join(dir, "active-id")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+		writeKeyFile(t, sub, testSecretIDB, []byte("secret-b-bytes-x0123456789abcdef"))
+
+		os.Setenv("TK_HMAC_ACTIVE_ID", testSecretIDB)
+		defer os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+		p, err := NewDirProvider(sub)
+		if err != nil {
+			t.Fatalf("NewDirProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		id, _, ok := p.Current().Signing(time.Now())
+		if !ok || id != testSecretIDB {
+			t.Errorf("Signing() = (%q, %v), want (%q, true)", id, ok, testSecretIDB)
+		}
+	})
+
+	t.Run("TK_HMAC_ACTIVE_ID naming an unknown secret errors", func(t *testing.T) {
+		sub := filepath.Join(dir, "active-id-unknown")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+
+		os.Setenv("TK_HMAC_ACTIVE_ID", "does-not-exist")
+		defer os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+		if _, err := NewDirProvider(sub); err == nil {
+			t.Error("NewDirProvider() with an unknown TK_HMAC_ACTIVE_ID = nil error, want error")
+		}
+	})
+
+	t.Run("without TK_HMAC_ACTIVE_ID the lexically-first secret_id is primary", func(t *testing.T) {
+		sub := filepath.Join(dir, "default-primary")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+		writeKeyFile(t, sub, testSecretIDB, []byte("secret-b-bytes-x0123456789abcdef"))
+
+		p, err := NewDirProvider(sub)
+		if err != nil {
+			t.Fatalf("NewDirProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		want := testSecretIDA
+		if testSecretIDB < testSecretIDA {
+			want = testSecretIDB
+		}
+		id, _, ok := p.Current().Signing(time.Now())
+		if !ok || id != want {
+			t.Errorf("Signing() = (%q, %v), want (%q, true)", id, ok, want)
+		}
+	})
+
+	t.Run("non-.key files are ignored", func(t *testing.T) {
+		sub := filepath.Join(dir, "ignore")
+		os.MkdirAll(sub, 0o755)
+		writeKeyFile(t, sub, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+		if err := os.WriteFile(filepath.Join(sub, "README.md"), []byte("not a secret"), 0o600); err != nil {
+			t.Fatalf("writing README: %v", err)
+		}
+
+		p, err := NewDirProvider(sub)
+		if err != nil {
+			t.Fatalf("NewDirProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		if _, ok := p.Current().Lookup("README", time.Now()); ok {
+			t.Error("Lookup(\"README\") = found, want not found")
+		}
+	})
+}
+
+func TestVaultProvider(t *testing.T) {
+	secret := testSecretB64('a')
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/secret/data/trapperkeeper/hmac":
+			if r.Header.Get("X-Vault-Token") != "test-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			resp := vaultKVv2Response{}
+			resp.Data.Data = map[string]string{testSecretIDA: secret}
+			json.NewEncoder(w).Encode(resp)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/auth/token/renew-self":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewVaultProvider(server.URL, "test-token", "secret/data/trapperkeeper/hmac")
+	if err != nil {
+		t.Fatalf("NewVaultProvider() error = %v", err)
+	}
+	defer p.Close()
+
+	if _, ok := p.Current().Lookup(testSecretIDA, time.Now()); !ok {
+		t.Errorf("Lookup(%q) = not found, want found", testSecretIDA)
+	}
+}
+
+func TestVaultProvider_WrongToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	if _, err := NewVaultProvider(server.URL, "wrong-token", "secret/data/trapperkeeper/hmac"); err == nil {
+		t.Error("NewVaultProvider() error = nil, want non-nil for a rejected token")
+	}
+}
+
+func TestNewSecretProviderFromSource(t *testing.T) {
+	t.Run("vault:// requires TK_VAULT_ADDR", func(t *testing.T) {
+		os.Unsetenv("TK_VAULT_ADDR")
+		os.Unsetenv("TK_VAULT_TOKEN")
+		if _, err := newSecretProviderFromSource("vault://secret/data/trapperkeeper/hmac"); err == nil {
+			t.Error("newSecretProviderFromSource() error = nil, want non-nil without TK_VAULT_ADDR")
+		}
+	})
+
+	t.Run("dir:// constructs a DirProvider", func(t *testing.T) {
+		dir := t.TempDir()
+		writeKeyFile(t, dir, testSecretIDA, []byte("secret-a-bytes-x0123456789abcdef"))
+
+		p, err := newSecretProviderFromSource(fmt.Sprintf("dir://%s", dir))
+		if err != nil {
+			t.Fatalf("newSecretProviderFromSource() error = %v", err)
+		}
+		defer p.(*DirProvider).Close()
+
+		if _, ok := p.Current().Lookup(testSecretIDA, time.Now()); !ok {
+			t.Errorf("Lookup(%q) = not found, want found", testSecretIDA)
+		}
+	})
+
+	t.Run("unrecognized scheme errors", func(t *testing.T) {
+		if _, err := newSecretProviderFromSource("ftp://wherever"); err == nil {
+			t.Error("newSecretProviderFromSource() error = nil, want non-nil for an unrecognized scheme")
+		}
+	})
+
+	t.Run("awskms:// errors honestly instead of pretending to work", func(t *testing.T) {
+		if _, err := newSecretProviderFromSource("awskms://alias/trapperkeeper-hmac"); err == nil {
+			t.Error("newSecretProviderFromSource() error = nil, want non-nil for the unimplemented awskms scheme")
+		}
+	})
+}
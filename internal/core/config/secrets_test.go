@@ -0,0 +1,281 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const (
+	testSecretIDA = "0123456789abcdef0123456789abcdef"
+	testSecretIDB = "fedcba9876543210fedcba9876543210"
+)
+
+func testSecretB64(seed byte) string {
+	b := make([]byte, 32)
+	for i := range b {
+		b[i] = seed
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func writeSecretsFile(t *testing.T, dir string, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "secrets.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("writing secrets file: %v", err)
+	}
+	return path
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("loads a primary secret", func(t *testing.T) {
+		sub := filepath.Join(dir, "load")
+		os.MkdirAll(sub, 0o755)
+		body := fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}]`, testSecretIDA, testSecretB64('a'))
+		path := writeSecretsFile(t, sub, body)
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		id, _, ok := p.Current().Signing(time.Now())
+		if !ok || id != testSecretIDA {
+			t.Errorf("Signing() = (%q, %v), want (%q, true)", id, ok, testSecretIDA)
+		}
+	})
+
+	t.Run("hot-add picks up a new secret without reconstructing the provider", func(t *testing.T) {
+		sub := filepath.Join(dir, "hot-add")
+		os.MkdirAll(sub, 0o755)
+		body := fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}]`, testSecretIDA, testSecretB64('a'))
+		path := writeSecretsFile(t, sub, body)
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		ch := p.Subscribe()
+
+		body = fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}, {"secret_id": %q, "secret_b64": %q}]`,
+			testSecretIDA, testSecretB64('a'), testSecretIDB, testSecretB64('b'))
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("rewriting secrets file: %v", err)
+		}
+
+		select {
+		case snap := <-ch:
+			if _, ok := snap.Lookup(testSecretIDB, time.Now()); !ok {
+				t.Errorf("Lookup(%q) after hot-add = not found, want found", testSecretIDB)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for FileProvider to pick up hot-add")
+		}
+	})
+
+	t.Run("hot-remove retires a secret", func(t *testing.T) {
+		sub := filepath.Join(dir, "hot-remove")
+		os.MkdirAll(sub, 0o755)
+		body := fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}, {"secret_id": %q, "secret_b64": %q}]`,
+			testSecretIDA, testSecretB64('a'), testSecretIDB, testSecretB64('b'))
+		path := writeSecretsFile(t, sub, body)
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		ch := p.Subscribe()
+
+		body = fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}]`, testSecretIDA, testSecretB64('a'))
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("rewriting secrets file: %v", err)
+		}
+
+		select {
+		case snap := <-ch:
+			if _, ok := snap.Lookup(testSecretIDB, time.Now()); ok {
+				t.Errorf("Lookup(%q) after hot-remove = found, want not found", testSecretIDB)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for FileProvider to pick up hot-remove")
+		}
+	})
+
+	t.Run("invalid reload rolls back to the previous snapshot", func(t *testing.T) {
+		sub := filepath.Join(dir, "rollback")
+		os.MkdirAll(sub, 0o755)
+		body := fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}]`, testSecretIDA, testSecretB64('a'))
+		path := writeSecretsFile(t, sub, body)
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		// Invalid: two primaries.
+		bad := fmt.Sprintf(`[{"secret_id": %q, "secret_b64": %q, "primary": true}, {"secret_id": %q, "secret_b64": %q, "primary": true}]`,
+			testSecretIDA, testSecretB64('a'), testSecretIDB, testSecretB64('b'))
+		if err := os.WriteFile(path, []byte(bad), 0o600); err != nil {
+			t.Fatalf("rewriting secrets file: %v", err)
+		}
+
+		// Give the watcher a moment to process the (rejected) reload, then
+		// confirm the original snapshot is still being served.
+		time.Sleep(300 * time.Millisecond)
+
+		id, _, ok := p.Current().Signing(time.Now())
+		if !ok || id != testSecretIDA {
+			t.Errorf("after invalid reload, Signing() = (%q, %v), want (%q, true) (rolled back)", id, ok, testSecretIDA)
+		}
+		if _, ok := p.Current().Lookup(testSecretIDB, time.Now()); ok {
+			t.Error("after invalid reload, rejected secret_id should not be present")
+		}
+	})
+
+	t.Run("not_before and not_after gate verification by clock", func(t *testing.T) {
+		sub := filepath.Join(dir, "gating")
+		os.MkdirAll(sub, 0o755)
+		now := time.Now()
+		notBefore := now.Add(time.Hour)
+		notAfter := now.Add(-time.Hour)
+		body := fmt.Sprintf(`[
+			{"secret_id": %q, "secret_b64": %q, "primary": true},
+			{"secret_id": %q, "secret_b64": %q, "not_before": %q}
+		]`, testSecretIDA, testSecretB64('a'), testSecretIDB, testSecretB64('b'), notBefore.Format(time.RFC3339))
+		path := writeSecretsFile(t, sub, body)
+
+		p, err := NewFileProvider(path)
+		if err != nil {
+			t.Fatalf("NewFileProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		if _, ok := p.Current().Lookup(testSecretIDB, now); ok {
+			t.Errorf("Lookup(%q) before not_before = found, want not found", testSecretIDB)
+		}
+		if _, ok := p.Current().Lookup(testSecretIDB, notBefore.Add(time.Minute)); !ok {
+			t.Errorf("Lookup(%q) after not_before = not found, want found", testSecretIDB)
+		}
+
+		// Separately: a secret whose not_after has already passed is never
+		// valid for verification.
+		expired := SecretEntry{SecretID: testSecretIDA, Secret: []byte("01234567890123456789012345678901"), Primary: true, NotAfter: notAfter}
+		snap, err := newSecretsSnapshot([]SecretEntry{expired})
+		if err != nil {
+			t.Fatalf("newSecretsSnapshot() error = %v", err)
+		}
+		if _, ok := snap.Lookup(testSecretIDA, now); ok {
+			t.Error("Lookup() for an expired (not_after in the past) secret = found, want not found")
+		}
+	})
+}
+
+func TestEnvProvider(t *testing.T) {
+	os.Unsetenv("TK_HMAC_SECRET")
+	os.Unsetenv("TK_HMAC_SECRET_1")
+	os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+	os.Setenv("TK_HMAC_SECRET", fmt.Sprintf("%s:%s", testSecretIDA, testSecretB64('a')))
+	defer os.Unsetenv("TK_HMAC_SECRET")
+
+	p, err := NewEnvProvider()
+	if err != nil {
+		t.Fatalf("NewEnvProvider() error = %v", err)
+	}
+	defer p.Close()
+
+	id, _, ok := p.Current().Signing(time.Now())
+	if !ok || id != testSecretIDA {
+		t.Errorf("Signing() = (%q, %v), want (%q, true)", id, ok, testSecretIDA)
+	}
+
+	// Nothing has rotated yet, so Subscribe's channel should not have a
+	// snapshot ready.
+	select {
+	case <-p.Subscribe():
+		t.Error("EnvProvider.Subscribe() sent a snapshot before any reload, want none")
+	default:
+	}
+}
+
+func TestEnvProvider_ActiveIDOverridesPrimary(t *testing.T) {
+	os.Unsetenv("TK_HMAC_SECRET")
+	os.Unsetenv("TK_HMAC_SECRET_1")
+	defer os.Unsetenv("TK_HMAC_SECRET")
+	defer os.Unsetenv("TK_HMAC_SECRET_1")
+	defer os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+	os.Setenv("TK_HMAC_SECRET", fmt.Sprintf("%s:%s", testSecretIDA, testSecretB64('a')))
+	os.Setenv("TK_HMAC_SECRET_1", fmt.Sprintf("%s:%s", testSecretIDB, testSecretB64('b')))
+
+	t.Run("promotes the named secret to primary", func(t *testing.T) {
+		os.Setenv("TK_HMAC_ACTIVE_ID", testSecretIDB)
+		defer os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+		p, err := NewEnvProvider()
+		if err != nil {
+			t.Fatalf("NewEnvProvider() error = %v", err)
+		}
+		defer p.Close()
+
+		id, _, ok := p.Current().Signing(time.Now())
+		if !ok || id != testSecretIDB {
+			t.Errorf("Signing() = (%q, %v), want (%q, true)", id, ok, testSecretIDB)
+		}
+	})
+
+	t.Run("errors when the named secret does not exist", func(t *testing.T) {
+		os.Setenv("TK_HMAC_ACTIVE_ID", "does-not-exist")
+		defer os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+		if _, err := NewEnvProvider(); err == nil {
+			t.Error("NewEnvProvider() with an unknown TK_HMAC_ACTIVE_ID = nil error, want error")
+		}
+	})
+}
+
+func TestEnvProvider_SIGHUPReload(t *testing.T) {
+	os.Unsetenv("TK_HMAC_SECRET")
+	os.Unsetenv("TK_HMAC_SECRET_1")
+	os.Unsetenv("TK_HMAC_ACTIVE_ID")
+
+	os.Setenv("TK_HMAC_SECRET", fmt.Sprintf("%s:%s", testSecretIDA, testSecretB64('a')))
+	defer os.Unsetenv("TK_HMAC_SECRET")
+
+	p, err := NewEnvProvider()
+	if err != nil {
+		t.Fatalf("NewEnvProvider() error = %v", err)
+	}
+	defer p.Close()
+
+	ch := p.Subscribe()
+
+	os.Setenv("TK_HMAC_SECRET_1", fmt.Sprintf("%s:%s", testSecretIDB, testSecretB64('b')))
+	defer os.Unsetenv("TK_HMAC_SECRET_1")
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("sending SIGHUP: %v", err)
+	}
+
+	select {
+	case snap := <-ch:
+		if _, ok := snap.Lookup(testSecretIDB, time.Now()); !ok {
+			t.Errorf("Lookup(%q) after SIGHUP reload = not found, want found", testSecretIDB)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for EnvProvider to pick up SIGHUP reload")
+	}
+}
@@ -19,6 +19,39 @@ func LoadConfig(configPath string) (*SensorAPIConfig, error) {
 	v.SetDefault("sensor_api.request_timeout", "30s")
 	v.SetDefault("sensor_api.max_batch_size", 1000)
 	v.SetDefault("sensor_api.data_dir", "./data")
+	defaultSensorAPI := DefaultSensorAPIConfig()
+	v.SetDefault("sensor_api.jsonl_sync_interval", defaultSensorAPI.JSONLSyncInterval)
+	v.SetDefault("sensor_api.jsonl_max_buffered_bytes", defaultSensorAPI.JSONLMaxBufferedBytes)
+	v.SetDefault("sensor_api.migration_lock_timeout", defaultSensorAPI.MigrationLockTimeout)
+	v.SetDefault("sensor_api.migration_lock_retry_interval", defaultSensorAPI.MigrationLockRetryInterval)
+
+	// Observability defaults - everything off, so an operator opts in
+	// per-sensor (see ObservabilityConfig's doc comment).
+	defaultObs := DefaultObservabilityConfig()
+	v.SetDefault("observability.audit_sink", defaultObs.AuditSink)
+	v.SetDefault("observability.audit_collector_addr", defaultObs.AuditCollectorAddr)
+	v.SetDefault("observability.metrics_enabled", defaultObs.MetricsEnabled)
+	v.SetDefault("observability.metrics_addr", defaultObs.MetricsAddr)
+	v.SetDefault("observability.tracing_enabled", defaultObs.TracingEnabled)
+	v.SetDefault("observability.otlp_endpoint", defaultObs.OTLPEndpoint)
+	v.SetDefault("observability.service_name", defaultObs.ServiceName)
+
+	// Rules defaults - adaptive reordering off, so AdaptiveReordering
+	// requires an explicit opt-in per-sensor (see RulesConfig's doc comment).
+	defaultRules := DefaultRulesConfig()
+	v.SetDefault("rules.adaptive_reordering", defaultRules.AdaptiveReordering)
+	v.SetDefault("rules.reorder_every", defaultRules.ReorderEvery)
+
+	// JWT defaults - disabled, so a sensor keeps using HMAC API keys alone
+	// until an operator opts in (see JWTConfig's doc comment).
+	defaultJWT := DefaultJWTConfig()
+	v.SetDefault("jwt.enabled", defaultJWT.Enabled)
+	v.SetDefault("jwt.issuer", defaultJWT.Issuer)
+	v.SetDefault("jwt.audience", defaultJWT.Audience)
+	v.SetDefault("jwt.jwks_url", defaultJWT.JWKSURL)
+	v.SetDefault("jwt.tenant_claim_path", defaultJWT.TenantClaimPath)
+	v.SetDefault("jwt.leeway", defaultJWT.Leeway)
+	v.SetDefault("jwt.jwks_refresh_interval", defaultJWT.JWKSRefreshInterval)
 
 	// Bind environment variables with TK_ prefix
 	v.SetEnvPrefix("TK")
@@ -46,6 +79,35 @@ func LoadConfig(configPath string) (*SensorAPIConfig, error) {
 		RequestTimeout: v.GetDuration("sensor_api.request_timeout"),
 		MaxBatchSize:   v.GetInt("sensor_api.max_batch_size"),
 		DataDir:        v.GetString("sensor_api.data_dir"),
+
+		JSONLSyncInterval:     v.GetDuration("sensor_api.jsonl_sync_interval"),
+		JSONLMaxBufferedBytes: v.GetInt("sensor_api.jsonl_max_buffered_bytes"),
+
+		MigrationLockTimeout:       v.GetDuration("sensor_api.migration_lock_timeout"),
+		MigrationLockRetryInterval: v.GetDuration("sensor_api.migration_lock_retry_interval"),
+
+		Observability: ObservabilityConfig{
+			AuditSink:          v.GetString("observability.audit_sink"),
+			AuditCollectorAddr: v.GetString("observability.audit_collector_addr"),
+			MetricsEnabled:     v.GetBool("observability.metrics_enabled"),
+			MetricsAddr:        v.GetString("observability.metrics_addr"),
+			TracingEnabled:     v.GetBool("observability.tracing_enabled"),
+			OTLPEndpoint:       v.GetString("observability.otlp_endpoint"),
+			ServiceName:        v.GetString("observability.service_name"),
+		},
+		Rules: RulesConfig{
+			AdaptiveReordering: v.GetBool("rules.adaptive_reordering"),
+			ReorderEvery:       uint64(v.GetInt64("rules.reorder_every")),
+		},
+		JWT: JWTConfig{
+			Enabled:             v.GetBool("jwt.enabled"),
+			Issuer:              v.GetString("jwt.issuer"),
+			Audience:            v.GetString("jwt.audience"),
+			JWKSURL:             v.GetString("jwt.jwks_url"),
+			TenantClaimPath:     v.GetString("jwt.tenant_claim_path"),
+			Leeway:              v.GetDuration("jwt.leeway"),
+			JWKSRefreshInterval: v.GetDuration("jwt.jwks_refresh_interval"),
+		},
 	}
 
 	if err := validateConfig(cfg); err != nil {
@@ -69,6 +131,26 @@ func validateConfig(cfg *SensorAPIConfig) error {
 	if cfg.MaxBatchSize <= 0 {
 		return fmt.Errorf("max_batch_size must be positive, got %d", cfg.MaxBatchSize)
 	}
+	if cfg.MigrationLockTimeout <= 0 {
+		return fmt.Errorf("migration_lock_timeout must be positive, got %v", cfg.MigrationLockTimeout)
+	}
+	if cfg.MigrationLockRetryInterval <= 0 {
+		return fmt.Errorf("migration_lock_retry_interval must be positive, got %v", cfg.MigrationLockRetryInterval)
+	}
+	if cfg.JWT.Enabled {
+		if cfg.JWT.Issuer == "" {
+			return fmt.Errorf("jwt.issuer is required when jwt.enabled is true")
+		}
+		if cfg.JWT.Audience == "" {
+			return fmt.Errorf("jwt.audience is required when jwt.enabled is true")
+		}
+		if cfg.JWT.JWKSURL == "" {
+			return fmt.Errorf("jwt.jwks_url is required when jwt.enabled is true")
+		}
+		if cfg.JWT.TenantClaimPath == "" {
+			return fmt.Errorf("jwt.tenant_claim_path is required when jwt.enabled is true")
+		}
+	}
 	return nil
 }
 
@@ -77,5 +159,8 @@ func validateNoSecretsInConfig(v *viper.Viper) error {
 	if v.IsSet("hmac_secret") || v.IsSet("sensor_api.hmac_secret") {
 		return fmt.Errorf("HMAC secrets not allowed in config files (use TK_HMAC_SECRET environment variable)")
 	}
+	if v.IsSet("hmac_secrets_file") || v.IsSet("sensor_api.hmac_secrets_file") {
+		return fmt.Errorf("HMAC secrets file path not allowed in config files (use TK_HMAC_SECRETS_FILE environment variable)")
+	}
 	return nil
 }
@@ -0,0 +1,165 @@
+// internal/core/db/migration_lock.go
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+/*
+ * Distributed lock around MigrateUp.
+ *
+ * A rolling deploy can start several trapperkeeper instances against the
+ * same database at once; without coordination they'd all race the same
+ * DDL, producing duplicate-key errors or, worse, partially-applied
+ * migrations. acquireMigrationLock makes the first instance to reach
+ * MigrateUp run the migrations while the rest poll until either it's
+ * their turn (the lock holder released it with nothing left pending) or
+ * MigrationLockPolicy.Timeout elapses, in which case MigrateUp returns
+ * ErrMigrationLocked so the caller can back off and retry later.
+ *
+ * PostgreSQL gets a real session-scoped advisory lock (pg_try_advisory_lock),
+ * held on a single reserved connection for the duration of the migration
+ * run. SQLite has no equivalent primitive reachable through database/sql,
+ * so it gets a migration_lock table guarded by a unique primary key: the
+ * first INSERT wins, and losers keep retrying the INSERT until it
+ * succeeds or they time out.
+ */
+
+// ErrMigrationLocked is returned by MigrateUp/MigrateUpWithLock when the
+// migration lock is still held by another instance after
+// MigrationLockPolicy.Timeout has elapsed.
+var ErrMigrationLocked = errors.New("db: migration lock held by another instance")
+
+// migrationLockKey names the lock every instance of a given trapperkeeper
+// deployment contends for - a fixed string rather than something derived
+// per-database, since the whole point is that all instances agree on it.
+const migrationLockKey = "trapperkeeper_migrations"
+
+// MigrationLockPolicy configures acquireMigrationLock: how long to wait
+// for the lock before giving up with ErrMigrationLocked, and how often to
+// poll for it while waiting. The zero value is not usable; construct with
+// DefaultMigrationLockPolicy or populate explicitly.
+type MigrationLockPolicy struct {
+	Timeout       time.Duration
+	RetryInterval time.Duration
+}
+
+// DefaultMigrationLockPolicy is the policy MigrateUp uses: wait up to 60s,
+// polling every 500ms, which is enough for a handful of rolling-deploy
+// instances to take turns without any of them giving up too early.
+func DefaultMigrationLockPolicy() MigrationLockPolicy {
+	return MigrationLockPolicy{
+		Timeout:       60 * time.Second,
+		RetryInterval: 500 * time.Millisecond,
+	}
+}
+
+// acquireMigrationLock blocks (polling at policy.RetryInterval) until it
+// holds the migration lock or policy.Timeout elapses, in which case it
+// returns ErrMigrationLocked. On success it returns an unlock func the
+// caller must call exactly once, however MigrateUpWithLock returns.
+func acquireMigrationLock(db *sqlx.DB, policy MigrationLockPolicy) (unlock func(), err error) {
+	switch db.DriverName() {
+	case "postgres":
+		return acquirePostgresLock(db, policy)
+	case "sqlite3":
+		return acquireSqliteLock(db, policy)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", db.DriverName())
+	}
+}
+
+// acquirePostgresLock takes pg_try_advisory_lock on a single reserved
+// connection (advisory locks are session-scoped, so acquire and release
+// must happen on the same connection - any other pooled connection would
+// release the wrong session's lock, or none at all).
+func acquirePostgresLock(db *sqlx.DB, policy MigrationLockPolicy) (func(), error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring migration lock: %w", err)
+	}
+
+	key := advisoryLockKey()
+	deadline := time.Now().Add(policy.Timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		if acquired {
+			return func() {
+				if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key); err != nil {
+					log.Printf("db: releasing migration lock: %v", err)
+				}
+				conn.Close()
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			conn.Close()
+			return nil, ErrMigrationLocked
+		}
+		time.Sleep(policy.RetryInterval)
+	}
+}
+
+// advisoryLockKey derives pg_advisory_lock's bigint argument from
+// migrationLockKey, so every instance of this deployment computes the
+// same key without either hand-picking an arbitrary constant or risking a
+// collision with some other part of the codebase's own advisory locks.
+func advisoryLockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte(migrationLockKey))
+	return int64(h.Sum64())
+}
+
+// acquireSqliteLock guards migrations with a migration_lock table instead
+// of a session-held BEGIN EXCLUSIVE: sqlx.DB is a connection pool, so
+// nothing pins a BEGIN EXCLUSIVE's session across the whole migration run
+// the way a single INSERT into a uniquely-keyed table can. The first
+// INSERT wins; everyone else retries until it succeeds or times out. If a
+// holder crashes mid-migration, its row is never cleaned up - an operator
+// has to notice and delete it manually, same caveat any advisory lock
+// has against its holder dying.
+func acquireSqliteLock(db *sqlx.DB, policy MigrationLockPolicy) (func(), error) {
+	if err := createMigrationLockTable(db); err != nil {
+		return nil, fmt.Errorf("acquiring migration lock: %w", err)
+	}
+
+	deadline := time.Now().Add(policy.Timeout)
+	for {
+		res, err := db.Exec("INSERT OR IGNORE INTO migration_lock (id, acquired_at) VALUES (1, ?)", time.Now().UTC().Format(time.RFC3339))
+		if err != nil {
+			return nil, fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			return func() {
+				if _, err := db.Exec("DELETE FROM migration_lock WHERE id = 1"); err != nil {
+					log.Printf("db: releasing migration lock: %v", err)
+				}
+			}, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrMigrationLocked
+		}
+		time.Sleep(policy.RetryInterval)
+	}
+}
+
+func createMigrationLockTable(db *sqlx.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS migration_lock (
+			id INTEGER PRIMARY KEY,
+			acquired_at TEXT NOT NULL
+		)
+	`)
+	return err
+}
@@ -2,10 +2,12 @@ package db
 
 import (
 	"crypto/sha256"
+	"database/sql"
 	"embed"
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -16,31 +18,73 @@ import (
 
 // MigrationStatus represents the state of a single migration.
 type MigrationStatus struct {
-	ID          string
-	Checksum    string
-	Applied     bool
-	AppliedAt   *time.Time
-	ExecutionMs int64
+	ID            string
+	Checksum      string
+	Applied       bool
+	AppliedAt     *time.Time
+	ExecutionMs   int64
+	Transactional bool // false if the migration's Up half carries a "notransaction" directive
 }
 
-// MigrateUp runs all pending migrations against the database.
-// Detects driver type, selects appropriate embedded migrations,
-// validates checksums, and applies pending migrations in order.
-func MigrateUp(db *sqlx.DB) error {
-	driver := db.DriverName()
-
-	var migrationsFS embed.FS
-	var migrationsDir string
-
+// embeddedMigrationsFor selects the embedded migration filesystem and
+// directory for db's driver. Shared by every entry point below so adding a
+// driver means touching one switch instead of one per entry point.
+func embeddedMigrationsFor(driver string) (embed.FS, string, error) {
 	switch driver {
 	case "sqlite3":
-		migrationsFS = embeddedmigrations.SqliteMigrations
-		migrationsDir = "sqlite"
+		return embeddedmigrations.SqliteMigrations, "sqlite", nil
 	case "postgres":
-		migrationsFS = embeddedmigrations.PostgresMigrations
-		migrationsDir = "postgres"
+		return embeddedmigrations.PostgresMigrations, "postgres", nil
+	default:
+		return embed.FS{}, "", fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// MigrateUp runs all pending migrations against the database, coordinating
+// with any other instance doing the same via DefaultMigrationLockPolicy
+// (see MigrateUpWithLock). Detects driver type, selects appropriate
+// embedded migrations, validates checksums, and applies pending
+// migrations in order.
+func MigrateUp(db *sqlx.DB) error {
+	return MigrateUpWithLock(db, DefaultMigrationLockPolicy())
+}
+
+// MigrateUpWithLock is MigrateUp with an explicit MigrationLockPolicy.
+// Before touching any migration it acquires a database-wide lock (see
+// acquireMigrationLock) so that several instances starting at once - e.g.
+// a Kubernetes rolling deploy - take turns instead of racing each other's
+// DDL. It polls at policy.RetryInterval until it acquires the lock or
+// policy.Timeout elapses, in which case it returns ErrMigrationLocked.
+func MigrateUpWithLock(db *sqlx.DB, policy MigrationLockPolicy) error {
+	unlock, err := acquireMigrationLock(db, policy)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return migrateUpN(db, -1)
+}
+
+// MigrateStep applies up to n pending migrations (n > 0) or rolls back up
+// to -n applied migrations (n < 0). Step(0) is a no-op.
+func MigrateStep(db *sqlx.DB, n int) error {
+	switch {
+	case n > 0:
+		return migrateUpN(db, n)
+	case n < 0:
+		return MigrateDown(db, -n)
 	default:
-		return fmt.Errorf("unsupported database driver: %s", driver)
+		return nil
+	}
+}
+
+// migrateUpN applies pending migrations in order, stopping after limit
+// applications, or all of them when limit is negative. MigrateUp and
+// MigrateStep(n>0) are both thin wrappers around this.
+func migrateUpN(db *sqlx.DB, limit int) error {
+	migrationsFS, migrationsDir, err := embeddedMigrationsFor(db.DriverName())
+	if err != nil {
+		return err
 	}
 
 	// Create migrations tracking table if not exists
@@ -66,58 +110,345 @@ func MigrateUp(db *sqlx.DB) error {
 		return fmt.Errorf("failed to query applied migrations: %w", err)
 	}
 
-	// Apply pending migrations in order
+	// Apply pending migrations in order, up to limit of them (unlimited if
+	// limit < 0).
+	applyCount := 0
 	for _, m := range migrations {
 		if applied[m.ID] {
 			continue
 		}
+		if limit >= 0 && applyCount >= limit {
+			break
+		}
+
+		if err := applyOne(db, m); err != nil {
+			return err
+		}
+		applyCount++
+	}
+
+	return nil
+}
 
-		start := time.Now()
+// applyOne applies a single migration's up half and records it. Shared by
+// migrateUpN's forward loop and MigrateRedo, which reapplies exactly one
+// migration without touching the rest of the pending set.
+//
+// Normally the DDL and the migrations-table insert run in one transaction,
+// so a failure partway through leaves no partial state. A migration whose
+// .up.sql starts with "-- +migrate Up notransaction" runs outside any
+// transaction instead - required for statements like
+// CREATE INDEX CONCURRENTLY or ALTER TYPE ... ADD VALUE, which Postgres
+// refuses to run inside one - and only the bookkeeping insert is
+// transactional.
+func applyOne(db *sqlx.DB, m migration) error {
+	start := time.Now()
+
+	if m.UpNoTransaction {
+		if err := execStatements(db, m.SQL); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.ID, err)
+		}
+		duration := time.Since(start)
 
-		// Wrap migration execution and recording in transaction for atomicity
-		// If migration succeeds but recording fails, rollback prevents partial state
 		tx, err := db.Beginx()
 		if err != nil {
-			return fmt.Errorf("failed to begin transaction for migration %s: %w", m.ID, err)
+			return fmt.Errorf("failed to begin transaction recording migration %s: %w", m.ID, err)
 		}
-
-		if err := applyMigration(tx, m); err != nil {
+		if err := recordMigration(tx, m.ID, m.Checksum, m.DownChecksum, duration); err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to apply migration %s: %w", m.ID, err)
+			return fmt.Errorf("failed to record migration %s: %w", m.ID, err)
 		}
+		return tx.Commit()
+	}
 
-		duration := time.Since(start)
+	// Wrap migration execution and recording in transaction for atomicity
+	// If migration succeeds but recording fails, rollback prevents partial state
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %s: %w", m.ID, err)
+	}
+
+	if err := execStatements(tx, m.SQL); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to apply migration %s: %w", m.ID, err)
+	}
+
+	duration := time.Since(start)
+
+	// Record migration metadata for audit trail
+	if err := recordMigration(tx, m.ID, m.Checksum, m.DownChecksum, duration); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %s: %w", m.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", m.ID, err)
+	}
+
+	return nil
+}
+
+// MigrateDown rolls back up to steps of the most recently applied
+// migrations, most recent first. A migration without a paired .down.sql
+// can't be rolled back; MigrateDown stops there rather than silently
+// skipping it, so an operator sees exactly how far back it got.
+func MigrateDown(db *sqlx.DB, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive, got %d", steps)
+	}
+
+	migrationsFS, migrationsDir, err := embeddedMigrationsFor(db.DriverName())
+	if err != nil {
+		return err
+	}
 
-		// Record migration metadata for audit trail
-		if err := recordMigration(tx, m.ID, m.Checksum, duration); err != nil {
+	if err := createMigrationsTable(db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := parseMigrationFiles(migrationsFS, migrationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to parse migrations: %w", err)
+	}
+	byID := make(map[string]migration, len(migrations))
+	for _, m := range migrations {
+		byID[m.ID] = m
+	}
+
+	applied, err := appliedMigrationIDsDesc(db)
+	if err != nil {
+		return fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	if steps > len(applied) {
+		steps = len(applied)
+	}
+
+	for _, id := range applied[:steps] {
+		m, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("applied migration %s not found in embedded files", id)
+		}
+		if err := rollbackOne(db, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollbackOne rolls back a single applied migration's down half and
+// unrecords it. Shared by MigrateDown's reverse loop, MigrateTo, and
+// MigrateRedo. Like applyOne, a "-- +migrate Down notransaction" directive
+// on the .down.sql runs the DDL outside any transaction, with only the
+// unrecording delete transactional.
+func rollbackOne(db *sqlx.DB, m migration) error {
+	if m.DownSQL == "" {
+		return fmt.Errorf("migration %s has no paired .down.sql, cannot roll back", m.ID)
+	}
+
+	if m.DownNoTransaction {
+		if err := execStatements(db, m.DownSQL); err != nil {
+			return fmt.Errorf("failed to roll back migration %s: %w", m.ID, err)
+		}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction unrecording migration %s: %w", m.ID, err)
+		}
+		if _, err := tx.Exec(tx.Rebind("DELETE FROM migrations WHERE migration_id = ?"), m.ID); err != nil {
 			tx.Rollback()
-			return fmt.Errorf("failed to record migration %s: %w", m.ID, err)
+			return fmt.Errorf("failed to unrecord migration %s: %w", m.ID, err)
+		}
+		return tx.Commit()
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %s: %w", m.ID, err)
+	}
+
+	if err := execStatements(tx, m.DownSQL); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to roll back migration %s: %w", m.ID, err)
+	}
+
+	if _, err := tx.Exec(tx.Rebind("DELETE FROM migrations WHERE migration_id = ?"), m.ID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to unrecord migration %s: %w", m.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit rollback of migration %s: %w", m.ID, err)
+	}
+
+	return nil
+}
+
+// MigrateTo brings the database to exactly target as its most recently
+// applied migration: rolling back anything applied after it (most recent
+// first), then applying anything up to and including it that is still
+// pending. Lets an operator (or the "migrate to <version>" CLI subcommand)
+// move in either direction without first figuring out which of Up/Down
+// they need.
+func MigrateTo(db *sqlx.DB, target string) error {
+	migrationsFS, migrationsDir, err := embeddedMigrationsFor(db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	if err := createMigrationsTable(db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	migrations, err := parseMigrationFiles(migrationsFS, migrationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to parse migrations: %w", err)
+	}
+	byID := make(map[string]migration, len(migrations))
+	targetIdx := -1
+	for i, m := range migrations {
+		byID[m.ID] = m
+		if m.ID == target {
+			targetIdx = i
+		}
+	}
+	if targetIdx == -1 {
+		return fmt.Errorf("migration %s not found in embedded files", target)
+	}
+
+	applied, err := getAppliedMigrations(db)
+	if err != nil {
+		return fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+
+	appliedDesc, err := appliedMigrationIDsDesc(db)
+	if err != nil {
+		return fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	migrationIdx := make(map[string]int, len(migrations))
+	for i, m := range migrations {
+		migrationIdx[m.ID] = i
+	}
+	for _, id := range appliedDesc {
+		if migrationIdx[id] <= targetIdx {
+			continue
+		}
+		m, ok := byID[id]
+		if !ok {
+			return fmt.Errorf("applied migration %s not found in embedded files", id)
+		}
+		if err := rollbackOne(db, m); err != nil {
+			return err
 		}
+	}
 
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", m.ID, err)
+	for i := 0; i <= targetIdx; i++ {
+		if applied[migrations[i].ID] {
+			continue
+		}
+		if err := applyOne(db, migrations[i]); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// MigrateStatus returns the status of all migrations (applied and pending).
-func MigrateStatus(db *sqlx.DB) ([]MigrationStatus, error) {
-	driver := db.DriverName()
+// MigrateRedo rolls back and reapplies a single migration: id if given,
+// else whichever migration is currently most recently applied. Only the
+// latest applied migration may be redone - redoing an earlier one would
+// require also rolling back and reapplying everything after it, which is
+// what MigrateTo is for.
+func MigrateRedo(db *sqlx.DB, id string) error {
+	migrationsFS, migrationsDir, err := embeddedMigrationsFor(db.DriverName())
+	if err != nil {
+		return err
+	}
 
-	var migrationsFS embed.FS
-	var migrationsDir string
+	if err := createMigrationsTable(db); err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
 
-	switch driver {
-	case "sqlite3":
-		migrationsFS = embeddedmigrations.SqliteMigrations
-		migrationsDir = "sqlite"
-	case "postgres":
-		migrationsFS = embeddedmigrations.PostgresMigrations
-		migrationsDir = "postgres"
-	default:
-		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	migrations, err := parseMigrationFiles(migrationsFS, migrationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to parse migrations: %w", err)
+	}
+	byID := make(map[string]migration, len(migrations))
+	for _, m := range migrations {
+		byID[m.ID] = m
+	}
+
+	appliedDesc, err := appliedMigrationIDsDesc(db)
+	if err != nil {
+		return fmt.Errorf("failed to query applied migrations: %w", err)
+	}
+	if len(appliedDesc) == 0 {
+		return fmt.Errorf("no applied migrations to redo")
+	}
+	latest := appliedDesc[0]
+	if id == "" {
+		id = latest
+	} else if id != latest {
+		return fmt.Errorf("can only redo the most recently applied migration (%s), not %s", latest, id)
+	}
+
+	m, ok := byID[id]
+	if !ok {
+		return fmt.Errorf("applied migration %s not found in embedded files", id)
+	}
+
+	if err := rollbackOne(db, m); err != nil {
+		return err
+	}
+	return applyOne(db, m)
+}
+
+// Migrator binds Up/Down/Step/Status to a single *sqlx.DB, matching the
+// method-based interface operators expect alongside the free functions
+// above (kept for existing callers). Its dialect comes from db.DriverName()
+// rather than a separate parameter, consistent with every entry point in
+// this file.
+type Migrator struct {
+	db *sqlx.DB
+}
+
+// NewMigrator returns a Migrator bound to db.
+func NewMigrator(db *sqlx.DB) *Migrator {
+	return &Migrator{db: db}
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error { return MigrateUp(m.db) }
+
+// UpWithLock applies all pending migrations using an explicit
+// MigrationLockPolicy instead of DefaultMigrationLockPolicy.
+func (m *Migrator) UpWithLock(policy MigrationLockPolicy) error {
+	return MigrateUpWithLock(m.db, policy)
+}
+
+// Down rolls back up to steps of the most recently applied migrations.
+func (m *Migrator) Down(steps int) error { return MigrateDown(m.db, steps) }
+
+// Step applies n pending migrations (n > 0) or rolls back -n applied
+// migrations (n < 0).
+func (m *Migrator) Step(n int) error { return MigrateStep(m.db, n) }
+
+// To brings the database to exactly target as its most recently applied
+// migration, migrating up or down as needed.
+func (m *Migrator) To(target string) error { return MigrateTo(m.db, target) }
+
+// Redo rolls back and reapplies id, or the most recently applied
+// migration if id is empty.
+func (m *Migrator) Redo(id string) error { return MigrateRedo(m.db, id) }
+
+// Status returns the status of every embedded migration.
+func (m *Migrator) Status() ([]MigrationStatus, error) { return MigrateStatus(m.db) }
+
+// MigrateStatus returns the status of all migrations (applied and pending).
+func MigrateStatus(db *sqlx.DB) ([]MigrationStatus, error) {
+	migrationsFS, migrationsDir, err := embeddedMigrationsFor(db.DriverName())
+	if err != nil {
+		return nil, err
 	}
 
 	// Create migrations tracking table if not exists
@@ -147,33 +478,46 @@ func MigrateStatus(db *sqlx.DB) ([]MigrationStatus, error) {
 		applied[status.ID] = status
 	}
 
-	// Build status list
+	// Build status list. Transactional always comes from the embedded
+	// migration file, not the applied row, since it's a property of the
+	// SQL itself rather than something recorded at apply time.
 	var statuses []MigrationStatus
 	for _, m := range migrations {
-		if s, ok := applied[m.ID]; ok {
-			statuses = append(statuses, s)
-		} else {
-			statuses = append(statuses, MigrationStatus{
-				ID:       m.ID,
-				Checksum: m.Checksum,
-				Applied:  false,
-			})
+		status, ok := applied[m.ID]
+		if !ok {
+			status = MigrationStatus{ID: m.ID, Checksum: m.Checksum}
 		}
+		status.Transactional = !m.UpNoTransaction
+		statuses = append(statuses, status)
 	}
 
 	return statuses, nil
 }
 
-// migration represents a parsed migration file
+// migration represents a parsed migration file. DownSQL (and DownChecksum)
+// are empty for a migration that predates paired .up.sql/.down.sql support
+// (a plain "NNN_name.sql" file) and can't be rolled back. UpNoTransaction/
+// DownNoTransaction reflect a "-- +migrate Up|Down notransaction" directive
+// found in the respective file (see parseTransactionDirective); SQL/
+// DownSQL have that directive line already stripped.
 type migration struct {
-	ID       string
-	Checksum string
-	SQL      string
+	ID                string
+	Checksum          string
+	SQL               string
+	UpNoTransaction   bool
+	DownSQL           string
+	DownChecksum      string
+	DownNoTransaction bool
 }
 
-// parseMigrationFiles extracts ordered list of migrations from embed.FS
+// parseMigrationFiles extracts an ordered list of migrations from embed.FS.
+// Three filename shapes are recognized per entry:
+//   - "<id>.up.sql" / "<id>.down.sql": a paired migration; <id> is its ID.
+//   - anything else ending in ".sql": a plain, up-only migration whose ID
+//     is the full filename - the convention every migration used before
+//     paired up/down support existed, kept working unchanged.
 func parseMigrationFiles(fsys embed.FS, dir string) ([]migration, error) {
-	var migrations []migration
+	builds := make(map[string]*migration)
 
 	err := fs.WalkDir(fsys, dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -187,32 +531,71 @@ func parseMigrationFiles(fsys embed.FS, dir string) ([]migration, error) {
 		if err != nil {
 			return fmt.Errorf("failed to read %s: %w", path, err)
 		}
-
-		// SHA256 checksum for tamper detection
-		hash := sha256.Sum256(content)
-		checksum := fmt.Sprintf("%x", hash)
-
-		migrations = append(migrations, migration{
-			ID:       filepath.Base(path),
-			Checksum: checksum,
-			SQL:      string(content),
-		})
+		name := filepath.Base(path)
+
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			id := strings.TrimSuffix(name, ".up.sql")
+			m := buildFor(builds, id)
+			body, noTx := parseTransactionDirective(string(content))
+			m.SQL = body
+			m.UpNoTransaction = noTx
+			m.Checksum = checksumOf(content)
+		case strings.HasSuffix(name, ".down.sql"):
+			id := strings.TrimSuffix(name, ".down.sql")
+			m := buildFor(builds, id)
+			body, noTx := parseTransactionDirective(string(content))
+			m.DownSQL = body
+			m.DownNoTransaction = noTx
+			m.DownChecksum = checksumOf(content)
+		default:
+			body, noTx := parseTransactionDirective(string(content))
+			builds[name] = &migration{ID: name, SQL: body, UpNoTransaction: noTx, Checksum: checksumOf(content)}
+		}
 
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort by filename for deterministic ordering
-	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].ID < migrations[j].ID
-	})
+	ids := make([]string, 0, len(builds))
+	for id, m := range builds {
+		if m.SQL == "" {
+			return nil, fmt.Errorf("migration %s has a down.sql but no matching up.sql", id)
+		}
+		ids = append(ids, id)
+	}
+	// Sort by ID for deterministic ordering
+	sort.Strings(ids)
 
+	migrations := make([]migration, 0, len(ids))
+	for _, id := range ids {
+		migrations = append(migrations, *builds[id])
+	}
 	return migrations, nil
 }
 
+// buildFor returns the in-progress migration for id, creating it on first
+// reference regardless of whether the .up.sql or .down.sql half is seen
+// first (fs.WalkDir visits both in the same directory, ".down.sql" <
+// ".up.sql" lexically, so the down half is seen first).
+func buildFor(builds map[string]*migration, id string) *migration {
+	m, ok := builds[id]
+	if !ok {
+		m = &migration{ID: id}
+		builds[id] = m
+	}
+	return m
+}
+
+// checksumOf returns the hex SHA256 checksum of content, used for
+// tamper detection against already-applied migrations.
+func checksumOf(content []byte) string {
+	hash := sha256.Sum256(content)
+	return fmt.Sprintf("%x", hash)
+}
+
 // createMigrationsTable ensures migrations tracking table exists
 // IMPORTANT: Schema must match migrations table definition in 001_initial_schema.sql
 // If migration schema changes, update both locations
@@ -224,6 +607,7 @@ func createMigrationsTable(db *sqlx.DB) error {
 			CREATE TABLE IF NOT EXISTS migrations (
 				migration_id TEXT PRIMARY KEY,
 				checksum TEXT NOT NULL,
+				down_checksum TEXT NOT NULL DEFAULT '',
 				applied_at TEXT NOT NULL,
 				execution_ms INTEGER NOT NULL,
 				CHECK (applied_at LIKE '____-__-__T__:__:__Z')
@@ -234,6 +618,7 @@ func createMigrationsTable(db *sqlx.DB) error {
 			CREATE TABLE IF NOT EXISTS migrations (
 				migration_id TEXT PRIMARY KEY,
 				checksum TEXT NOT NULL,
+				down_checksum TEXT NOT NULL DEFAULT '',
 				applied_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
 				execution_ms INTEGER NOT NULL
 			)
@@ -264,70 +649,307 @@ func getAppliedMigrations(db *sqlx.DB) (map[string]bool, error) {
 	return applied, nil
 }
 
-// validateChecksums verifies all applied migrations match embedded checksums
+// appliedMigrationIDsDesc returns applied migration IDs ordered most
+// recently applied first, the order MigrateDown rolls them back in.
+func appliedMigrationIDsDesc(db *sqlx.DB) ([]string, error) {
+	rows, err := db.Queryx("SELECT migration_id FROM migrations ORDER BY applied_at DESC, migration_id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// validateChecksums verifies all applied migrations' up and (where
+// recorded) down SQL still match the embedded files' checksums, so a
+// tampered .down.sql is caught at the same point an up-side tamper always
+// was, not silently accepted the next time it's rolled back.
 func validateChecksums(db *sqlx.DB, migrations []migration) error {
-	rows, err := db.Queryx("SELECT migration_id, checksum FROM migrations")
+	rows, err := db.Queryx("SELECT migration_id, checksum, down_checksum FROM migrations")
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	checksumMap := make(map[string]string)
+	byID := make(map[string]migration, len(migrations))
 	for _, m := range migrations {
-		checksumMap[m.ID] = m.Checksum
+		byID[m.ID] = m
 	}
 
 	for rows.Next() {
-		var id, dbChecksum string
-		if err := rows.Scan(&id, &dbChecksum); err != nil {
+		var id, dbChecksum, dbDownChecksum string
+		if err := rows.Scan(&id, &dbChecksum, &dbDownChecksum); err != nil {
 			return err
 		}
 
-		expectedChecksum, ok := checksumMap[id]
+		expected, ok := byID[id]
 		if !ok {
 			return fmt.Errorf("migration %s exists in database but not in embedded files", id)
 		}
-		if dbChecksum != expectedChecksum {
-			return fmt.Errorf("checksum mismatch for migration %s: expected %s, got %s", id, expectedChecksum, dbChecksum)
+		if dbChecksum != expected.Checksum {
+			return fmt.Errorf("checksum mismatch for migration %s: expected %s, got %s", id, expected.Checksum, dbChecksum)
+		}
+		// dbDownChecksum is "" for a row recorded before this column
+		// existed, or for a migration with no .down.sql - neither is
+		// tampering.
+		if dbDownChecksum != "" && dbDownChecksum != expected.DownChecksum {
+			return fmt.Errorf("down-migration checksum mismatch for migration %s: expected %s, got %s", id, expected.DownChecksum, dbDownChecksum)
 		}
 	}
 
 	return nil
 }
 
-// applyMigration executes a single migration SQL within a transaction
-func applyMigration(tx *sqlx.Tx, m migration) error {
-	// Split on semicolons for PostgreSQL compatibility
-	// lib/pq doesn't support multiple statements in single Exec
-	statements := strings.Split(m.SQL, ";")
-	for _, stmt := range statements {
-		stmt = strings.TrimSpace(stmt)
-		if stmt == "" || strings.HasPrefix(stmt, "--") {
+// sqlExecutor is the subset of *sqlx.Tx and *sqlx.DB that execStatements
+// needs, so the same statement-by-statement loop runs whether a migration
+// is being applied inside a transaction or (for a "notransaction"
+// migration) directly against the database.
+type sqlExecutor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// execStatements executes sql (a migration's up or down half) one
+// statement at a time - required for PostgreSQL, where lib/pq doesn't
+// support multiple statements in a single Exec.
+func execStatements(ex sqlExecutor, sql string) error {
+	for _, stmt := range splitStatements(sql) {
+		if isBlankStatement(stmt) {
 			continue
 		}
-		if _, err := tx.Exec(stmt); err != nil {
+		if _, err := ex.Exec(stmt); err != nil {
 			return fmt.Errorf("statement failed: %w", err)
 		}
 	}
 	return nil
 }
 
+// transactionDirectiveRe matches a "-- +migrate Up" / "-- +migrate Down"
+// header line, optionally followed by "notransaction", on its own line -
+// the same directive rubenv/sql-migrate uses, adapted to this package's
+// paired .up.sql/.down.sql files (where a single file only ever needs one
+// of the two, matching its own direction).
+var transactionDirectiveRe = regexp.MustCompile(`(?m)^--\s*\+migrate\s+(?:Up|Down)(\s+notransaction)?\s*$\n?`)
+
+// parseTransactionDirective strips the first "-- +migrate Up|Down
+// [notransaction]" directive line from sqlText, if present, and reports
+// whether "notransaction" was given. body is sqlText with that line
+// removed; the rest of the file executes exactly as before.
+func parseTransactionDirective(sqlText string) (body string, noTransaction bool) {
+	loc := transactionDirectiveRe.FindStringSubmatchIndex(sqlText)
+	if loc == nil {
+		return sqlText, false
+	}
+	noTransaction = loc[2] != -1
+	return sqlText[:loc[0]] + sqlText[loc[1]:], noTransaction
+}
+
+// statementBeginRe / statementEndRe bookend a block (typically a Postgres
+// function body or DO block) that splitStatements must treat as one
+// statement even where it contains semicolons outside of any string or
+// dollar-quote - mirrors rubenv/sql-migrate's StatementBegin/StatementEnd.
+var (
+	statementBeginRe = regexp.MustCompile(`^--\s*\+migrate\s+StatementBegin\s*$`)
+	statementEndRe   = regexp.MustCompile(`^--\s*\+migrate\s+StatementEnd\s*$`)
+)
+
+// splitStatements splits a migration's SQL into individual statements,
+// tracking single- and double-quoted strings, $tag$-delimited dollar-quoted
+// strings, "--" line comments, and "/* */" block comments, so a semicolon
+// inside any of those doesn't split a statement. This replaces the naive
+// strings.Split(sql, ";") it's named after, which broke on Postgres
+// function bodies, DO blocks, and any string literal containing a
+// semicolon. A "-- +migrate StatementBegin"/"StatementEnd" pair forces
+// everything between them into one statement regardless of semicolons.
+func splitStatements(sqlText string) []string {
+	var statements []string
+	var cur strings.Builder
+
+	flush := func() {
+		stmt := strings.TrimSpace(cur.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		cur.Reset()
+	}
+
+	inSingle, inDouble, inBlockComment, inLineComment, inStatementBlock := false, false, false, false, false
+	dollarTag := "" // non-empty while inside a $tag$ ... $tag$ span
+
+	i, n := 0, len(sqlText)
+	for i < n {
+		c := sqlText[i]
+
+		switch {
+		case inLineComment:
+			cur.WriteByte(c)
+			i++
+			if c == '\n' {
+				inLineComment = false
+			}
+			continue
+
+		case inBlockComment:
+			if c == '*' && i+1 < n && sqlText[i+1] == '/' {
+				cur.WriteString("*/")
+				i += 2
+				inBlockComment = false
+				continue
+			}
+			cur.WriteByte(c)
+			i++
+			continue
+
+		case inSingle:
+			cur.WriteByte(c)
+			i++
+			if c == '\'' {
+				if i < n && sqlText[i] == '\'' { // doubled '' escapes a quote
+					cur.WriteByte('\'')
+					i++
+					continue
+				}
+				inSingle = false
+			}
+			continue
+
+		case inDouble:
+			cur.WriteByte(c)
+			i++
+			if c == '"' {
+				inDouble = false
+			}
+			continue
+
+		case dollarTag != "":
+			if strings.HasPrefix(sqlText[i:], dollarTag) {
+				cur.WriteString(dollarTag)
+				i += len(dollarTag)
+				dollarTag = ""
+				continue
+			}
+			cur.WriteByte(c)
+			i++
+			continue
+		}
+
+		// Not inside any quoted/commented span.
+		switch {
+		case c == '-' && i+1 < n && sqlText[i+1] == '-':
+			line, lineLen := takeLine(sqlText[i:])
+			switch {
+			case statementBeginRe.MatchString(strings.TrimSpace(line)):
+				inStatementBlock = true
+			case statementEndRe.MatchString(strings.TrimSpace(line)):
+				inStatementBlock = false
+			}
+			// takeLine already consumed the whole comment line, newline
+			// included, so the next line is ordinary SQL again.
+			cur.WriteString(line)
+			i += lineLen
+
+		case c == '/' && i+1 < n && sqlText[i+1] == '*':
+			inBlockComment = true
+			cur.WriteString("/*")
+			i += 2
+
+		case c == '\'':
+			inSingle = true
+			cur.WriteByte(c)
+			i++
+
+		case c == '"':
+			inDouble = true
+			cur.WriteByte(c)
+			i++
+
+		case c == '$':
+			if tag, ok := matchDollarTag(sqlText[i:]); ok {
+				dollarTag = tag
+				cur.WriteString(tag)
+				i += len(tag)
+				continue
+			}
+			cur.WriteByte(c)
+			i++
+
+		case c == ';' && !inStatementBlock:
+			flush()
+			i++
+
+		default:
+			cur.WriteByte(c)
+			i++
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// takeLine returns s up to and including its first newline (or all of s,
+// if it has none) and that slice's length.
+func takeLine(s string) (string, int) {
+	if idx := strings.IndexByte(s, '\n'); idx != -1 {
+		return s[:idx+1], idx + 1
+	}
+	return s, len(s)
+}
+
+// matchDollarTag recognizes a Postgres dollar-quote opening tag like $$ or
+// $tag$ at the start of s, returning the full tag (both dollar signs
+// included) and true if one is present.
+func matchDollarTag(s string) (string, bool) {
+	if len(s) == 0 || s[0] != '$' {
+		return "", false
+	}
+	end := strings.IndexByte(s[1:], '$')
+	if end == -1 {
+		return "", false
+	}
+	tag := s[1 : 1+end]
+	for _, c := range tag {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')) {
+			return "", false
+		}
+	}
+	return s[:1+end+1], true
+}
+
+// blankStatementRe strips "--" line comments and "/* */" block comments so
+// isBlankStatement can tell a statement that's comment-only (skip it) from
+// one that has real SQL after its leading comments (run it).
+var blankStatementRe = regexp.MustCompile(`(?s)--[^\n]*|/\*.*?\*/`)
+
+func isBlankStatement(stmt string) bool {
+	return strings.TrimSpace(blankStatementRe.ReplaceAllString(stmt, "")) == ""
+}
+
 // recordMigration stores migration metadata for audit trail within a transaction
-func recordMigration(tx *sqlx.Tx, id, checksum string, duration time.Duration) error {
+func recordMigration(tx *sqlx.Tx, id, checksum, downChecksum string, duration time.Duration) error {
 	now := time.Now().UTC()
 	executionMs := duration.Milliseconds()
 
 	if tx.DriverName() == "sqlite3" {
 		_, err := tx.Exec(
-			"INSERT INTO migrations (migration_id, checksum, applied_at, execution_ms) VALUES (?, ?, ?, ?)",
-			id, checksum, now.Format(time.RFC3339), executionMs,
+			"INSERT INTO migrations (migration_id, checksum, down_checksum, applied_at, execution_ms) VALUES (?, ?, ?, ?, ?)",
+			id, checksum, downChecksum, now.Format(time.RFC3339), executionMs,
 		)
 		return err
 	}
 
 	_, err := tx.Exec(
-		"INSERT INTO migrations (migration_id, checksum, applied_at, execution_ms) VALUES ($1, $2, $3, $4)",
-		id, checksum, now, executionMs,
+		"INSERT INTO migrations (migration_id, checksum, down_checksum, applied_at, execution_ms) VALUES ($1, $2, $3, $4, $5)",
+		id, checksum, downChecksum, now, executionMs,
 	)
 	return err
 }
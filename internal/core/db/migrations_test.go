@@ -0,0 +1,84 @@
+// internal/core/db/migrations_test.go
+package db
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// openTestDB opens a fresh sqlite database backed by a file in t.TempDir(),
+// so each test gets its own isolated database without needing cleanup.
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tk.db")
+	db, err := Open("sqlite://" + path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestMigrateUp_AppliesEmbeddedMigrations exercises the embedded
+// migrations/sqlite/0001_init.up.sql end to end: MigrateUp must apply it,
+// MigrateStatus must report it applied, and the tables it creates must
+// actually match what internal/core/api's queries assume exist.
+func TestMigrateUp_AppliesEmbeddedMigrations(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+
+	// A second MigrateUp call should be a no-op: already applied, nothing
+	// pending.
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("second MigrateUp() error = %v", err)
+	}
+
+	if _, err := db.Exec(
+		"INSERT INTO rules (rule_id, tenant_id, name, state, action, expression, sample_rate, scope_tags, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"rule-1", "tenant-1", "r", "active", "observe", "[]", 1.0, "[]", "2026-01-01T00:00:00Z",
+	); err != nil {
+		t.Fatalf("insert into rules failed, schema mismatch: %v", err)
+	}
+
+	if _, err := db.Exec(
+		"INSERT INTO events (event_id, tenant_id, client_timestamp, server_received_at, file_path, file_offset, payload_hash, matched_rule_count, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"event-1", "tenant-1", "2026-01-01T00:00:00Z", "2026-01-01T00:00:00Z", "events/2026-01-01.jsonl", 0, "deadbeef", 0, "2026-01-01T00:00:00Z",
+	); err != nil {
+		t.Fatalf("insert into events failed, schema mismatch: %v", err)
+	}
+
+	// The unique index backing ReportEvents' dedup (see
+	// internal/core/api/report_events.go) must actually reject a repeat
+	// payload_hash within the same tenant.
+	if _, err := db.Exec(
+		"INSERT INTO events (event_id, tenant_id, client_timestamp, server_received_at, file_path, file_offset, payload_hash, matched_rule_count, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"event-2", "tenant-1", "2026-01-01T00:00:00Z", "2026-01-01T00:00:00Z", "events/2026-01-01.jsonl", 1, "deadbeef", 0, "2026-01-01T00:00:00Z",
+	); err == nil {
+		t.Error("expected duplicate (tenant_id, payload_hash) insert to fail the unique index")
+	}
+}
+
+// TestMigrateDown_RollsBack verifies 0001_init's down half actually
+// reverses its up half: after MigrateDown, the tables it created are gone.
+func TestMigrateDown_RollsBack(t *testing.T) {
+	db := openTestDB(t)
+
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if err := MigrateDown(db, 1); err != nil {
+		t.Fatalf("MigrateDown() error = %v", err)
+	}
+
+	if _, err := db.Exec(
+		"INSERT INTO rules (rule_id, tenant_id, name, state, action, expression, sample_rate, scope_tags, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		"rule-1", "tenant-1", "r", "active", "observe", "[]", 1.0, "[]", "2026-01-01T00:00:00Z",
+	); err == nil {
+		t.Error("expected rules table to no longer exist after MigrateDown")
+	}
+}
@@ -82,3 +82,70 @@ func (q *Queries) Select(name string, dest interface{}, args ...interface{}) err
 	}
 	return q.db.Select(dest, q.db.Rebind(query), args...)
 }
+
+// ExecNamed executes a named query whose .sql text uses :field-style
+// bindings (e.g. :tenant_id, :rule_id) instead of positional ?, resolving
+// each binding from arg (a struct or map[string]any) via sqlx.Named before
+// rebinding for PostgreSQL. Prefer this over Exec for multi-column inserts
+// and partial updates, where positional placeholders get hard to keep
+// aligned with the column list.
+func (q *Queries) ExecNamed(name string, arg interface{}) (sql.Result, error) {
+	query, args, err := q.named(name, arg)
+	if err != nil {
+		return nil, err
+	}
+	return q.db.Exec(q.db.Rebind(query), args...)
+}
+
+// GetNamed retrieves a single row into dest struct using a named query with
+// :field-style bindings. See ExecNamed.
+func (q *Queries) GetNamed(name string, dest interface{}, arg interface{}) error {
+	query, args, err := q.named(name, arg)
+	if err != nil {
+		return err
+	}
+	return q.db.Get(dest, q.db.Rebind(query), args...)
+}
+
+// SelectNamed retrieves multiple rows into dest slice using a named query
+// with :field-style bindings. See ExecNamed.
+func (q *Queries) SelectNamed(name string, dest interface{}, arg interface{}) error {
+	query, args, err := q.named(name, arg)
+	if err != nil {
+		return err
+	}
+	return q.db.Select(dest, q.db.Rebind(query), args...)
+}
+
+// named resolves a dotsql query's :field-style bindings against arg via
+// sqlx.Named, producing a ?-placeholder query and its positional args in
+// the same style Exec/Get/Select already expect.
+func (q *Queries) named(name string, arg interface{}) (string, []interface{}, error) {
+	query, err := q.dot.Raw(name)
+	if err != nil {
+		return "", nil, fmt.Errorf("query not found: %s", name)
+	}
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return "", nil, fmt.Errorf("named query %s: %w", name, err)
+	}
+	return query, args, nil
+}
+
+// In expands a named query's IN (:field) clause against arg (a struct or
+// map[string]any whose slice-valued field becomes the IN list) via
+// sqlx.Named followed by sqlx.In, so rule-loading queries that pull a batch
+// of IDs don't need hand-constructed placeholder strings. The returned
+// query/args are rebound for PostgreSQL and ready for db.Exec/Get/Select,
+// e.g. q.db.Select(dest, query, args...).
+func (q *Queries) In(name string, arg interface{}) (string, []interface{}, error) {
+	query, args, err := q.named(name, arg)
+	if err != nil {
+		return "", nil, err
+	}
+	query, args, err = sqlx.In(query, args...)
+	if err != nil {
+		return "", nil, fmt.Errorf("expanding IN clause for %s: %w", name, err)
+	}
+	return q.db.Rebind(query), args, nil
+}
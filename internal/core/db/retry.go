@@ -0,0 +1,120 @@
+// internal/core/db/retry.go
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+)
+
+/*
+ * Retry policy for transient connection errors.
+ *
+ * Container startup races (PostgreSQL not yet accepting connections, SQLite
+ * momentarily locked by a migration) shouldn't fail a caller on the first
+ * attempt. Retry implements truncated exponential backoff with full jitter
+ * (Berglund et al.'s "Exponential Backoff And Jitter"):
+ *   delay_n = rand(0, min(Cap, Base*2^n))
+ * and only for errors IsRetryable recognizes as transient - an auth
+ * failure or malformed URL fails fast instead of burning MaxAttempts.
+ */
+
+// RetryPolicy configures Retry's backoff. The zero value is not usable;
+// construct with DefaultRetryPolicy or populate explicitly.
+type RetryPolicy struct {
+	Base        time.Duration
+	Cap         time.Duration
+	MaxAttempts int
+}
+
+// DefaultRetryPolicy is the policy Open uses: base=100ms, cap=10s,
+// max_attempts=10 - roughly 10-20s of total retry budget, enough to ride
+// out a PostgreSQL container's startup window.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		Base:        100 * time.Millisecond,
+		Cap:         10 * time.Second,
+		MaxAttempts: 10,
+	}
+}
+
+// backoffDelay returns the full-jitter delay before retrying attempt n
+// (0-indexed): a uniform random duration in [0, min(p.Cap, p.Base*2^n)].
+func (p RetryPolicy) backoffDelay(n int) time.Duration {
+	capped := p.Base << uint(n)
+	if capped <= 0 || capped > p.Cap {
+		capped = p.Cap
+	}
+	return time.Duration(rand.Int63n(int64(capped) + 1))
+}
+
+// Retry calls fn until it succeeds, policy.MaxAttempts is exhausted, fn
+// returns a non-retryable error (see IsRetryable), or ctx is done -
+// whichever comes first. logf, if non-nil, receives one line per failed
+// attempt before its backoff sleep; callers pass their process logger's
+// Printf (e.g. log.Printf) or nil to stay silent.
+func Retry(ctx context.Context, policy RetryPolicy, logf func(format string, args ...any), fn func() error) error {
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		if !IsRetryable(err) {
+			return err
+		}
+		if attempt == policy.MaxAttempts-1 {
+			break
+		}
+
+		delay := policy.backoffDelay(attempt)
+		if logf != nil {
+			logf("db: attempt %d/%d failed: %v (retrying in %s)", attempt+1, policy.MaxAttempts, err, delay)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("db: exhausted %d attempts: %w", policy.MaxAttempts, err)
+}
+
+// retryableSubstrings match driver error text IsRetryable treats as
+// transient: PostgreSQL's startup message and SQLite's busy/locked errors,
+// neither of which the database/sql/driver error types distinguish from
+// fatal errors on their own.
+var retryableSubstrings = []string{
+	"the database system is starting up",
+	"SQLITE_BUSY",
+	"database is locked",
+}
+
+// IsRetryable reports whether err looks transient (connection refused, a
+// database still starting up, a momentarily locked SQLite file) as opposed
+// to fatal (bad credentials, malformed URL, schema error) - the latter
+// never succeeds on retry, so Retry returns it immediately instead of
+// burning its attempt budget.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	msg := err.Error()
+	for _, substr := range retryableSubstrings {
+		if strings.Contains(msg, substr) {
+			return true
+		}
+	}
+	return false
+}
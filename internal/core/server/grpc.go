@@ -10,10 +10,12 @@ import (
 	"github.com/solatis/trapperkeeper/internal/core/api"
 	"github.com/solatis/trapperkeeper/internal/core/auth"
 	"github.com/solatis/trapperkeeper/internal/core/config"
+	"github.com/solatis/trapperkeeper/internal/observability"
 	pb "github.com/solatis/trapperkeeper/internal/protobuf/trapperkeeper/sensor/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
 // GRPCServer manages gRPC server lifecycle.
@@ -23,8 +25,11 @@ type GRPCServer struct {
 	config   *config.SensorAPIConfig
 }
 
-// NewGRPCServer creates gRPC server with auth interceptor and service registration.
-func NewGRPCServer(cfg *config.SensorAPIConfig, service *api.SensorAPIService, authenticator *auth.Authenticator) (*GRPCServer, error) {
+// NewGRPCServer creates gRPC server with auth interceptor and service
+// registration. authenticator may be an *auth.Authenticator (HMAC API
+// keys), *auth.JWTAuthenticator (bearer JWTs), or *auth.ChainAuthenticator
+// (both) - any auth.Interceptor.
+func NewGRPCServer(cfg *config.SensorAPIConfig, service *api.SensorAPIService, authenticator auth.Interceptor) (*GRPCServer, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("cfg cannot be nil")
 	}
@@ -37,6 +42,7 @@ func NewGRPCServer(cfg *config.SensorAPIConfig, service *api.SensorAPIService, a
 
 	opts := []grpc.ServerOption{
 		grpc.ChainUnaryInterceptor(
+			tracingUnaryInterceptor,
 			authenticator.UnaryInterceptor(),
 		),
 	}
@@ -54,6 +60,18 @@ func NewGRPCServer(cfg *config.SensorAPIConfig, service *api.SensorAPIService, a
 	}, nil
 }
 
+// tracingUnaryInterceptor extracts the caller's trace context (propagated
+// via traceparent/tracestate gRPC metadata) into ctx before the auth
+// interceptor and handler run, so spans started downstream - e.g.
+// SyncRules' - attach to the caller's trace instead of starting a new one.
+// A no-op until InitTracer installs a real tracer provider.
+func tracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = observability.ExtractTraceContext(ctx, md)
+	}
+	return handler(ctx, req)
+}
+
 // Start binds listener and serves gRPC requests.
 // Context is provided for API consistency but Serve blocks until Shutdown is called.
 func (s *GRPCServer) Start(ctx context.Context) error {
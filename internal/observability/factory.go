@@ -0,0 +1,33 @@
+package observability
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/solatis/trapperkeeper/internal/core/config"
+)
+
+// NewSinkFromConfig builds the AuditSink cfg.AuditSink selects. db is used
+// only by "sqlite" (may be nil otherwise); a "grpc" sink additionally
+// needs a CollectorClient, which callers construct themselves and pass via
+// NewGRPCPushSink directly - there's no generated collector client in this
+// tree to dial from a bare address (see CollectorClient's doc comment), so
+// cfg.AuditSink == "grpc" here returns an error explaining the gap rather
+// than silently downgrading to NoopSink.
+func NewSinkFromConfig(cfg config.ObservabilityConfig, db *sqlx.DB) (AuditSink, error) {
+	switch cfg.AuditSink {
+	case "", "none":
+		return NoopSink{}, nil
+	case "stdout":
+		return NewStdoutSink(), nil
+	case "sqlite":
+		if db == nil {
+			return nil, fmt.Errorf("observability: audit_sink=sqlite requires a database connection")
+		}
+		return NewSQLiteSink(db), nil
+	case "grpc":
+		return nil, fmt.Errorf("observability: audit_sink=grpc requires a CollectorClient; construct NewGRPCPushSink directly once a collector client is available")
+	default:
+		return nil, fmt.Errorf("observability: unknown audit_sink %q", cfg.AuditSink)
+	}
+}
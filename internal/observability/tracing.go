@@ -0,0 +1,94 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// tracerName identifies this package's spans in a trace backend.
+const tracerName = "github.com/solatis/trapperkeeper/internal/observability"
+
+// InitTracer configures the global OpenTelemetry tracer provider to export
+// spans via OTLP/gRPC to otlpEndpoint (e.g. "localhost:4317", an
+// otel-collector sidecar). Returns a shutdown func callers defer at
+// process exit to flush any buffered spans.
+func InitTracer(ctx context.Context, serviceName, otlpEndpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(otlpEndpoint), otlptracegrpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+
+	resource, err := sdkresource.New(ctx, sdkresource.WithAttributes(
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("building resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns this package's tracer from the global provider InitTracer
+// installed (a no-op tracer before InitTracer is called, so instrumented
+// code doesn't need to check whether tracing is enabled).
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// StartSpan starts a child span named name under ctx's current span (or a
+// new root span if ctx carries none).
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// grpcMetadataCarrier adapts grpc metadata.MD to otel's
+// propagation.TextMapCarrier, so ExtractTraceContext can read the
+// traceparent header a calling sensor's SDK (or an upstream service mesh
+// sidecar) propagated.
+type grpcMetadataCarrier metadata.MD
+
+func (c grpcMetadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (c grpcMetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c grpcMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ExtractTraceContext returns ctx carrying the remote span context
+// propagated in md (via the traceparent/tracestate headers), so a span
+// started from the returned ctx appears as a child of the caller's span
+// instead of starting a new trace.
+func ExtractTraceContext(ctx context.Context, md metadata.MD) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, grpcMetadataCarrier(md))
+}
@@ -0,0 +1,253 @@
+// internal/rules/adaptive.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Runtime-adaptive condition reordering.
+ *
+ * Compile orders each CompiledOrGroup's conditions once, from
+ * CalculateConditionCost alone. That static model can mispredict: a cheap
+ * exists on a field that's almost always present is a worse first
+ * condition than an expensive eq that almost never matches, since an AND
+ * group short-circuits on the first non-match. Engine.Register installs a
+ * rule for adaptive evaluation; Engine.Evaluate then evaluates each group
+ * in its current runtime order (initially the compiled one), records
+ * per-condition stats (stats.go), and periodically re-sorts toward
+ * observed_cost / (1 - match_rate + epsilon) - cheaper and more likely to
+ * short-circuit sorts first.
+ *
+ * Per-condition work on the hot path takes no lock: a group's current order
+ * is an atomic.Pointer swapped wholesale by resort, and counters are plain
+ * atomics. Evaluate and GetConditionStats take Engine.mu for reading only,
+ * to look up a rule's adaptive state; Register, which runs far less often
+ * than every evaluation, is its only writer.
+ */
+
+// adaptiveGroup is one CompiledOrGroup's adaptive-reordering state. base
+// and its element order never change after newAdaptiveGroup - only order
+// (a permutation of indices into base) and each condition's stats do, so
+// every field reachable from a stats/order snapshot is safe to read
+// without holding Engine.mu.
+type adaptiveGroup struct {
+	base  []CompiledCondition
+	stats []*conditionStats
+
+	order atomic.Pointer[[]int]
+
+	since      atomic.Uint64
+	reordering atomic.Bool
+}
+
+func newAdaptiveGroup(g CompiledOrGroup) *adaptiveGroup {
+	base := g.Conditions
+	order := make([]int, len(base))
+	stats := make([]*conditionStats, len(base))
+	for i := range base {
+		order[i] = i
+		stats[i] = &conditionStats{}
+	}
+
+	ag := &adaptiveGroup{base: base, stats: stats}
+	ag.order.Store(&order)
+	return ag
+}
+
+// resort rebuilds order from each condition's current effective cost,
+// ascending, breaking ties on OriginalIndex - the same stable-secondary-
+// key discipline Compile's static sort uses, so two conditions whose
+// scores happen to tie still produce deterministic matched_field
+// reporting. A condition with no evaluations yet scores 0 (conditionStats'
+// zero value), so it sorts as if cheapest until it has data of its own;
+// in practice that only affects conditions later in the group that a
+// short-circuiting predecessor has kept from ever running.
+func (g *adaptiveGroup) resort() {
+	type scoredCondition struct {
+		idx  int
+		cost float64
+	}
+
+	scored := make([]scoredCondition, len(g.base))
+	for i, s := range g.stats {
+		scored[i] = scoredCondition{idx: i, cost: s.snapshot().EffectiveCost}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].cost != scored[j].cost {
+			return scored[i].cost < scored[j].cost
+		}
+		return g.base[scored[i].idx].OriginalIndex < g.base[scored[j].idx].OriginalIndex
+	})
+
+	newOrder := make([]int, len(scored))
+	for i, sc := range scored {
+		newOrder[i] = sc.idx
+	}
+	g.order.Store(&newOrder)
+}
+
+// adaptiveRule is a Register-ed CompiledRule's per-OrGroup adaptive state.
+type adaptiveRule struct {
+	groups []*adaptiveGroup
+}
+
+func newAdaptiveRule(rule *CompiledRule) *adaptiveRule {
+	groups := make([]*adaptiveGroup, len(rule.OrGroups))
+	for i, g := range rule.OrGroups {
+		groups[i] = newAdaptiveGroup(g)
+	}
+	return &adaptiveRule{groups: groups}
+}
+
+// Register installs rule for adaptive evaluation via Engine.Evaluate,
+// starting every OrGroup from its compiled (static cost) order. Call once
+// per compiled rule, before routing its events through Engine.Evaluate;
+// re-registering the same RuleID - e.g. after a rule edit recompiles it -
+// replaces its adaptive state from scratch, since an edited rule's
+// conditions may no longer line up with the old GroupIndex/OriginalIndex
+// keys. A no-op cost if e.AdaptiveReordering is off: Evaluate ignores the
+// registry entirely in that mode, so callers can register every compiled
+// rule unconditionally and flip AdaptiveReordering on/off without also
+// touching their Register call sites.
+func (e *Engine) Register(rule *CompiledRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules[rule.RuleID] = newAdaptiveRule(rule)
+}
+
+// Evaluate evaluates rule against payload like the package-level Evaluate,
+// but - when e.AdaptiveReordering is on and rule was Register-ed - walks
+// each OrGroup's conditions in their current adaptive order and records
+// per-condition stats for GetConditionStats and future reordering. A rule
+// Engine never saw via Register, or any rule at all while
+// AdaptiveReordering is off, falls straight through to the package-level
+// Evaluate, so cost-model-only behavior remains exactly what it was
+// before this file existed.
+func (e *Engine) Evaluate(ctx context.Context, rule *CompiledRule, payload json.RawMessage, eventKey string) (MatchResult, error) {
+	if !e.AdaptiveReordering {
+		return Evaluate(ctx, rule, payload, eventKey)
+	}
+
+	e.mu.RLock()
+	ar := e.rules[rule.RuleID]
+	e.mu.RUnlock()
+	if ar == nil {
+		return Evaluate(ctx, rule, payload, eventKey)
+	}
+
+	result := MatchResult{
+		RuleID:   rule.RuleID,
+		RuleName: rule.Name,
+		Action:   rule.Action,
+	}
+
+	if !shouldSampleRule(ctx, rule, payload, eventKey) {
+		return result, nil
+	}
+
+	for groupIdx, group := range ar.groups {
+		matched, field, value, err := e.evaluateAdaptiveGroup(ctx, group, payload)
+		if err != nil {
+			return result, err
+		}
+		if matched {
+			result.Matched = true
+			result.MatchedField = field
+			result.MatchedValue = value
+			result.MatchedCondition = []any{"any", groupIdx, "all"}
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// evaluateAdaptiveGroup mirrors evaluateGroup's short-circuit and
+// matched_field semantics exactly; it differs only in which order
+// conditions are visited, in recording per-condition stats as it goes,
+// and in triggering maybeReorder once the group is done.
+func (e *Engine) evaluateAdaptiveGroup(ctx context.Context, group *adaptiveGroup, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
+	order := *group.order.Load()
+
+	var firstField []types.PathSegment
+	var firstValue any
+
+	for pos, idx := range order {
+		cond := group.base[idx]
+
+		start := time.Now()
+		matched, field, value, err := evaluateCondition(ctx, cond, payload)
+		group.stats[idx].record(matched, time.Since(start))
+
+		if err != nil {
+			e.maybeReorder(group)
+			return false, nil, nil, err
+		}
+		if !matched {
+			e.maybeReorder(group)
+			return false, nil, nil, nil
+		}
+		if pos == 0 {
+			firstField = field
+			firstValue = value
+		}
+	}
+
+	e.maybeReorder(group)
+	return true, firstField, firstValue, nil
+}
+
+// maybeReorder bumps group's since-last-reorder counter and, once it
+// reaches e.ReorderEvery (DefaultReorderEvery if unset), resorts - gated
+// by group.reordering so concurrent evaluations that cross the threshold
+// together still only trigger one resort instead of a thundering herd.
+func (e *Engine) maybeReorder(group *adaptiveGroup) {
+	threshold := e.ReorderEvery
+	if threshold == 0 {
+		threshold = DefaultReorderEvery
+	}
+
+	if group.since.Add(1) < threshold {
+		return
+	}
+	if !group.reordering.CompareAndSwap(false, true) {
+		return
+	}
+	defer group.reordering.Store(false)
+
+	group.since.Store(0)
+	group.resort()
+}
+
+// GetConditionStats returns a snapshot of every condition's runtime
+// counters for ruleID, ordered by (GroupIndex, OriginalIndex) - the
+// compiled order, not whatever order adaptive reordering has since
+// shuffled them to - so callers can always correlate a result back to the
+// rule definition. Returns nil if ruleID was never Register-ed.
+func (e *Engine) GetConditionStats(ruleID types.RuleID) []ConditionStats {
+	e.mu.RLock()
+	ar := e.rules[ruleID]
+	e.mu.RUnlock()
+	if ar == nil {
+		return nil
+	}
+
+	var out []ConditionStats
+	for groupIdx, group := range ar.groups {
+		for idx, cond := range group.base {
+			snap := group.stats[idx].snapshot()
+			snap.GroupIndex = groupIdx
+			snap.OriginalIndex = cond.OriginalIndex
+			out = append(out, snap)
+		}
+	}
+	return out
+}
@@ -0,0 +1,195 @@
+// internal/rules/adaptive_test.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+func twoConditionAndRule(ruleID types.RuleID) *types.Rule {
+	return &types.Rule{
+		RuleID:     ruleID,
+		Name:       "two-condition-and",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath:      []types.PathSegment{{Key: "status"}},
+						Operator:       int(OpEq),
+						FieldType:      int(FieldTypeText),
+						Value:          "active",
+						OnMissingField: int(OnMissingSkip),
+						OnCoercionFail: int(OnCoercionSkip),
+					},
+					{
+						FieldPath:      []types.PathSegment{{Key: "region"}},
+						Operator:       int(OpEq),
+						FieldType:      int(FieldTypeText),
+						Value:          "eu",
+						OnMissingField: int(OnMissingSkip),
+						OnCoercionFail: int(OnCoercionSkip),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEngine_Evaluate_AdaptiveReorderingOff_MatchesPlainEvaluate(t *testing.T) {
+	compiled, err := Compile(context.Background(), twoConditionAndRule("rule-adaptive-001"))
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	engine := NewEngine()
+	payload := json.RawMessage(`{"status": "active", "region": "eu"}`)
+
+	want, err := Evaluate(context.Background(), compiled, payload, "")
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v, want nil", err)
+	}
+	got, err := engine.Evaluate(context.Background(), compiled, payload, "")
+	if err != nil {
+		t.Fatalf("Engine.Evaluate() error = %v, want nil", err)
+	}
+
+	if got.Matched != want.Matched {
+		t.Errorf("Matched = %v, want %v", got.Matched, want.Matched)
+	}
+}
+
+func TestEngine_Evaluate_Adaptive_RecordsConditionStats(t *testing.T) {
+	compiled, err := Compile(context.Background(), twoConditionAndRule("rule-adaptive-002"))
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	engine := NewEngine()
+	engine.AdaptiveReordering = true
+	engine.Register(compiled)
+
+	payload := json.RawMessage(`{"status": "active", "region": "eu"}`)
+	for i := 0; i < 5; i++ {
+		if _, err := engine.Evaluate(context.Background(), compiled, payload, ""); err != nil {
+			t.Fatalf("Engine.Evaluate() error = %v, want nil", err)
+		}
+	}
+
+	stats := engine.GetConditionStats("rule-adaptive-002")
+	if len(stats) != 2 {
+		t.Fatalf("len(stats) = %v, want 2", len(stats))
+	}
+	for _, s := range stats {
+		if s.Evaluated != 5 {
+			t.Errorf("condition (group=%d, idx=%d): Evaluated = %v, want 5", s.GroupIndex, s.OriginalIndex, s.Evaluated)
+		}
+		if s.Matched != 5 {
+			t.Errorf("condition (group=%d, idx=%d): Matched = %v, want 5", s.GroupIndex, s.OriginalIndex, s.Matched)
+		}
+	}
+}
+
+func TestEngine_GetConditionStats_UnregisteredRule(t *testing.T) {
+	engine := NewEngine()
+	if stats := engine.GetConditionStats("never-registered"); stats != nil {
+		t.Errorf("GetConditionStats() = %v, want nil", stats)
+	}
+}
+
+func TestEffectiveCost_PenalizesHighMatchRate(t *testing.T) {
+	// Same observed cost, different match rates: a condition that almost
+	// always matches offers little short-circuit value and should score
+	// higher (sort later) than one that rarely matches.
+	rarelyMatches := effectiveCost(1000, 0.01)
+	almostAlwaysMatches := effectiveCost(1000, 0.99)
+
+	if rarelyMatches >= almostAlwaysMatches {
+		t.Errorf("effectiveCost(rarely matches) = %v, want < effectiveCost(almost always matches) = %v", rarelyMatches, almostAlwaysMatches)
+	}
+}
+
+func TestAdaptiveGroup_Resort_OrdersByEffectiveCostWithStableTiebreak(t *testing.T) {
+	rule := &types.Rule{
+		RuleID: "rule-resort",
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{FieldPath: []types.PathSegment{{Key: "a"}}, Operator: int(OpExists), FieldType: int(FieldTypeAny)},
+					{FieldPath: []types.PathSegment{{Key: "b"}}, Operator: int(OpExists), FieldType: int(FieldTypeAny)},
+					{FieldPath: []types.PathSegment{{Key: "c"}}, Operator: int(OpExists), FieldType: int(FieldTypeAny)},
+				},
+			},
+		},
+	}
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	group := newAdaptiveGroup(compiled.OrGroups[0])
+
+	// Condition 0 ("a"): expensive and rarely short-circuits -> should sort last.
+	group.stats[0].record(true, 500*time.Microsecond)
+	for i := 0; i < 99; i++ {
+		group.stats[0].record(true, 500*time.Microsecond)
+	}
+	// Condition 1 ("b") and 2 ("c"): identical stats -> tie, broken by OriginalIndex.
+	group.stats[1].record(false, 10*time.Microsecond)
+	group.stats[2].record(false, 10*time.Microsecond)
+
+	group.resort()
+	order := *group.order.Load()
+
+	if len(order) != 3 {
+		t.Fatalf("len(order) = %v, want 3", len(order))
+	}
+	if order[2] != 0 {
+		t.Errorf("order[2] = %v, want 0 (the expensive, high-match-rate condition sorts last)", order[2])
+	}
+	if order[0] != 1 || order[1] != 2 {
+		t.Errorf("order = %v, want [1 2 0] (tied conditions keep OriginalIndex order)", order)
+	}
+}
+
+func TestEngine_MaybeReorder_GatesOnReorderEvery(t *testing.T) {
+	rule := &types.Rule{
+		RuleID: "rule-gate",
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{FieldPath: []types.PathSegment{{Key: "a"}}, Operator: int(OpExists), FieldType: int(FieldTypeAny)},
+					{FieldPath: []types.PathSegment{{Key: "b"}}, Operator: int(OpExists), FieldType: int(FieldTypeAny)},
+				},
+			},
+		},
+	}
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	engine := NewEngine()
+	engine.ReorderEvery = 3
+	group := newAdaptiveGroup(compiled.OrGroups[0])
+
+	// Flip which condition looks cheaper so a reorder, if it fired, would be observable.
+	group.stats[0].record(true, 500*time.Microsecond)
+	group.stats[1].record(false, 1*time.Microsecond)
+
+	engine.maybeReorder(group)
+	engine.maybeReorder(group)
+	if order := *group.order.Load(); order[0] != 0 {
+		t.Fatalf("order = %v after 2 calls, want unchanged [0 1] (threshold is 3)", order)
+	}
+
+	engine.maybeReorder(group)
+	if order := *group.order.Load(); order[0] != 1 {
+		t.Errorf("order = %v after 3 calls, want [1 0] (condition 1 is cheaper)", order)
+	}
+}
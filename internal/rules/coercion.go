@@ -2,9 +2,14 @@
 package rules
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/netip"
 	"strconv"
 	"strings"
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/solatis/trapperkeeper/internal/types"
 )
@@ -27,6 +32,10 @@ import (
  *
  * Performance: String trimming for NUMERIC per spec (whitespace-only strings
  * are not valid numbers).
+ *
+ * Coerce/CoerceWithOptions enforce no resource limit of their own (no
+ * Max* constant bounds a coercion), so unlike Resolve/ResolveAll/Compile
+ * they have nothing to pull from types.LimitsFromContext.
  */
 
 // FieldType mirrors the protobuf enum for field type specification.
@@ -38,6 +47,21 @@ const (
 	FieldTypeText
 	FieldTypeBoolean
 	FieldTypeAny
+
+	// FieldTypeTimestamp canonicalizes to time.Time. Strict: accepts
+	// RFC3339 strings, *timestamppb.Timestamp, and unix-epoch numerics;
+	// rejects everything else (no implicit string-that-isn't-RFC3339).
+	FieldTypeTimestamp
+
+	// FieldTypeDuration canonicalizes to time.Duration. Strict: accepts
+	// time.ParseDuration strings ("90s", "1h30m") and numeric seconds.
+	FieldTypeDuration
+
+	// FieldTypeIPAddr canonicalizes to netip.Addr for a plain address, or
+	// netip.Prefix for CIDR notation. Strict: accepts strings only -
+	// numeric-to-IP coercion is rejected (a bare integer isn't visibly an
+	// address or a mistake in the way a numeric string is).
+	FieldTypeIPAddr
 )
 
 // CoercionResult holds the coerced value or indicates null.
@@ -63,6 +87,12 @@ func Coerce(value any, fieldType FieldType) (CoercionResult, error) {
 		return coerceBoolean(value)
 	case FieldTypeAny:
 		return coerceAny(value)
+	case FieldTypeTimestamp:
+		return coerceTimestamp(value)
+	case FieldTypeDuration:
+		return coerceDuration(value)
+	case FieldTypeIPAddr:
+		return coerceIPAddr(value)
 	case FieldTypeUnspecified:
 		// Treat unspecified as ANY
 		return coerceAny(value)
@@ -145,3 +175,203 @@ func coerceAny(value any) (CoercionResult, error) {
 	// Numeric/string comparison handled by Compare() operator logic
 	return CoercionResult{Value: value}, nil
 }
+
+// coerceTimestamp converts value to time.Time. Accepts RFC3339 strings,
+// *timestamppb.Timestamp (the wire representation events carry), and
+// unix-epoch numerics (seconds, since that's what field paths resolving
+// into a raw JSON payload are most likely to hold). Rejects booleans and
+// any string that isn't valid RFC3339 - strict mode, same as NUMERIC/BOOLEAN.
+func coerceTimestamp(value any) (CoercionResult, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return CoercionResult{Value: v}, nil
+	case *timestamppb.Timestamp:
+		return CoercionResult{Value: v.AsTime()}, nil
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: t}, nil
+	case float64:
+		return CoercionResult{Value: time.Unix(0, int64(v*float64(time.Second))).UTC()}, nil
+	case int:
+		return CoercionResult{Value: time.Unix(int64(v), 0).UTC()}, nil
+	case int64:
+		return CoercionResult{Value: time.Unix(v, 0).UTC()}, nil
+	default:
+		return CoercionResult{}, types.ErrCoercionFailed
+	}
+}
+
+// coerceDuration converts value to time.Duration. Accepts
+// time.ParseDuration strings ("90s", "1h30m") and numeric seconds. Rejects
+// booleans and unparseable strings.
+func coerceDuration(value any) (CoercionResult, error) {
+	switch v := value.(type) {
+	case time.Duration:
+		return CoercionResult{Value: v}, nil
+	case string:
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: d}, nil
+	case float64:
+		return CoercionResult{Value: time.Duration(v * float64(time.Second))}, nil
+	case int:
+		return CoercionResult{Value: time.Duration(v) * time.Second}, nil
+	case int64:
+		return CoercionResult{Value: time.Duration(v) * time.Second}, nil
+	default:
+		return CoercionResult{}, types.ErrCoercionFailed
+	}
+}
+
+// coerceIPAddr converts value to netip.Addr (plain address) or
+// netip.Prefix (CIDR notation). Strings only: a bare integer is rejected
+// rather than reinterpreted as a packed IP, unlike the lenient
+// numeric<->string coercions elsewhere in this file.
+func coerceIPAddr(value any) (CoercionResult, error) {
+	switch v := value.(type) {
+	case netip.Addr:
+		return CoercionResult{Value: v}, nil
+	case netip.Prefix:
+		return CoercionResult{Value: v}, nil
+	case string:
+		if strings.Contains(v, "/") {
+			prefix, err := netip.ParsePrefix(v)
+			if err != nil {
+				return CoercionResult{}, types.ErrCoercionFailed
+			}
+			return CoercionResult{Value: prefix}, nil
+		}
+		addr, err := netip.ParseAddr(v)
+		if err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: addr}, nil
+	default:
+		return CoercionResult{}, types.ErrCoercionFailed
+	}
+}
+
+// NumberMode selects how CoerceWithOptions represents numeric values,
+// mirroring the UseNumber option on encoding/json.Decoder.
+type NumberMode int
+
+const (
+	// NumberModeFloat64 matches Coerce(): everything becomes float64.
+	NumberModeFloat64 NumberMode = iota
+	// NumberModeJSONNumber keeps the original digit string as json.Number,
+	// so comparisons run on the raw digits instead of a lossy float64.
+	NumberModeJSONNumber
+	// NumberModeAutoInt keeps values that fit in int64 as int64, falling
+	// back to float64 only for non-integral or out-of-range values.
+	NumberModeAutoInt
+)
+
+// CoerceOptions configures precision-preserving numeric coercion.
+type CoerceOptions struct {
+	// PreserveIntegers is shorthand for NumberModeAutoInt when NumberMode
+	// is left at its zero value; set NumberMode directly for JSON-Number mode.
+	PreserveIntegers bool
+	NumberMode       NumberMode
+}
+
+// CoerceWithOptions behaves like Coerce, except for FieldTypeNumeric it can
+// preserve int64 precision for values beyond float64's 2^53 integer range
+// (event IDs, order numbers, epoch-nanos timestamps) instead of silently
+// losing precision. Non-numeric field types are unaffected by opts.
+func CoerceWithOptions(value any, ft FieldType, opts CoerceOptions) (CoercionResult, error) {
+	if value == nil {
+		return CoercionResult{IsNull: true}, nil
+	}
+	if ft != FieldTypeNumeric {
+		return Coerce(value, ft)
+	}
+
+	mode := opts.NumberMode
+	if opts.PreserveIntegers && mode == NumberModeFloat64 {
+		mode = NumberModeAutoInt
+	}
+
+	switch mode {
+	case NumberModeJSONNumber:
+		return coerceNumericAsJSONNumber(value)
+	case NumberModeAutoInt:
+		return coerceNumericAutoInt(value)
+	default:
+		return coerceNumeric(value)
+	}
+}
+
+// coerceNumericAsJSONNumber preserves the original digit string as
+// json.Number rather than collapsing it into a lossy float64.
+func coerceNumericAsJSONNumber(value any) (CoercionResult, error) {
+	switch v := value.(type) {
+	case json.Number:
+		return CoercionResult{Value: v}, nil
+	case float64:
+		return CoercionResult{Value: json.Number(strconv.FormatFloat(v, 'f', -1, 64))}, nil
+	case int:
+		return CoercionResult{Value: json.Number(strconv.Itoa(v))}, nil
+	case int64:
+		return CoercionResult{Value: json.Number(strconv.FormatInt(v, 10))}, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: json.Number(s)}, nil
+	case bool:
+		return CoercionResult{}, types.ErrCoercionFailed
+	default:
+		return CoercionResult{}, types.ErrCoercionFailed
+	}
+}
+
+// coerceNumericAutoInt keeps integers that fit in int64 as int64; anything
+// non-integral or out of int64 range falls back to float64.
+func coerceNumericAutoInt(value any) (CoercionResult, error) {
+	switch v := value.(type) {
+	case int64:
+		return CoercionResult{Value: v}, nil
+	case int:
+		return CoercionResult{Value: int64(v)}, nil
+	case float64:
+		if i := int64(v); float64(i) == v {
+			return CoercionResult{Value: i}, nil
+		}
+		return CoercionResult{Value: v}, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return CoercionResult{Value: i}, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: f}, nil
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return CoercionResult{Value: i}, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return CoercionResult{}, types.ErrCoercionFailed
+		}
+		return CoercionResult{Value: f}, nil
+	case bool:
+		return CoercionResult{}, types.ErrCoercionFailed
+	default:
+		return CoercionResult{}, types.ErrCoercionFailed
+	}
+}
@@ -1,12 +1,23 @@
 package rules
 
 import (
+	"encoding/json"
 	"math"
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/solatis/trapperkeeper/internal/types"
 )
 
+func mustParseRFC3339(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
 func TestCoerce(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -297,6 +308,111 @@ func TestCoerce(t *testing.T) {
 			wantNull:  false,
 			wantErr:   nil,
 		},
+
+		// TIMESTAMP type tests
+		{
+			name:      "timestamp: RFC3339 string",
+			value:     "2026-01-15T10:30:00Z",
+			fieldType: FieldTypeTimestamp,
+			wantValue: mustParseRFC3339("2026-01-15T10:30:00Z"),
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "timestamp: unix-epoch seconds",
+			value:     float64(1768473000),
+			fieldType: FieldTypeTimestamp,
+			wantValue: time.Unix(1768473000, 0).UTC(),
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "timestamp: time.Time passthrough",
+			value:     mustParseRFC3339("2026-01-15T10:30:00Z"),
+			fieldType: FieldTypeTimestamp,
+			wantValue: mustParseRFC3339("2026-01-15T10:30:00Z"),
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "timestamp: non-RFC3339 string fails",
+			value:     "not-a-timestamp",
+			fieldType: FieldTypeTimestamp,
+			wantErr:   types.ErrCoercionFailed,
+		},
+		{
+			name:      "timestamp: boolean rejected",
+			value:     true,
+			fieldType: FieldTypeTimestamp,
+			wantErr:   types.ErrCoercionFailed,
+		},
+		{
+			name:      "timestamp: nil returns null",
+			value:     nil,
+			fieldType: FieldTypeTimestamp,
+			wantNull:  true,
+			wantErr:   nil,
+		},
+
+		// DURATION type tests
+		{
+			name:      "duration: ParseDuration string",
+			value:     "90s",
+			fieldType: FieldTypeDuration,
+			wantValue: 90 * time.Second,
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "duration: numeric seconds",
+			value:     float64(30),
+			fieldType: FieldTypeDuration,
+			wantValue: 30 * time.Second,
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "duration: unparseable string fails",
+			value:     "soon",
+			fieldType: FieldTypeDuration,
+			wantErr:   types.ErrCoercionFailed,
+		},
+		{
+			name:      "duration: boolean rejected",
+			value:     false,
+			fieldType: FieldTypeDuration,
+			wantErr:   types.ErrCoercionFailed,
+		},
+
+		// IPADDR type tests
+		{
+			name:      "ipaddr: plain address",
+			value:     "10.0.0.5",
+			fieldType: FieldTypeIPAddr,
+			wantValue: netip.MustParseAddr("10.0.0.5"),
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "ipaddr: CIDR canonicalizes to netip.Prefix",
+			value:     "10.0.0.0/8",
+			fieldType: FieldTypeIPAddr,
+			wantValue: netip.MustParsePrefix("10.0.0.0/8"),
+			wantNull:  false,
+			wantErr:   nil,
+		},
+		{
+			name:      "ipaddr: unparseable string fails",
+			value:     "not-an-ip",
+			fieldType: FieldTypeIPAddr,
+			wantErr:   types.ErrCoercionFailed,
+		},
+		{
+			name:      "ipaddr: numeric rejected (no numeric-to-IP coercion)",
+			value:     float64(167772165),
+			fieldType: FieldTypeIPAddr,
+			wantErr:   types.ErrCoercionFailed,
+		},
 	}
 
 	for _, tt := range tests {
@@ -429,3 +545,170 @@ func TestCoerceNumericEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestCoerceWithOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     any
+		opts      CoerceOptions
+		wantValue any
+		wantNull  bool
+		wantErr   error
+	}{
+		{
+			name:      "float64 mode behaves like Coerce",
+			value:     int64(100),
+			opts:      CoerceOptions{},
+			wantValue: 100.0,
+		},
+		{
+			name:      "PreserveIntegers keeps int64 beyond 2^53 exact",
+			value:     int64(9007199254740993), // 2^53 + 1, not representable exactly as float64
+			opts:      CoerceOptions{PreserveIntegers: true},
+			wantValue: int64(9007199254740993),
+		},
+		{
+			name:      "PreserveIntegers falls back to float64 for non-integral values",
+			value:     3.14,
+			opts:      CoerceOptions{PreserveIntegers: true},
+			wantValue: 3.14,
+		},
+		{
+			name:      "PreserveIntegers converts numeric string to int64",
+			value:     "9007199254740993",
+			opts:      CoerceOptions{PreserveIntegers: true},
+			wantValue: int64(9007199254740993),
+		},
+		{
+			name:      "NumberModeJSONNumber preserves original digit string",
+			value:     json.Number("9007199254740993"),
+			opts:      CoerceOptions{NumberMode: NumberModeJSONNumber},
+			wantValue: json.Number("9007199254740993"),
+		},
+		{
+			name:      "NumberModeJSONNumber converts int64 to json.Number",
+			value:     int64(42),
+			opts:      CoerceOptions{NumberMode: NumberModeJSONNumber},
+			wantValue: json.Number("42"),
+		},
+		{
+			name:    "NumberModeJSONNumber rejects boolean (strict mode)",
+			value:   true,
+			opts:    CoerceOptions{NumberMode: NumberModeJSONNumber},
+			wantErr: types.ErrCoercionFailed,
+		},
+		{
+			name:     "nil returns null regardless of mode",
+			value:    nil,
+			opts:     CoerceOptions{PreserveIntegers: true},
+			wantNull: true,
+		},
+		{
+			name:      "non-numeric field type ignores opts",
+			value:     42,
+			opts:      CoerceOptions{PreserveIntegers: true},
+			wantValue: "42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ft := FieldTypeNumeric
+			if tt.name == "non-numeric field type ignores opts" {
+				ft = FieldTypeText
+			}
+
+			result, err := CoerceWithOptions(tt.value, ft, tt.opts)
+
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Errorf("CoerceWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("CoerceWithOptions() unexpected error = %v", err)
+				return
+			}
+
+			if result.IsNull != tt.wantNull {
+				t.Errorf("CoerceWithOptions() IsNull = %v, want %v", result.IsNull, tt.wantNull)
+			}
+
+			if !tt.wantNull && result.Value != tt.wantValue {
+				t.Errorf("CoerceWithOptions() Value = %v (%T), want %v (%T)", result.Value, result.Value, tt.wantValue, tt.wantValue)
+			}
+		})
+	}
+}
+
+// TestCompareEqual_LargeInt64Precision demonstrates the motivating case for
+// PreserveIntegers: two distinct int64 IDs beyond float64's 2^53 integer
+// range collapse to the same float64 under plain Coerce/EQ, but compare
+// correctly when PreserveIntegers routes through CoerceWithOptions.
+func TestCompareEqual_LargeInt64Precision(t *testing.T) {
+	a := int64(9007199254740992) // 2^53
+	b := int64(9007199254740993) // 2^53 + 1, rounds to same float64 as a
+
+	plainA, _ := Coerce(a, FieldTypeNumeric)
+	plainB, _ := Coerce(b, FieldTypeNumeric)
+	if !compareEqual(plainA.Value, plainB.Value) {
+		t.Fatal("expected plain float64 coercion to collide for these two IDs (test assumption broken)")
+	}
+
+	preciseA, _ := CoerceWithOptions(a, FieldTypeNumeric, CoerceOptions{PreserveIntegers: true})
+	preciseB, _ := CoerceWithOptions(b, FieldTypeNumeric, CoerceOptions{PreserveIntegers: true})
+	if compareEqual(preciseA.Value, preciseB.Value) {
+		t.Error("compareEqual() = true with PreserveIntegers, want false (distinct int64 IDs)")
+	}
+}
+
+// TestCompare_TimestampDuration exercises LT/GTE against the coerced
+// time.Time/time.Duration values FieldTypeTimestamp/FieldTypeDuration
+// produce.
+func TestCompare_TimestampDuration(t *testing.T) {
+	earlier := mustParseRFC3339("2026-01-01T00:00:00Z")
+	later := mustParseRFC3339("2026-06-01T00:00:00Z")
+	if !Compare(OpLt, earlier, later) {
+		t.Error("Compare(OpLt) = false for earlier < later timestamps, want true")
+	}
+	if Compare(OpLt, later, earlier) {
+		t.Error("Compare(OpLt) = true for later < earlier timestamps, want false")
+	}
+	if !Compare(OpEq, earlier, mustParseRFC3339("2026-01-01T00:00:00Z")) {
+		t.Error("Compare(OpEq) = false for identical timestamps, want true")
+	}
+
+	if !Compare(OpGte, 90*time.Second, 30*time.Second) {
+		t.Error("Compare(OpGte) = false for 90s >= 30s, want true")
+	}
+	if Compare(OpGte, 10*time.Second, 30*time.Second) {
+		t.Error("Compare(OpGte) = true for 10s >= 30s, want false")
+	}
+}
+
+// TestCompare_IPAddrCIDRContainment exercises FieldTypeIPAddr's OpEq/OpIn
+// containment semantics: a CIDR target matches any address within it, not
+// just a literal string match.
+func TestCompare_IPAddrCIDRContainment(t *testing.T) {
+	addr := netip.MustParseAddr("10.1.2.3")
+
+	if !Compare(OpEq, addr, netip.MustParsePrefix("10.0.0.0/8")) {
+		t.Error("Compare(OpEq) = false for address within CIDR, want true (containment)")
+	}
+	if Compare(OpEq, addr, netip.MustParsePrefix("192.168.0.0/16")) {
+		t.Error("Compare(OpEq) = true for address outside CIDR, want false")
+	}
+	if !Compare(OpEq, addr, netip.MustParseAddr("10.1.2.3")) {
+		t.Error("Compare(OpEq) = false for identical address, want true")
+	}
+
+	set := []any{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}
+	if !Compare(OpIn, addr, set) {
+		t.Error("Compare(OpIn) = false for address contained in one of the CIDR set, want true")
+	}
+	if Compare(OpIn, netip.MustParseAddr("172.16.0.1"), set) {
+		t.Error("Compare(OpIn) = true for address outside every CIDR in the set, want false")
+	}
+}
@@ -2,6 +2,10 @@
 package rules
 
 import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
 	"sort"
 
 	"github.com/solatis/trapperkeeper/internal/types"
@@ -14,7 +18,9 @@ import (
  * resource limits, and calculated priority for cost-based evaluation.
  *
  * Compilation workflow:
- *   1. Validate resource limits (path depth, wildcards, IN values)
+ *   1. Validate resource limits (path depth, wildcards, IN values) against
+ *      the types.Limits installed into ctx via types.WithLimits
+ *      (types.DefaultLimits() if ctx carries none)
  *   2. Calculate condition costs using canonical cost model
  *   3. Order conditions by ascending cost (stable sort for determinism)
  *   4. Calculate rule priority from total cost + penalties
@@ -47,6 +53,14 @@ const (
 	OpIn
 	OpExists
 	OpIsNull
+	OpAnyOf  // true if any wildcard-resolved leaf satisfies Quantifier
+	OpAllOf  // true if every wildcard-resolved leaf satisfies Quantifier
+	OpCount  // true if the count of leaves satisfying Quantifier matches Quantifier.CountValue
+	OpNoneOf // true if no wildcard-resolved leaf satisfies Quantifier (negation of OpAnyOf)
+
+	OpRegex // value matches Value's pattern, compiled to *regexp.Regexp at Compile time
+	OpGlob  // value matches Value's glob pattern (*, ?, [abc]), compiled to *globPattern at Compile time
+	OpCIDR  // value, parsed as an IP, falls within Value's CIDR, parsed to *net.IPNet at Compile time
 )
 
 // OnMissingField policy for missing field handling.
@@ -69,15 +83,28 @@ const (
 
 // CompiledCondition is a pre-processed condition ready for evaluation.
 type CompiledCondition struct {
-	Path       []types.PathSegment
-	Operator   Operator
-	FieldType  FieldType
-	Value      any   // comparison value (nil for exists/is_null)
-	Values     []any // for IN operator
-	FieldRef   []types.PathSegment // for cross-field comparison (mutually exclusive with Value)
-	OnMissing  OnMissingField
-	OnCoercion OnCoercionPolicy
-	Cost       int
+	Path             []types.PathSegment
+	Operator         Operator
+	FieldType        FieldType
+	Value            any   // comparison value (nil for exists/is_null)
+	Values           []any // for IN operator
+	FieldRef         []types.PathSegment // for cross-field comparison (mutually exclusive with Value)
+	OnMissing        OnMissingField
+	OnCoercion       OnCoercionPolicy
+	PreserveIntegers bool                    // precision-preserving numeric coercion (see types.Condition)
+	Quantifier       *types.QuantifierClause // for ANY_OF/ALL_OF/COUNT
+	Cost             int
+
+	// GroupIndex and OriginalIndex identify this condition's position as
+	// compiled, before any cost-based sort: GroupIndex is its
+	// CompiledOrGroup's index within CompiledRule.OrGroups, OriginalIndex
+	// its index within that group's Conditions as written in the rule.
+	// Both stay fixed across re-sorts (the static stable sort in Compile
+	// and Engine's adaptive reordering in adaptive.go), so they double as
+	// the stable secondary sort key and the key conditionStats is tracked
+	// under.
+	GroupIndex    int
+	OriginalIndex int
 }
 
 // CompiledOrGroup is a pre-processed AND group.
@@ -93,6 +120,11 @@ type CompiledRule struct {
 	SampleRate float64
 	OrGroups   []CompiledOrGroup
 	Priority   int // calculated from cost model
+
+	// SamplingStrategy and SampleKeyPath configure how SampleRate's
+	// threshold is applied - see evaluate.go and types.Rule.
+	SamplingStrategy SamplingStrategy
+	SampleKeyPath    []types.PathSegment
 }
 
 // Action mirrors the protobuf enum for rule actions.
@@ -105,28 +137,35 @@ const (
 	ActionFail
 )
 
-// Compile validates and pre-processes a rule for efficient evaluation.
-func Compile(rule *types.Rule) (*CompiledRule, error) {
+// Compile validates and pre-processes a rule for efficient evaluation,
+// enforcing the resource limits installed into ctx via types.WithLimits
+// (types.DefaultLimits() if ctx carries none).
+func Compile(ctx context.Context, rule *types.Rule) (*CompiledRule, error) {
+	limits := types.LimitsFromContext(ctx)
 	compiled := &CompiledRule{
-		RuleID:     rule.RuleID,
-		Name:       rule.Name,
-		Action:     Action(rule.Action),
-		SampleRate: rule.SampleRate,
-		OrGroups:   make([]CompiledOrGroup, 0, len(rule.OrGroups)),
+		RuleID:           rule.RuleID,
+		Name:             rule.Name,
+		Action:           Action(rule.Action),
+		SampleRate:       rule.SampleRate,
+		OrGroups:         make([]CompiledOrGroup, 0, len(rule.OrGroups)),
+		SamplingStrategy: SamplingStrategy(rule.SamplingStrategy),
+		SampleKeyPath:    rule.SampleKeyPath,
 	}
 
 	totalCost := 0
 
-	for _, group := range rule.OrGroups {
+	for groupIdx, group := range rule.OrGroups {
 		compiledGroup := CompiledOrGroup{
 			Conditions: make([]CompiledCondition, 0, len(group.Conditions)),
 		}
 
-		for _, cond := range group.Conditions {
-			cc, err := compileCondition(cond)
+		for condIdx, cond := range group.Conditions {
+			cc, err := compileCondition(limits, cond)
 			if err != nil {
 				return nil, err
 			}
+			cc.GroupIndex = groupIdx
+			cc.OriginalIndex = condIdx
 			compiledGroup.Conditions = append(compiledGroup.Conditions, cc)
 			totalCost += cc.Cost
 		}
@@ -148,13 +187,14 @@ func Compile(rule *types.Rule) (*CompiledRule, error) {
 }
 
 // compileCondition validates and pre-processes a single condition for evaluation.
-// Enforces path depth, wildcard, and IN value limits. Calculates cost for ordering.
-// Validates field_ref paths contain no wildcards (prevents N*M comparison matrix).
-func compileCondition(cond types.Condition) (CompiledCondition, error) {
+// Enforces path depth, wildcard, and IN value limits from limits. Calculates
+// cost for ordering. Validates field_ref paths contain no wildcards
+// (prevents N*M comparison matrix).
+func compileCondition(limits types.Limits, cond types.Condition) (CompiledCondition, error) {
 	path := cond.FieldPath
 
 	// Validate path depth
-	if len(path) > types.MaxPathDepth {
+	if len(path) > limits.MaxPathDepth {
 		return CompiledCondition{}, types.ErrPathTooDeep
 	}
 
@@ -165,7 +205,7 @@ func compileCondition(cond types.Condition) (CompiledCondition, error) {
 			wildcardCount++
 		}
 	}
-	if wildcardCount > types.MaxNestedWildcards {
+	if wildcardCount > limits.MaxNestedWildcards {
 		return CompiledCondition{}, types.ErrTooManyWildcards
 	}
 
@@ -183,21 +223,115 @@ func compileCondition(cond types.Condition) (CompiledCondition, error) {
 	ft := FieldType(cond.FieldType)
 
 	// Validate IN operator values
-	if op == OpIn && len(cond.Values) > types.MaxInOperatorValues {
+	if op == OpIn && len(cond.Values) > limits.MaxInOperatorValues {
 		return CompiledCondition{}, types.ErrTooManyInValues
 	}
 
+	// ANY_OF/ALL_OF/COUNT/NONE_OF quantify over every wildcard leaf, so they
+	// need a path with at least one wildcard and a quantifier clause to apply.
+	if op == OpAnyOf || op == OpAllOf || op == OpCount || op == OpNoneOf {
+		if wildcardCount == 0 {
+			return CompiledCondition{}, types.ErrInvalidOperator
+		}
+		if cond.Quantifier == nil {
+			return CompiledCondition{}, types.ErrInvalidOperator
+		}
+	}
+
+	// OpRegex/OpGlob/OpCIDR precompile their literal once here, replacing
+	// cond.Value with the compiled artifact so Evaluate/Compare never
+	// reparse a pattern per event (see matchCompiledPattern in evaluate.go
+	// and Compare in operators.go, both of which type-assert Value to the
+	// artifact type the operator expects). A condition whose value isn't
+	// parseable at all (bad regex/glob syntax, bad CIDR notation) is
+	// rejected at compile time via ErrInvalidOperator - the same error
+	// Coerce-adjacent validation elsewhere in this file uses for "this
+	// condition can never evaluate correctly," as opposed to
+	// ErrCoercionFailed, which is reserved for a payload value (discovered
+	// only at evaluation time) that doesn't coerce to the condition's
+	// FieldType. A resolved field value that isn't a parseable IP address
+	// is a *runtime* case these precompiled artifacts handle themselves
+	// (matchCompiledPattern/compareCIDR return false rather than erroring,
+	// the same way comparePrefix/compareSuffix already do for a non-string
+	// value) - it never reaches ErrCoercionFailed because FieldTypeText's
+	// Coerce step has no notion of "IP-shaped", only "string-shaped".
+	switch op {
+	case OpRegex, OpGlob:
+		pattern, ok := cond.Value.(string)
+		if !ok {
+			return CompiledCondition{}, types.ErrInvalidOperator
+		}
+		if len(pattern) > limits.MaxRegexSize {
+			return CompiledCondition{}, types.ErrPatternTooLarge
+		}
+		if op == OpRegex {
+			compiledRegex, err := regexp.Compile(pattern)
+			if err != nil {
+				return CompiledCondition{}, fmt.Errorf("%w: %v", types.ErrInvalidOperator, err)
+			}
+			cond.Value = compiledRegex
+		} else {
+			compiledGlob, err := compileGlob(pattern)
+			if err != nil {
+				return CompiledCondition{}, fmt.Errorf("%w: %v", types.ErrInvalidOperator, err)
+			}
+			cond.Value = compiledGlob
+		}
+	case OpCIDR:
+		cidr, ok := cond.Value.(string)
+		if !ok {
+			return CompiledCondition{}, types.ErrInvalidOperator
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return CompiledCondition{}, fmt.Errorf("%w: %v", types.ErrInvalidOperator, err)
+		}
+		cond.Value = network
+	}
+
+	// FieldTypeTimestamp/FieldTypeDuration/FieldTypeIPAddr canonicalize
+	// their comparison literal(s) once here too, the same reasoning as
+	// OpRegex/OpGlob/OpCIDR above: the payload side already runs through
+	// Coerce at evaluation time, so the literal side needs the same
+	// canonical type (time.Time/time.Duration/netip.Addr/netip.Prefix) for
+	// Compare's comparisons to see matching types instead of a raw
+	// string/number on one side. field_ref targets don't need this - they
+	// resolve against the payload and go through CoerceWithOptions at
+	// evaluation time like any other field_ref.
+	if len(fieldRef) == 0 && (ft == FieldTypeTimestamp || ft == FieldTypeDuration || ft == FieldTypeIPAddr) {
+		switch op {
+		case OpEq, OpNeq, OpLt, OpLte, OpGt, OpGte:
+			coerced, err := Coerce(cond.Value, ft)
+			if err != nil {
+				return CompiledCondition{}, fmt.Errorf("%w: %v", types.ErrInvalidOperator, err)
+			}
+			cond.Value = coerced.Value
+		case OpIn:
+			values := make([]any, len(cond.Values))
+			for i, v := range cond.Values {
+				coerced, err := Coerce(v, ft)
+				if err != nil {
+					return CompiledCondition{}, fmt.Errorf("%w: %v", types.ErrInvalidOperator, err)
+				}
+				values[i] = coerced.Value
+			}
+			cond.Values = values
+		}
+	}
+
 	cost := CalculateConditionCost(path, op, ft)
 
 	return CompiledCondition{
-		Path:       path,
-		Operator:   op,
-		FieldType:  ft,
-		Value:      cond.Value,
-		Values:     cond.Values,
-		FieldRef:   fieldRef,
-		OnMissing:  OnMissingField(cond.OnMissingField),
-		OnCoercion: OnCoercionPolicy(cond.OnCoercionFail),
-		Cost:       cost,
+		Path:             path,
+		Operator:         op,
+		FieldType:        ft,
+		Value:            cond.Value,
+		Values:           cond.Values,
+		FieldRef:         fieldRef,
+		OnMissing:        OnMissingField(cond.OnMissingField),
+		OnCoercion:       OnCoercionPolicy(cond.OnCoercionFail),
+		PreserveIntegers: cond.PreserveIntegers,
+		Quantifier:       cond.Quantifier,
+		Cost:             cost,
 	}, nil
 }
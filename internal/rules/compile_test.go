@@ -2,6 +2,10 @@
 package rules
 
 import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
 	"testing"
 
 	"github.com/solatis/trapperkeeper/internal/types"
@@ -26,7 +30,7 @@ func TestCompile_SimpleRule(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
@@ -75,7 +79,7 @@ func TestCompile_MultiGroupDNF(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
@@ -110,7 +114,7 @@ func TestCompile_ConditionsOrderedByCost(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
@@ -160,7 +164,7 @@ func TestCompile_MaximumWildcardsAllowed(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil (2 wildcards should be allowed)", err)
 	}
@@ -195,7 +199,7 @@ func TestCompile_MaximumINValues(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil (64 IN values should be allowed)", err)
 	}
@@ -230,7 +234,7 @@ func TestCompile_ErrorPathTooDeep(t *testing.T) {
 		},
 	}
 
-	_, err := Compile(rule)
+	_, err := Compile(context.Background(), rule)
 	if err != types.ErrPathTooDeep {
 		t.Errorf("Compile() error = %v, want ErrPathTooDeep", err)
 	}
@@ -262,7 +266,7 @@ func TestCompile_ErrorTooManyWildcards(t *testing.T) {
 		},
 	}
 
-	_, err := Compile(rule)
+	_, err := Compile(context.Background(), rule)
 	if err != types.ErrTooManyWildcards {
 		t.Errorf("Compile() error = %v, want ErrTooManyWildcards", err)
 	}
@@ -293,7 +297,7 @@ func TestCompile_ErrorINOperatorTooManyValues(t *testing.T) {
 		},
 	}
 
-	_, err := Compile(rule)
+	_, err := Compile(context.Background(), rule)
 	if err != types.ErrTooManyInValues {
 		t.Errorf("Compile() error = %v, want ErrTooManyInValues", err)
 	}
@@ -323,7 +327,7 @@ func TestCompile_ErrorFieldRefWithWildcard(t *testing.T) {
 		},
 	}
 
-	_, err := Compile(rule)
+	_, err := Compile(context.Background(), rule)
 	if err != types.ErrWildcardInFieldRef {
 		t.Errorf("Compile() error = %v, want ErrWildcardInFieldRef", err)
 	}
@@ -348,7 +352,7 @@ func TestCompile_PriorityCalculation(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
@@ -366,3 +370,69 @@ func TestCompile_PriorityCalculation(t *testing.T) {
 		t.Errorf("Priority = %v, want %v", compiled.Priority, expectedPriority)
 	}
 }
+
+func patternCondition(op Operator, pattern any) *types.Rule {
+	return &types.Rule{
+		RuleID:     "rule-pattern",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+			{FieldPath: []types.PathSegment{{Key: "value"}}, Operator: int(op), FieldType: int(FieldTypeText), Value: pattern},
+		}}},
+	}
+}
+
+func TestCompile_OpRegexPrecompilesPattern(t *testing.T) {
+	compiled, err := Compile(context.Background(), patternCondition(OpRegex, `^[a-z]+\d+$`))
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+	if _, ok := compiled.OrGroups[0].Conditions[0].Value.(*regexp.Regexp); !ok {
+		t.Errorf("Conditions[0].Value = %T, want *regexp.Regexp", compiled.OrGroups[0].Conditions[0].Value)
+	}
+}
+
+func TestCompile_OpRegexRejectsInvalidPattern(t *testing.T) {
+	_, err := Compile(context.Background(), patternCondition(OpRegex, `(unclosed`))
+	if !errors.Is(err, types.ErrInvalidOperator) {
+		t.Errorf("Compile() error = %v, want wrapping ErrInvalidOperator", err)
+	}
+}
+
+func TestCompile_OpRegexRejectsOversizedPattern(t *testing.T) {
+	limits := types.DefaultLimits()
+	limits.MaxRegexSize = 4
+	ctx := types.WithLimits(context.Background(), limits)
+
+	_, err := Compile(ctx, patternCondition(OpRegex, `abcdefgh`))
+	if err != types.ErrPatternTooLarge {
+		t.Errorf("Compile() error = %v, want ErrPatternTooLarge", err)
+	}
+}
+
+func TestCompile_OpGlobPrecompilesPattern(t *testing.T) {
+	compiled, err := Compile(context.Background(), patternCondition(OpGlob, "/api/*.json"))
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+	if _, ok := compiled.OrGroups[0].Conditions[0].Value.(*globPattern); !ok {
+		t.Errorf("Conditions[0].Value = %T, want *globPattern", compiled.OrGroups[0].Conditions[0].Value)
+	}
+}
+
+func TestCompile_OpCIDRPrecompilesNetwork(t *testing.T) {
+	compiled, err := Compile(context.Background(), patternCondition(OpCIDR, "10.0.0.0/8"))
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+	if _, ok := compiled.OrGroups[0].Conditions[0].Value.(*net.IPNet); !ok {
+		t.Errorf("Conditions[0].Value = %T, want *net.IPNet", compiled.OrGroups[0].Conditions[0].Value)
+	}
+}
+
+func TestCompile_OpCIDRRejectsInvalidNetwork(t *testing.T) {
+	_, err := Compile(context.Background(), patternCondition(OpCIDR, "not-a-cidr"))
+	if !errors.Is(err, types.ErrInvalidOperator) {
+		t.Errorf("Compile() error = %v, want wrapping ErrInvalidOperator", err)
+	}
+}
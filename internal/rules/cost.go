@@ -37,6 +37,14 @@ const (
 	CostIn     = 8
 	CostPrefix = 10
 	CostSuffix = 10
+	CostCIDR   = 12 // net.ParseIP + IPNet.Contains per event, compiled IPNet reused across events
+	CostGlob   = 20 // compiled to a regexp (see compileGlob); same cost as OpRegex
+	CostRegex  = 20
+
+	// ANY_OF/ALL_OF/COUNT/NONE_OF walk every wildcard match via ResolveAll
+	// rather than short-circuiting on the first, so they cost more than a
+	// plain wildcard comparison of the same operator.
+	CostQuantifier = 20
 
 	// Field lookup cost per string component
 	CostLookupPerSegment = 128
@@ -48,6 +56,18 @@ const (
 	MultiplierString = 48
 	MultiplierAny    = 128
 
+	// MultiplierDuration is as cheap as float: time.Duration comparison is
+	// a plain int64 compare once coerced, and time.ParseDuration is no
+	// pricier than strconv.ParseFloat.
+	MultiplierDuration = 4
+	// MultiplierTimestamp costs more than Duration: time.Parse(RFC3339)
+	// does more work than ParseDuration, and *timestamppb.Timestamp/
+	// unix-epoch inputs still pay a time.Time allocation.
+	MultiplierTimestamp = 8
+	// MultiplierIP costs the same as Timestamp: netip.ParseAddr/ParsePrefix
+	// plus a possible Prefix.Contains containment check for CIDR targets.
+	MultiplierIP = 8
+
 	// Base priority offset
 	BasePriority = 1000
 )
@@ -55,12 +75,28 @@ const (
 // CalculateConditionCost computes cost for a single condition.
 // cost = lookup_cost + (operator_cost * field_type_multiplier * 8^wildcards)
 func CalculateConditionCost(path []types.PathSegment, op Operator, fieldType FieldType) int {
-	lookupCost := 0
+	return conditionCostBreakdown(path, op, fieldType).Total
+}
+
+// ConditionCostBreakdown decomposes CalculateConditionCost's single int
+// into the four factors that produced it, so a debugging surface (see
+// internal/rules/explain.go) can show why a condition costs what it does
+// instead of just the final number.
+type ConditionCostBreakdown struct {
+	LookupCost       int
+	OpCost           int
+	TypeMultiplier   int
+	WildcardExecMult int // 8^n for n wildcards in the condition's path
+	Total            int
+}
+
+// conditionCostBreakdown is CalculateConditionCost's body, factored out so
+// ExplainRule (see internal/rules/explain.go) can report the breakdown
+// without recomputing it against a second copy of this arithmetic.
+func conditionCostBreakdown(path []types.PathSegment, op Operator, fieldType FieldType) ConditionCostBreakdown {
+	lookupCost := pathLookupCost(path)
 	wildcardCount := 0
 	for _, seg := range path {
-		if seg.Key != "" {
-			lookupCost += CostLookupPerSegment
-		}
 		if seg.Wildcard {
 			wildcardCount++
 		}
@@ -75,7 +111,27 @@ func CalculateConditionCost(path []types.PathSegment, op Operator, fieldType Fie
 		execMult *= 8
 	}
 
-	return lookupCost + (opCost * typeMult * execMult)
+	return ConditionCostBreakdown{
+		LookupCost:       lookupCost,
+		OpCost:           opCost,
+		TypeMultiplier:   typeMult,
+		WildcardExecMult: execMult,
+		Total:            lookupCost + (opCost * typeMult * execMult),
+	}
+}
+
+// pathLookupCost returns the portion of CalculateConditionCost attributable
+// to traversing path itself, independent of operator/field type/wildcard
+// fan-out. CompileSet (planner.go) amortizes this cost once per shared
+// fieldSlot instead of once per condition.
+func pathLookupCost(path []types.PathSegment) int {
+	cost := 0
+	for _, seg := range path {
+		if seg.Key != "" {
+			cost += CostLookupPerSegment
+		}
+	}
+	return cost
 }
 
 // operatorCost returns base cost for operator execution.
@@ -92,6 +148,14 @@ func operatorCost(op Operator) int {
 		return CostIn
 	case OpPrefix, OpSuffix:
 		return CostPrefix
+	case OpCIDR:
+		return CostCIDR
+	case OpGlob:
+		return CostGlob
+	case OpRegex:
+		return CostRegex
+	case OpAnyOf, OpAllOf, OpCount, OpNoneOf:
+		return CostQuantifier
 	default:
 		return CostEq
 	}
@@ -107,6 +171,12 @@ func typeMultiplier(ft FieldType) int {
 		return MultiplierBool
 	case FieldTypeText:
 		return MultiplierString
+	case FieldTypeDuration:
+		return MultiplierDuration
+	case FieldTypeTimestamp:
+		return MultiplierTimestamp
+	case FieldTypeIPAddr:
+		return MultiplierIP
 	case FieldTypeAny:
 		return MultiplierAny
 	default:
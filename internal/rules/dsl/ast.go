@@ -0,0 +1,135 @@
+// internal/rules/dsl/ast.go
+package dsl
+
+import (
+	"github.com/solatis/trapperkeeper/internal/rules"
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Boolean expression tree and DNF conversion.
+ *
+ * The parser builds a general AND/OR/NOT tree (boolExpr) over leaf
+ * conditions, matching how operators read naturally in source - e.g.
+ * "a AND (b OR c)". types.Rule only has room for DNF (OR of AND groups;
+ * see types.OrGroup), so toDNF pushes NOT down to the leaves via De
+ * Morgan's laws and then distributes AND over OR, same as a textbook CNF
+ * front-end feeding a DNF-only backend.
+ */
+
+// boolExpr is a node in the parser's AND/OR/NOT tree, prior to DNF
+// conversion.
+type boolExpr interface {
+	isBoolExpr()
+}
+
+type andExpr struct{ left, right boolExpr }
+type orExpr struct{ left, right boolExpr }
+type notExpr struct{ operand boolExpr }
+
+// conditionExpr is a leaf condition, already shaped as a types.Condition
+// except for the fields DNF grouping doesn't touch (OrGroups membership).
+type conditionExpr struct {
+	cond types.Condition
+}
+
+func (*andExpr) isBoolExpr()       {}
+func (*orExpr) isBoolExpr()        {}
+func (*notExpr) isBoolExpr()       {}
+func (*conditionExpr) isBoolExpr() {}
+
+// pushNegation eliminates notExpr nodes by pushing negation down to leaves
+// (De Morgan's laws), returning a *ParseError if a leaf's operator has no
+// negated form the Condition model can represent (see negateCondition).
+func pushNegation(e boolExpr, negate bool) (boolExpr, error) {
+	switch v := e.(type) {
+	case *andExpr:
+		l, err := pushNegation(v.left, negate)
+		if err != nil {
+			return nil, err
+		}
+		r, err := pushNegation(v.right, negate)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			return &orExpr{left: l, right: r}, nil
+		}
+		return &andExpr{left: l, right: r}, nil
+	case *orExpr:
+		l, err := pushNegation(v.left, negate)
+		if err != nil {
+			return nil, err
+		}
+		r, err := pushNegation(v.right, negate)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			return &andExpr{left: l, right: r}, nil
+		}
+		return &orExpr{left: l, right: r}, nil
+	case *notExpr:
+		return pushNegation(v.operand, !negate)
+	case *conditionExpr:
+		if !negate {
+			return v, nil
+		}
+		return negateCondition(v)
+	default:
+		return nil, &ParseError{Msg: "unreachable: unknown boolExpr node"}
+	}
+}
+
+// negateCondition inverts a leaf condition's operator where the Operator
+// enum has a direct complement. EXISTS/IN/PREFIX/SUFFIX have no such
+// complement in this tree (there is no "not exists"/"not in" operator), so
+// NOT over one of those is a parse error rather than a silent
+// approximation.
+func negateCondition(c *conditionExpr) (*conditionExpr, error) {
+	negated := c.cond
+	switch rules.Operator(c.cond.Operator) {
+	case rules.OpEq:
+		negated.Operator = int(rules.OpNeq)
+	case rules.OpNeq:
+		negated.Operator = int(rules.OpEq)
+	case rules.OpLt:
+		negated.Operator = int(rules.OpGte)
+	case rules.OpLte:
+		negated.Operator = int(rules.OpGt)
+	case rules.OpGt:
+		negated.Operator = int(rules.OpLte)
+	case rules.OpGte:
+		negated.Operator = int(rules.OpLt)
+	default:
+		return nil, &ParseError{Msg: "NOT is not supported for this operator"}
+	}
+	return &conditionExpr{cond: negated}, nil
+}
+
+// toDNF distributes AND over OR to expand e into an OR of AND-groups,
+// matching types.Rule.OrGroups's shape. Must be called after pushNegation
+// has already eliminated notExpr nodes.
+func toDNF(e boolExpr) [][]types.Condition {
+	switch v := e.(type) {
+	case *conditionExpr:
+		return [][]types.Condition{{v.cond}}
+	case *orExpr:
+		return append(toDNF(v.left), toDNF(v.right)...)
+	case *andExpr:
+		left := toDNF(v.left)
+		right := toDNF(v.right)
+		groups := make([][]types.Condition, 0, len(left)*len(right))
+		for _, lg := range left {
+			for _, rg := range right {
+				group := make([]types.Condition, 0, len(lg)+len(rg))
+				group = append(group, lg...)
+				group = append(group, rg...)
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+// internal/rules/dsl/dsl.go
+package dsl
+
+import (
+	"fmt"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Text DSL front-end for authoring rules.
+ *
+ * Parse turns a textual rule expression, e.g.:
+ *
+ *   user EXISTS AND (status == "active" OR priority > 5)
+ *     AND orders[*].items[*].price > 100 SAMPLE 0.5 OBSERVE
+ *
+ * into a *types.Rule that flows through rules.Compile() exactly like a
+ * hand-built struct literal, so every compile-time invariant Compile
+ * already enforces (MaxPathDepth, MaxInOperatorValues, ErrWildcardInFieldRef,
+ * ...) continues to apply to DSL-authored rules. The one check Parse
+ * also makes itself is rejecting a wildcard inside FIELD(...), reported
+ * with its line/column. Parse performs no resource-limit validation - that is
+ * Compile's job per the resource-limit architecture in limits.go.
+ *
+ * Parse assigns a fresh types.NewRuleID() to every parsed rule (the DSL has
+ * no syntax for naming rules); callers that need a caller-chosen RuleID or
+ * Name should set those fields on the returned *types.Rule before passing
+ * it to Compile.
+ */
+
+// ParseError reports a DSL syntax error with its source position so editors
+// and CLI tooling can point at the offending token.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 && e.Col == 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Parse parses src as a rule DSL expression and returns the equivalent
+// *types.Rule, ready for rules.Compile(ctx, rule). Returns a *ParseError
+// with Line/Col set to the offending token's position for malformed input.
+func Parse(src string) (*types.Rule, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+	return p.parseRule()
+}
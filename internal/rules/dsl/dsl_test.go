@@ -0,0 +1,195 @@
+// internal/rules/dsl/dsl_test.go
+package dsl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/rules"
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+// handBuiltExampleRule is the struct-literal equivalent of the example DSL
+// expression from the package doc comment, built the way callers construct
+// rules today (see rules.TestCompile_MultiGroupDNF for the same style).
+func handBuiltExampleRule(ruleID types.RuleID) *types.Rule {
+	return &types.Rule{
+		RuleID:     ruleID,
+		SampleRate: 0.5,
+		Action:     int(rules.ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{FieldPath: []types.PathSegment{{Key: "user"}}, Operator: int(rules.OpExists)},
+					{FieldPath: []types.PathSegment{{Key: "status"}}, Operator: int(rules.OpEq), FieldType: int(rules.FieldTypeText), Value: "active"},
+					{FieldPath: []types.PathSegment{{Key: "orders"}, {Wildcard: true}, {Key: "items"}, {Wildcard: true}, {Key: "price"}}, Operator: int(rules.OpGt), FieldType: int(rules.FieldTypeNumeric), Value: 100.0},
+				},
+			},
+			{
+				Conditions: []types.Condition{
+					{FieldPath: []types.PathSegment{{Key: "user"}}, Operator: int(rules.OpExists)},
+					{FieldPath: []types.PathSegment{{Key: "priority"}}, Operator: int(rules.OpGt), FieldType: int(rules.FieldTypeNumeric), Value: 5.0},
+					{FieldPath: []types.PathSegment{{Key: "orders"}, {Wildcard: true}, {Key: "items"}, {Wildcard: true}, {Key: "price"}}, Operator: int(rules.OpGt), FieldType: int(rules.FieldTypeNumeric), Value: 100.0},
+				},
+			},
+		},
+	}
+}
+
+func TestParse_ExampleExpressionRoundTripsCompile(t *testing.T) {
+	src := `user EXISTS AND (status == "active" OR priority > 5) AND orders[*].items[*].price > 100 SAMPLE 0.5 OBSERVE`
+
+	parsed, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+
+	handBuilt := handBuiltExampleRule(parsed.RuleID) // Parse assigns its own fresh RuleID
+
+	gotCompiled, err := rules.Compile(context.Background(), parsed)
+	if err != nil {
+		t.Fatalf("Compile(Parse(...)) error = %v, want nil", err)
+	}
+	wantCompiled, err := rules.Compile(context.Background(), handBuilt)
+	if err != nil {
+		t.Fatalf("Compile(handBuilt) error = %v, want nil", err)
+	}
+
+	if gotCompiled.Priority != wantCompiled.Priority {
+		t.Errorf("Priority = %v, want %v", gotCompiled.Priority, wantCompiled.Priority)
+	}
+	if gotCompiled.SampleRate != wantCompiled.SampleRate {
+		t.Errorf("SampleRate = %v, want %v", gotCompiled.SampleRate, wantCompiled.SampleRate)
+	}
+	if gotCompiled.Action != wantCompiled.Action {
+		t.Errorf("Action = %v, want %v", gotCompiled.Action, wantCompiled.Action)
+	}
+	if len(gotCompiled.OrGroups) != len(wantCompiled.OrGroups) {
+		t.Fatalf("len(OrGroups) = %v, want %v", len(gotCompiled.OrGroups), len(wantCompiled.OrGroups))
+	}
+	for i := range gotCompiled.OrGroups {
+		gotConds := gotCompiled.OrGroups[i].Conditions
+		wantConds := wantCompiled.OrGroups[i].Conditions
+		if len(gotConds) != len(wantConds) {
+			t.Fatalf("OrGroups[%d]: len(Conditions) = %v, want %v", i, len(gotConds), len(wantConds))
+		}
+		for j := range gotConds {
+			// Conditions within a group are cost-ordered, so the same
+			// position in both slices must describe the same comparison.
+			if gotConds[j].Operator != wantConds[j].Operator {
+				t.Errorf("OrGroups[%d].Conditions[%d].Operator = %v, want %v", i, j, gotConds[j].Operator, wantConds[j].Operator)
+			}
+			if gotConds[j].Cost != wantConds[j].Cost {
+				t.Errorf("OrGroups[%d].Conditions[%d].Cost = %v, want %v", i, j, gotConds[j].Cost, wantConds[j].Cost)
+			}
+		}
+	}
+}
+
+func TestParse_SimpleComparison(t *testing.T) {
+	parsed, err := Parse(`status == "active"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+	if parsed.SampleRate != 1.0 {
+		t.Errorf("SampleRate = %v, want 1.0 (default)", parsed.SampleRate)
+	}
+	if parsed.Action != int(rules.ActionObserve) {
+		t.Errorf("Action = %v, want ActionObserve (default)", parsed.Action)
+	}
+	if len(parsed.OrGroups) != 1 || len(parsed.OrGroups[0].Conditions) != 1 {
+		t.Fatalf("unexpected OrGroups shape: %+v", parsed.OrGroups)
+	}
+	cond := parsed.OrGroups[0].Conditions[0]
+	if rules.Operator(cond.Operator) != rules.OpEq || cond.Value != "active" {
+		t.Errorf("condition = %+v, want status == \"active\"", cond)
+	}
+}
+
+func TestParse_InOperator(t *testing.T) {
+	parsed, err := Parse(`priority IN (1, 2, 3) DROP`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+	cond := parsed.OrGroups[0].Conditions[0]
+	if rules.Operator(cond.Operator) != rules.OpIn {
+		t.Fatalf("Operator = %v, want OpIn", cond.Operator)
+	}
+	if len(cond.Values) != 3 {
+		t.Fatalf("len(Values) = %v, want 3", len(cond.Values))
+	}
+	if parsed.Action != int(rules.ActionDrop) {
+		t.Errorf("Action = %v, want ActionDrop", parsed.Action)
+	}
+}
+
+func TestParse_FieldToFieldComparison(t *testing.T) {
+	parsed, err := Parse(`shipping.total == FIELD(billing.total)`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+	cond := parsed.OrGroups[0].Conditions[0]
+	if cond.Value != nil {
+		t.Errorf("Value = %v, want nil for a FIELD(...) comparison", cond.Value)
+	}
+	if len(cond.FieldRef) != 2 {
+		t.Fatalf("FieldRef = %+v, want 2 segments", cond.FieldRef)
+	}
+}
+
+func TestParse_FieldRefRejectsWildcards(t *testing.T) {
+	_, err := Parse(`total == FIELD(items[*].price)`)
+	if err == nil {
+		t.Fatalf("Parse() error = nil, want an error for a wildcard inside FIELD(...)")
+	}
+}
+
+func TestParse_NotNegatesComparison(t *testing.T) {
+	parsed, err := Parse(`NOT status == "active"`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+	cond := parsed.OrGroups[0].Conditions[0]
+	if rules.Operator(cond.Operator) != rules.OpNeq {
+		t.Errorf("Operator = %v, want OpNeq (NOT pushed into ==)", cond.Operator)
+	}
+}
+
+func TestParse_NotExistsIsRejected(t *testing.T) {
+	_, err := Parse(`NOT user EXISTS`)
+	if err == nil {
+		t.Fatalf("Parse() error = nil, want an error: EXISTS has no negated Operator")
+	}
+}
+
+func TestParse_ReportsLineAndColumn(t *testing.T) {
+	_, err := Parse("status ===\n\"active\"")
+	if err == nil {
+		t.Fatalf("Parse() error = nil, want a ParseError")
+	}
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("error type = %T, want *ParseError", err)
+	}
+	if perr.Line != 1 {
+		t.Errorf("Line = %v, want 1", perr.Line)
+	}
+}
+
+func TestParse_CompilesThroughLimits(t *testing.T) {
+	// A DSL-authored rule is subject to the same resource limits as a
+	// hand-built one - Parse performs no resource-limit validation of its own.
+	parsed, err := Parse(`priority IN (1, 2, 3) OBSERVE`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v, want nil", err)
+	}
+
+	limits := types.DefaultLimits()
+	limits.MaxInOperatorValues = 2
+	ctx := types.WithLimits(context.Background(), limits)
+
+	_, err = rules.Compile(ctx, parsed)
+	if err != types.ErrTooManyInValues {
+		t.Errorf("Compile() with tightened MaxInOperatorValues error = %v, want ErrTooManyInValues", err)
+	}
+}
@@ -0,0 +1,238 @@
+// internal/rules/dsl/lexer.go
+package dsl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tokenKind identifies the lexical category of a token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPath
+	tokNumber
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokEq
+	tokNeq
+	tokLt
+	tokLte
+	tokGt
+	tokGte
+	tokAnd
+	tokOr
+	tokNot
+	tokExists
+	tokIn
+	tokPrefix
+	tokSuffix
+	tokField
+	tokSample
+	tokTrue
+	tokFalse
+	tokNull
+	tokObserve
+	tokDrop
+	tokFail
+)
+
+// keywords maps the DSL's reserved words (the upper-case keywords plus the
+// lower-case literals true/false/null) to their token kind. Anything else starting with a letter/underscore lexes as tokPath -
+// see lexPath.
+var keywords = map[string]tokenKind{
+	"AND":     tokAnd,
+	"OR":      tokOr,
+	"NOT":     tokNot,
+	"EXISTS":  tokExists,
+	"IN":      tokIn,
+	"PREFIX":  tokPrefix,
+	"SUFFIX":  tokSuffix,
+	"FIELD":   tokField,
+	"SAMPLE":  tokSample,
+	"true":    tokTrue,
+	"false":   tokFalse,
+	"null":    tokNull,
+	"OBSERVE": tokObserve,
+	"DROP":    tokDrop,
+	"FAIL":    tokFail,
+}
+
+// token is one lexical unit, with its source position for ParseError.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	col  int
+}
+
+// lexer tokenizes DSL source for parser's recursive descent.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, col: 1}
+}
+
+// next returns the next token, advancing past it. Returns tokEOF at end of
+// input and a *ParseError for malformed input (unterminated string,
+// unrecognized character).
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line, col: l.col}, nil
+	}
+
+	startLine, startCol := l.line, l.col
+	c := l.src[l.pos]
+
+	switch {
+	case c == '(':
+		l.advance()
+		return token{kind: tokLParen, text: "(", line: startLine, col: startCol}, nil
+	case c == ')':
+		l.advance()
+		return token{kind: tokRParen, text: ")", line: startLine, col: startCol}, nil
+	case c == ',':
+		l.advance()
+		return token{kind: tokComma, text: ",", line: startLine, col: startCol}, nil
+	case c == '"':
+		return l.lexString(startLine, startCol)
+	case c == '=' && l.peek(1) == '=':
+		l.advance()
+		l.advance()
+		return token{kind: tokEq, text: "==", line: startLine, col: startCol}, nil
+	case c == '!' && l.peek(1) == '=':
+		l.advance()
+		l.advance()
+		return token{kind: tokNeq, text: "!=", line: startLine, col: startCol}, nil
+	case c == '>' && l.peek(1) == '=':
+		l.advance()
+		l.advance()
+		return token{kind: tokGte, text: ">=", line: startLine, col: startCol}, nil
+	case c == '<' && l.peek(1) == '=':
+		l.advance()
+		l.advance()
+		return token{kind: tokLte, text: "<=", line: startLine, col: startCol}, nil
+	case c == '>':
+		l.advance()
+		return token{kind: tokGt, text: ">", line: startLine, col: startCol}, nil
+	case c == '<':
+		l.advance()
+		return token{kind: tokLt, text: "<", line: startLine, col: startCol}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return l.lexNumber(startLine, startCol), nil
+	case isPathStart(c):
+		return l.lexPath(startLine, startCol), nil
+	default:
+		return token{}, &ParseError{Line: startLine, Col: startCol, Msg: fmt.Sprintf("unexpected character %q", c)}
+	}
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return
+		}
+		l.advance()
+	}
+}
+
+func (l *lexer) advance() {
+	if l.src[l.pos] == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	l.pos++
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *lexer) lexString(line, col int) (token, error) {
+	l.advance() // opening '"'
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return token{}, &ParseError{Line: line, Col: col, Msg: "unterminated string literal"}
+		}
+		c := l.src[l.pos]
+		if c == '"' {
+			l.advance()
+			return token{kind: tokString, text: b.String(), line: line, col: col}, nil
+		}
+		if c == '\\' && l.pos+1 < len(l.src) {
+			l.advance()
+			b.WriteByte(l.src[l.pos])
+			l.advance()
+			continue
+		}
+		b.WriteByte(c)
+		l.advance()
+	}
+}
+
+func (l *lexer) lexNumber(line, col int) token {
+	start := l.pos
+	if l.src[l.pos] == '-' {
+		l.advance()
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance()
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.advance()
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.advance()
+		}
+	}
+	return token{kind: tokNumber, text: l.src[start:l.pos], line: line, col: col}
+}
+
+// lexPath reads a run of identifier/path characters and classifies it as a
+// keyword or, failing that, a bare field path (user, status,
+// orders[*].items[*].price).
+func (l *lexer) lexPath(line, col int) token {
+	start := l.pos
+	for l.pos < len(l.src) && isPathCont(l.src[l.pos]) {
+		l.advance()
+	}
+	text := l.src[start:l.pos]
+	if kind, ok := keywords[text]; ok {
+		return token{kind: kind, text: text, line: line, col: col}
+	}
+	return token{kind: tokPath, text: text, line: line, col: col}
+}
+
+func isPathStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isPathCont(c byte) bool {
+	switch {
+	case isPathStart(c), isDigit(c):
+		return true
+	case c == '.' || c == '[' || c == ']' || c == '*' || c == '"' || c == '-':
+		return true
+	default:
+		return false
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,409 @@
+// internal/rules/dsl/parser.go
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/solatis/trapperkeeper/internal/rules"
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Recursive-descent parser for the rule DSL (see grammar.g4).
+ *
+ * Generated ANTLR4 parsers weren't an option in this tree: no ANTLR
+ * toolchain, no network access to fetch the ANTLR jar, and no go.mod to
+ * vendor github.com/antlr4-go/antlr/v4 against. grammar.g4 still documents
+ * the grammar precisely enough to regenerate a parser from it once those
+ * are available; until then this file and lexer.go hand-implement the same
+ * grammar, in the same recursive-descent style already used by
+ * types.ParsePath and types.parseFilterExpr elsewhere in this codebase.
+ *
+ * Path segments reuse types.ParsePath directly (prefixing a bare PATH
+ * token with "." so it matches ParsePath's expected leading-dot-or-bracket
+ * form) rather than re-implementing JSONPath lexing a third time.
+ */
+
+// parser consumes tokens from a lexer via recursive descent, tracking one
+// token of lookahead.
+type parser struct {
+	lex  *lexer
+	tok  token
+	last error
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	if p.tok.kind != kind {
+		return token{}, p.errorf("expected %s", what)
+	}
+	tok := p.tok
+	if err := p.advance(); err != nil {
+		return token{}, err
+	}
+	return tok, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &ParseError{Line: p.tok.line, Col: p.tok.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// parseRule parses the full "orExpr (SAMPLE NUMBER)? action? EOF" grammar
+// and compiles the result to a *types.Rule.
+func (p *parser) parseRule() (*types.Rule, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate := 1.0
+	if p.tok.kind == tokSample {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		numTok, err := p.expect(tokNumber, "a number after SAMPLE")
+		if err != nil {
+			return nil, err
+		}
+		sampleRate, err = strconv.ParseFloat(numTok.text, 64)
+		if err != nil {
+			return nil, &ParseError{Line: numTok.line, Col: numTok.col, Msg: "invalid SAMPLE rate " + numTok.text}
+		}
+	}
+
+	action := int(rules.ActionObserve) // action keyword is optional; default to the safe, non-enforcing action
+	switch p.tok.kind {
+	case tokObserve:
+		action = int(rules.ActionObserve)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	case tokDrop:
+		action = int(rules.ActionDrop)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	case tokFail:
+		action = int(rules.ActionFail)
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf("unexpected input after rule expression")
+	}
+
+	noNot, err := pushNegation(expr, false)
+	if err != nil {
+		return nil, err
+	}
+	groups := toDNF(noNot)
+
+	rule := &types.Rule{
+		RuleID:     types.NewRuleID(),
+		SampleRate: sampleRate,
+		Action:     action,
+		OrGroups:   make([]types.OrGroup, 0, len(groups)),
+	}
+	for _, g := range groups {
+		rule.OrGroups = append(rule.OrGroups, types.OrGroup{Conditions: g})
+	}
+	return rule, nil
+}
+
+func (p *parser) parseOr() (boolExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokOr {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (boolExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.kind == tokAnd {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (boolExpr, error) {
+	if p.tok.kind == tokNot {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (boolExpr, error) {
+	if p.tok.kind == tokLParen {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')'"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return p.parseCondition()
+}
+
+// parseCondition parses one "PATH ..." leaf per the condition rule in
+// grammar.g4.
+func (p *parser) parseCondition() (boolExpr, error) {
+	pathTok, err := p.expect(tokPath, "a field path")
+	if err != nil {
+		return nil, err
+	}
+	path, err := parseDSLPath(pathTok)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.tok.kind {
+	case tokExists:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return &conditionExpr{cond: types.Condition{
+			FieldPath: path,
+			Operator:  int(rules.OpExists),
+		}}, nil
+
+	case tokIn:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokLParen, "'(' after IN"); err != nil {
+			return nil, err
+		}
+		values, fieldType, err := p.parseLiteralList()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, "')' to close IN list"); err != nil {
+			return nil, err
+		}
+		return &conditionExpr{cond: types.Condition{
+			FieldPath: path,
+			Operator:  int(rules.OpIn),
+			FieldType: int(fieldType),
+			Values:    values,
+		}}, nil
+
+	case tokPrefix, tokSuffix:
+		op := rules.OpPrefix
+		if p.tok.kind == tokSuffix {
+			op = rules.OpSuffix
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		value, _, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &conditionExpr{cond: types.Condition{
+			FieldPath: path,
+			Operator:  int(op),
+			FieldType: int(rules.FieldTypeText),
+			Value:     value,
+		}}, nil
+
+	default:
+		op, ok := compareOpFor(p.tok.kind)
+		if !ok {
+			return nil, p.errorf("expected EXISTS, IN, PREFIX, SUFFIX, or a comparison operator")
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokField {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokLParen, "'(' after FIELD"); err != nil {
+				return nil, err
+			}
+			refTok, err := p.expect(tokPath, "a field path inside FIELD(...)")
+			if err != nil {
+				return nil, err
+			}
+			ref, err := parseDSLPath(refTok)
+			if err != nil {
+				return nil, err
+			}
+			for _, seg := range ref {
+				if seg.Wildcard {
+					return nil, &ParseError{Line: refTok.line, Col: refTok.col, Msg: "FIELD(...) path cannot contain wildcards"}
+				}
+			}
+			if _, err := p.expect(tokRParen, "')' to close FIELD(...)"); err != nil {
+				return nil, err
+			}
+			return &conditionExpr{cond: types.Condition{
+				FieldPath: path,
+				FieldRef:  ref,
+				Operator:  int(op),
+				FieldType: int(rules.FieldTypeAny),
+			}}, nil
+		}
+
+		value, fieldType, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &conditionExpr{cond: types.Condition{
+			FieldPath: path,
+			Operator:  int(op),
+			FieldType: int(fieldType),
+			Value:     value,
+		}}, nil
+	}
+}
+
+// parseLiteralList parses a comma-separated literal list for IN(...),
+// inferring FieldType from the first literal (IN values are expected to
+// share a type).
+func (p *parser) parseLiteralList() ([]any, rules.FieldType, error) {
+	first, fieldType, err := p.parseLiteral()
+	if err != nil {
+		return nil, rules.FieldTypeUnspecified, err
+	}
+	values := []any{first}
+	for p.tok.kind == tokComma {
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		v, _, err := p.parseLiteral()
+		if err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		values = append(values, v)
+	}
+	return values, fieldType, nil
+}
+
+// parseLiteral parses a single NUMBER/STRING/true/false/null literal,
+// inferring the Condition's FieldType from its lexical form.
+func (p *parser) parseLiteral() (any, rules.FieldType, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokNumber:
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, rules.FieldTypeUnspecified, &ParseError{Line: tok.line, Col: tok.col, Msg: "invalid number " + tok.text}
+		}
+		return f, rules.FieldTypeNumeric, nil
+	case tokString:
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		return tok.text, rules.FieldTypeText, nil
+	case tokTrue:
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		return true, rules.FieldTypeBoolean, nil
+	case tokFalse:
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		return false, rules.FieldTypeBoolean, nil
+	case tokNull:
+		if err := p.advance(); err != nil {
+			return nil, rules.FieldTypeUnspecified, err
+		}
+		return nil, rules.FieldTypeAny, nil
+	default:
+		return nil, rules.FieldTypeUnspecified, p.errorf("expected a literal value")
+	}
+}
+
+// compareOpFor maps a comparison token to its Operator enum value.
+func compareOpFor(kind tokenKind) (rules.Operator, bool) {
+	switch kind {
+	case tokEq:
+		return rules.OpEq, true
+	case tokNeq:
+		return rules.OpNeq, true
+	case tokLt:
+		return rules.OpLt, true
+	case tokLte:
+		return rules.OpLte, true
+	case tokGt:
+		return rules.OpGt, true
+	case tokGte:
+		return rules.OpGte, true
+	default:
+		return rules.OpUnspecified, false
+	}
+}
+
+// parseDSLPath turns a raw PATH token (e.g. "orders[*].items[*].price" or
+// "user", with no leading '$' or '.') into []types.PathSegment via
+// types.ParsePath, which expects a leading '.' or '[' after the optional
+// '$'.
+func parseDSLPath(tok token) ([]types.PathSegment, error) {
+	expr := tok.text
+	if !strings.HasPrefix(expr, "[") {
+		expr = "." + expr
+	}
+	segs, err := types.ParsePath(expr)
+	if err != nil {
+		return nil, &ParseError{Line: tok.line, Col: tok.col, Msg: err.Error()}
+	}
+	return segs, nil
+}
@@ -1,10 +1,46 @@
 package rules
 
+import (
+	"context"
+	"sync"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+// DefaultReorderEvery is how many evaluations an adaptive-reordering
+// CompiledOrGroup accumulates between re-sorts when Engine.ReorderEvery is
+// left at zero - see adaptive.go.
+const DefaultReorderEvery = 1000
+
 // Engine provides dependency injection interface for service.
-// No-op stub for service dependency injection. Provides no evaluation methods.
-type Engine struct{}
+// DefaultLimits is the resource-limit budget this engine installs into
+// context via WithLimits. AdaptiveReordering/ReorderEvery gate the
+// runtime-statistics-based condition reordering documented in adaptive.go;
+// with AdaptiveReordering false (the default), Engine.Evaluate is a
+// pass-through to the package-level Evaluate and behaves exactly as the
+// static, cost-model-only ordering from Compile always has.
+type Engine struct {
+	DefaultLimits types.Limits
+
+	AdaptiveReordering bool
+	ReorderEvery       uint64
 
-// NewEngine creates a new rules engine instance.
+	mu    sync.RWMutex
+	rules map[types.RuleID]*adaptiveRule
+}
+
+// NewEngine creates a new rules engine instance with today's default
+// limits and adaptive reordering off.
 func NewEngine() *Engine {
-	return &Engine{}
+	return &Engine{
+		DefaultLimits: types.DefaultLimits(),
+		rules:         make(map[types.RuleID]*adaptiveRule),
+	}
+}
+
+// WithLimits returns a copy of ctx carrying e.DefaultLimits, so services can
+// install a tenant-specific budget once at a request boundary instead of
+// threading limit overrides through every Resolve/ResolveAll/Compile call.
+func (e *Engine) WithLimits(ctx context.Context) context.Context {
+	return types.WithLimits(ctx, e.DefaultLimits)
 }
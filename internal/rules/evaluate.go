@@ -2,9 +2,15 @@
 package rules
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"regexp"
 
 	"github.com/solatis/trapperkeeper/internal/types"
 )
@@ -20,6 +26,9 @@ import (
  *   2. OR groups evaluation (short-circuit on first match)
  *   3. AND conditions evaluation (short-circuit on first non-match, cost-ordered)
  *   4. Per-condition: resolve path -> coerce type -> compare operator
+ *      (OpRegex/OpGlob/OpCIDR short-circuit straight to matchCompiledPattern,
+ *      dispatching on the artifact Compile already produced instead of
+ *      routing through Compare's operator switch)
  *   5. Apply on_missing_field and on_coercion_fail policies
  *   6. Record matched_field, matched_value, matched_condition for diagnostics
  *
@@ -28,9 +37,15 @@ import (
  *   - Coercion failure: defers to on_coercion_fail (skip/match/error)
  *   - Decision Log: null vs coercion failure use separate policies
  *
- * Sample rate implementation: crypto/rand provides secure randomness for
- * consistent sampling. Rate 0.0 never evaluates (fast-path), 1.0 always
- * evaluates (no RNG call), intermediate values use RNG with fail-safe on error.
+ * Sample rate implementation: shouldSampleRule dispatches on SamplingStrategy.
+ * SamplingRandom (default) uses crypto/rand for secure randomness; rate 0.0
+ * never evaluates (fast-path), 1.0 always evaluates (no RNG call), intermediate
+ * values use RNG with fail-safe on error. SamplingDeterministic instead hashes
+ * (RuleID, event key) via deterministicSampleDecision, so the same event
+ * sampled twice - replay, A/B comparison, test suite - always lands on the
+ * same side of the threshold; the event key is the caller-supplied eventKey
+ * if non-empty, else rule.SampleKeyPath resolved from payload, else it falls
+ * back to SamplingRandom for that event.
  *
  * Short-circuit semantics: First matching OR group stops evaluation. Within
  * AND group, first non-matching condition stops group evaluation. Cost ordering
@@ -48,25 +63,40 @@ type MatchResult struct {
 	RuleName         string
 }
 
-// Evaluate checks if the rule matches the given payload.
-func Evaluate(rule *CompiledRule, payload json.RawMessage) (MatchResult, error) {
+// SamplingStrategy selects how a CompiledRule's SampleRate threshold is applied.
+type SamplingStrategy int
+
+const (
+	// SamplingRandom (the zero value) draws fresh randomness per event via
+	// shouldSample/crypto-rand - the same event can land on either side of
+	// the threshold on a replay.
+	SamplingRandom SamplingStrategy = iota
+
+	// SamplingDeterministic hashes (RuleID, event key) via
+	// deterministicSampleDecision instead, so replays, A/B rollout
+	// comparisons, and test suites get the same sampling decision for the
+	// same event every time.
+	SamplingDeterministic
+)
+
+// Evaluate checks if the rule matches the given payload, enforcing the
+// resource limits installed into ctx via types.WithLimits (types.DefaultLimits()
+// if ctx carries none). eventKey is the event identity SamplingDeterministic
+// hashes against; pass "" to let it fall back to rule.SampleKeyPath (resolved
+// from payload) and then to SamplingRandom if that also yields nothing.
+func Evaluate(ctx context.Context, rule *CompiledRule, payload json.RawMessage, eventKey string) (MatchResult, error) {
 	result := MatchResult{
 		RuleID:   rule.RuleID,
 		RuleName: rule.Name,
 		Action:   rule.Action,
 	}
 
-	if rule.SampleRate == 0.0 {
+	if !shouldSampleRule(ctx, rule, payload, eventKey) {
 		return result, nil
 	}
-	if rule.SampleRate < 1.0 {
-		if !shouldSample(rule.SampleRate) {
-			return result, nil
-		}
-	}
 
 	for groupIdx, group := range rule.OrGroups {
-		matched, field, value, err := evaluateGroup(group, payload)
+		matched, field, value, err := evaluateGroup(ctx, group, payload)
 		if err != nil {
 			return result, err
 		}
@@ -84,12 +114,12 @@ func Evaluate(rule *CompiledRule, payload json.RawMessage) (MatchResult, error)
 
 // evaluateGroup evaluates AND group (all conditions must match).
 // Short-circuits on first non-match. Returns matched field/value from first condition.
-func evaluateGroup(group CompiledOrGroup, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
+func evaluateGroup(ctx context.Context, group CompiledOrGroup, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
 	var firstField []types.PathSegment
 	var firstValue any
 
 	for i, cond := range group.Conditions {
-		matched, field, value, err := evaluateCondition(cond, payload)
+		matched, field, value, err := evaluateCondition(ctx, cond, payload)
 		if err != nil {
 			return false, nil, nil, err
 		}
@@ -108,8 +138,12 @@ func evaluateGroup(group CompiledOrGroup, payload json.RawMessage) (bool, []type
 // evaluateCondition evaluates a single condition against payload.
 // Orchestrates: resolve path -> coerce type -> compare operator.
 // Applies on_missing_field and on_coercion_fail policies.
-func evaluateCondition(cond CompiledCondition, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
-	resolved, err := Resolve(cond.Path, payload)
+func evaluateCondition(ctx context.Context, cond CompiledCondition, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
+	if cond.Operator == OpAnyOf || cond.Operator == OpAllOf || cond.Operator == OpCount || cond.Operator == OpNoneOf {
+		return evaluateQuantifiedCondition(ctx, cond, payload)
+	}
+
+	resolved, err := Resolve(ctx, cond.Path, payload)
 	if err != nil {
 		if err == types.ErrFieldNotFound {
 			return applyMissingPolicy(cond.OnMissing), nil, nil, nil
@@ -121,7 +155,8 @@ func evaluateCondition(cond CompiledCondition, payload json.RawMessage) (bool, [
 		return applyMissingPolicy(cond.OnMissing), nil, nil, nil
 	}
 
-	coerced, err := Coerce(resolved.Value, cond.FieldType)
+	coerceOpts := CoerceOptions{PreserveIntegers: cond.PreserveIntegers}
+	coerced, err := CoerceWithOptions(resolved.Value, cond.FieldType, coerceOpts)
 	if err != nil {
 		if err == types.ErrCoercionFailed {
 			return applyCoercionPolicy(cond.OnCoercion), resolved.ResolvedPath, resolved.Value, nil
@@ -135,11 +170,11 @@ func evaluateCondition(cond CompiledCondition, payload json.RawMessage) (bool, [
 
 	var target any
 	if len(cond.FieldRef) > 0 {
-		refResolved, err := Resolve(cond.FieldRef, payload)
+		refResolved, err := Resolve(ctx, cond.FieldRef, payload)
 		if err != nil || !refResolved.Found {
 			return applyMissingPolicy(cond.OnMissing), resolved.ResolvedPath, coerced.Value, nil
 		}
-		refCoerced, err := Coerce(refResolved.Value, cond.FieldType)
+		refCoerced, err := CoerceWithOptions(refResolved.Value, cond.FieldType, coerceOpts)
 		if err != nil || refCoerced.IsNull {
 			return applyMissingPolicy(cond.OnMissing), resolved.ResolvedPath, coerced.Value, nil
 		}
@@ -150,10 +185,45 @@ func evaluateCondition(cond CompiledCondition, payload json.RawMessage) (bool, [
 		target = cond.Value
 	}
 
+	// OpRegex/OpGlob/OpCIDR's fast path: target is already the artifact
+	// Compile produced (*regexp.Regexp/*globPattern/*net.IPNet), so its
+	// concrete type alone determines the comparison - dispatching on it
+	// directly skips Compare's operator switch entirely instead of routing
+	// through it a second time.
+	if matched, handled := matchCompiledPattern(coerced.Value, target); handled {
+		return matched, resolved.ResolvedPath, coerced.Value, nil
+	}
+
 	matched := Compare(cond.Operator, coerced.Value, target)
 	return matched, resolved.ResolvedPath, coerced.Value, nil
 }
 
+// matchCompiledPattern is the fast path for OpRegex/OpGlob/OpCIDR: once
+// compileCondition has replaced a condition's literal with its compiled
+// artifact, the artifact's own type already determines which comparison
+// applies, so there's no need to also switch on cond.Operator. handled
+// reports whether target was one of these artifacts at all - every other
+// operator's target falls through unhandled to Compare unchanged.
+func matchCompiledPattern(value, target any) (matched, handled bool) {
+	switch t := target.(type) {
+	case *regexp.Regexp:
+		s, ok := value.(string)
+		return ok && t.MatchString(s), true
+	case *globPattern:
+		s, ok := value.(string)
+		return ok && t.MatchString(s), true
+	case *net.IPNet:
+		s, ok := value.(string)
+		if !ok {
+			return false, true
+		}
+		ip := net.ParseIP(s)
+		return ip != nil && t.Contains(ip), true
+	default:
+		return false, false
+	}
+}
+
 // applyMissingPolicy converts OnMissingField policy to boolean match result.
 // SKIP/FAIL -> false, MATCH -> true. Used for null values and missing fields.
 func applyMissingPolicy(policy OnMissingField) bool {
@@ -189,3 +259,53 @@ func shouldSample(rate float64) bool {
 	f := float64(n) / float64(1<<64)
 	return f < rate
 }
+
+// shouldSampleRule applies rule's SampleRate, honoring its SamplingStrategy.
+// Shared by Evaluate and EvaluateSet so a rule samples identically whether
+// it's evaluated standalone or through the cross-rule planner/Network.
+func shouldSampleRule(ctx context.Context, rule *CompiledRule, payload json.RawMessage, eventKey string) bool {
+	if rule.SampleRate == 0.0 {
+		return false
+	}
+	if rule.SampleRate == 1.0 {
+		return true
+	}
+
+	if rule.SamplingStrategy == SamplingDeterministic {
+		key := eventKey
+		if key == "" {
+			key = resolveSampleKey(ctx, rule.SampleKeyPath, payload)
+		}
+		if key != "" {
+			return deterministicSampleDecision(rule.RuleID, key, rule.SampleRate)
+		}
+	}
+
+	return shouldSample(rule.SampleRate)
+}
+
+// resolveSampleKey resolves path from payload and stringifies it for
+// deterministicSampleDecision. Returns "" (letting the caller fall back to
+// SamplingRandom) if path is empty, unresolvable, or not configured.
+func resolveSampleKey(ctx context.Context, path []types.PathSegment, payload json.RawMessage) string {
+	if len(path) == 0 {
+		return ""
+	}
+	resolved, err := Resolve(ctx, path, payload)
+	if err != nil || !resolved.Found || resolved.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(resolved.Value)
+}
+
+// deterministicSampleDecision hashes ruleID and eventKey into a uniformly
+// distributed uint64 (sha256 truncated to its first 8 bytes) and compares
+// it against rate's threshold, so the same (ruleID, eventKey) pair always
+// produces the same sampling decision - across events in one process and
+// across process restarts, unlike shouldSample's crypto/rand draw.
+func deterministicSampleDecision(ruleID types.RuleID, eventKey string, rate float64) bool {
+	sum := sha256.Sum256([]byte(string(ruleID) + "|" + eventKey))
+	n := binary.LittleEndian.Uint64(sum[:8])
+	f := float64(n) / float64(math.MaxUint64)
+	return f < rate
+}
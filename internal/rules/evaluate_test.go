@@ -2,7 +2,10 @@
 package rules
 
 import (
+	"context"
 	"encoding/json"
+	"net"
+	"regexp"
 	"testing"
 
 	"github.com/solatis/trapperkeeper/internal/types"
@@ -30,13 +33,13 @@ func TestEvaluate_SimpleMatch(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active"}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -82,13 +85,13 @@ func TestEvaluate_MultiConditionAND(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active", "priority": 10}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -128,13 +131,13 @@ func TestEvaluate_MultiConditionAND_ShortCircuit(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active", "priority": 10}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -178,13 +181,13 @@ func TestEvaluate_MultiGroupOR(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active", "priority": 10}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -240,13 +243,13 @@ func TestEvaluate_MultiGroupOR_ShortCircuit(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active", "priority": 10}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -281,13 +284,13 @@ func TestEvaluate_SampleRateZero(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active"}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -319,13 +322,13 @@ func TestEvaluate_SampleRateOne(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"status": "active"}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -335,6 +338,91 @@ func TestEvaluate_SampleRateOne(t *testing.T) {
 	}
 }
 
+func TestEvaluate_DeterministicSamplingStableAcrossCalls(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:           "rule-009",
+		Name:             "sample-rate-deterministic",
+		SampleRate:       0.5,
+		SamplingStrategy: int(SamplingDeterministic),
+		Action:           int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "active",
+					},
+				},
+			},
+		},
+	}
+
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	payload := json.RawMessage(`{"status": "active"}`)
+
+	first, err := Evaluate(context.Background(), compiled, payload, "order-42")
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v, want nil", err)
+	}
+	for i := 0; i < 10; i++ {
+		result, err := Evaluate(context.Background(), compiled, payload, "order-42")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v, want nil", err)
+		}
+		if result.Matched != first.Matched {
+			t.Errorf("call %d: Matched = %v, want %v (same rule_id/event_key must always agree)", i, result.Matched, first.Matched)
+		}
+	}
+}
+
+func TestEvaluate_DeterministicSamplingFallsBackToSampleKeyPath(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:           "rule-010",
+		Name:             "sample-rate-deterministic-key-path",
+		SampleRate:       0.5,
+		SamplingStrategy: int(SamplingDeterministic),
+		SampleKeyPath:    []types.PathSegment{{Key: "event"}, {Key: "id"}},
+		Action:           int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "active",
+					},
+				},
+			},
+		},
+	}
+
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+
+	payload := json.RawMessage(`{"status": "active", "event": {"id": "evt-123"}}`)
+
+	first, err := Evaluate(context.Background(), compiled, payload, "")
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v, want nil", err)
+	}
+	second, err := Evaluate(context.Background(), compiled, payload, "")
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v, want nil", err)
+	}
+	if second.Matched != first.Matched {
+		t.Errorf("Matched = %v, want %v (SampleKeyPath resolves the same event.id both times)", second.Matched, first.Matched)
+	}
+}
+
 func TestEvaluate_EmptyPayload(t *testing.T) {
 	rule := &types.Rule{
 		RuleID:     "rule-008",
@@ -357,13 +445,13 @@ func TestEvaluate_EmptyPayload(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -403,13 +491,13 @@ func TestEvaluate_AllConditionsSkip(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -441,13 +529,13 @@ func TestEvaluate_OnMissingMatch(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -479,13 +567,13 @@ func TestEvaluate_CoercionFailureSkip(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"value": "not-a-number"}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -517,13 +605,13 @@ func TestEvaluate_CoercionFailureMatch(t *testing.T) {
 		},
 	}
 
-	compiled, err := Compile(rule)
+	compiled, err := Compile(context.Background(), rule)
 	if err != nil {
 		t.Fatalf("Compile() error = %v, want nil", err)
 	}
 
 	payload := json.RawMessage(`{"value": "not-a-number"}`)
-	result, err := Evaluate(compiled, payload)
+	result, err := Evaluate(context.Background(), compiled, payload, "")
 	if err != nil {
 		t.Fatalf("Evaluate() error = %v, want nil", err)
 	}
@@ -575,3 +663,107 @@ func TestEvaluate_AllOperators(t *testing.T) {
 		})
 	}
 }
+
+// TestEvaluate_PatternOperators exercises OpRegex/OpGlob/OpCIDR end-to-end
+// (Compile -> Evaluate), confirming Compile's precompiled artifact round-trips
+// through evaluateCondition's matchCompiledPattern fast path correctly.
+func TestEvaluate_PatternOperators(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Operator
+		pattern any
+		payload string
+		want    bool
+	}{
+		{"regex_match", OpRegex, `^user-\d+$`, `{"id": "user-42"}`, true},
+		{"regex_no_match", OpRegex, `^user-\d+$`, `{"id": "admin-42"}`, false},
+		{"glob_match", OpGlob, "/api/*.json", `{"id": "/api/v1/orders.json"}`, true},
+		{"glob_no_match", OpGlob, "/api/*.json", `{"id": "/api/v1/orders.xml"}`, false},
+		{"cidr_match", OpCIDR, "10.0.0.0/8", `{"id": "10.1.2.3"}`, true},
+		{"cidr_no_match", OpCIDR, "10.0.0.0/8", `{"id": "192.168.1.1"}`, false},
+		{"cidr_unparseable_ip", OpCIDR, "10.0.0.0/8", `{"id": "not-an-ip"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &types.Rule{
+				RuleID:     "rule-pattern",
+				SampleRate: 1.0,
+				Action:     int(ActionObserve),
+				OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+					{FieldPath: []types.PathSegment{{Key: "id"}}, Operator: int(tt.op), FieldType: int(FieldTypeText), Value: tt.pattern},
+				}}},
+			}
+			compiled, err := Compile(context.Background(), rule)
+			if err != nil {
+				t.Fatalf("Compile() error = %v, want nil", err)
+			}
+
+			result, err := Evaluate(context.Background(), compiled, json.RawMessage(tt.payload), "")
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v, want nil", err)
+			}
+			if result.Matched != tt.want {
+				t.Errorf("Matched = %v, want %v", result.Matched, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompare_PatternOperators exercises Compare directly against
+// precompiled artifacts, the form Compare expects for OpRegex/OpGlob/OpCIDR
+// (see the package doc comment).
+func TestCompare_PatternOperators(t *testing.T) {
+	re := regexp.MustCompile(`^\d+$`)
+	glob, err := compileGlob("*.txt")
+	if err != nil {
+		t.Fatalf("compileGlob() error = %v, want nil", err)
+	}
+	_, network, err := net.ParseCIDR("192.168.0.0/16")
+	if err != nil {
+		t.Fatalf("net.ParseCIDR() error = %v, want nil", err)
+	}
+
+	if !Compare(OpRegex, "12345", re) {
+		t.Error("Compare(OpRegex, \"12345\", re) = false, want true")
+	}
+	if Compare(OpRegex, "abc", re) {
+		t.Error("Compare(OpRegex, \"abc\", re) = true, want false")
+	}
+	if !Compare(OpGlob, "notes.txt", glob) {
+		t.Error("Compare(OpGlob, \"notes.txt\", glob) = false, want true")
+	}
+	if !Compare(OpCIDR, "192.168.1.1", network) {
+		t.Error("Compare(OpCIDR, \"192.168.1.1\", network) = false, want true")
+	}
+	if Compare(OpCIDR, "10.0.0.1", network) {
+		t.Error("Compare(OpCIDR, \"10.0.0.1\", network) = true, want false")
+	}
+}
+
+// BenchmarkShouldSampleRule_RandomVsDeterministic measures the throughput
+// difference between SamplingRandom's crypto/rand draw and
+// SamplingDeterministic's hash-and-compare, per solatis/trapperkeeper#chunk5-5's
+// claim that hashing avoids a syscall per evaluation.
+func BenchmarkShouldSampleRule_RandomVsDeterministic(b *testing.B) {
+	payload := json.RawMessage(`{"status": "active"}`)
+
+	randomRule := &CompiledRule{RuleID: "rule-bench", SampleRate: 0.5, SamplingStrategy: SamplingRandom}
+	deterministicRule := &CompiledRule{RuleID: "rule-bench", SampleRate: 0.5, SamplingStrategy: SamplingDeterministic}
+
+	b.Run("SamplingRandom", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			shouldSampleRule(ctx, randomRule, payload, "")
+		}
+	})
+
+	b.Run("SamplingDeterministic", func(b *testing.B) {
+		ctx := context.Background()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			shouldSampleRule(ctx, deterministicRule, payload, "order-42")
+		}
+	})
+}
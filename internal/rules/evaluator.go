@@ -0,0 +1,84 @@
+// internal/rules/evaluator.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/solatis/trapperkeeper/internal/observability"
+)
+
+// Evaluator wraps the package-level Evaluate with an audit/metrics hook,
+// emitting one AuditEvent and one rule_eval_total/rule_eval_duration_seconds
+// observation per call, so operators can answer "why did this event drop?"
+// after the fact. Engine remains the lighter-weight dependency-injection
+// stub for callers that don't need per-evaluation instrumentation.
+type Evaluator struct {
+	audit   observability.AuditSink
+	metrics *observability.Metrics
+}
+
+// NewEvaluator returns an Evaluator with no-op audit/metrics until
+// SetAuditSink/SetMetrics are called.
+func NewEvaluator() *Evaluator {
+	return &Evaluator{audit: observability.NoopSink{}}
+}
+
+// SetAuditSink wires an audit trail for every Evaluate call.
+func (e *Evaluator) SetAuditSink(sink observability.AuditSink) {
+	e.audit = sink
+}
+
+// SetMetrics wires Prometheus rule_eval_total/rule_eval_duration_seconds
+// recording.
+func (e *Evaluator) SetMetrics(m *observability.Metrics) {
+	e.metrics = m
+}
+
+// Evaluate wraps Evaluate, recording the outcome and latency before
+// returning the same (MatchResult, error) Evaluate would have.
+func (e *Evaluator) Evaluate(ctx context.Context, rule *CompiledRule, payload json.RawMessage, eventKey string) (MatchResult, error) {
+	start := time.Now()
+	result, err := Evaluate(ctx, rule, payload, eventKey)
+	dur := time.Since(start)
+
+	action := actionLabel(rule.Action)
+	e.audit.Emit(ctx, observability.AuditEvent{
+		Timestamp: time.Now(),
+		Type:      "rule_eval",
+		RuleID:    string(rule.RuleID),
+		Action:    action,
+		Matched:   result.Matched,
+		Result:    matchResultLabel(result.Matched),
+		Latency:   dur,
+	})
+	if e.metrics != nil {
+		e.metrics.RecordRuleEval(action, string(rule.RuleID), dur)
+	}
+
+	return result, err
+}
+
+// actionLabel renders Action for the Prometheus "action" label and audit
+// Action field - Action itself stays a plain int enum (mirroring the
+// protobuf definition), so this is the one place that needs a string form.
+func actionLabel(a Action) string {
+	switch a {
+	case ActionObserve:
+		return "observe"
+	case ActionDrop:
+		return "drop"
+	case ActionFail:
+		return "fail"
+	default:
+		return "unspecified"
+	}
+}
+
+func matchResultLabel(matched bool) string {
+	if matched {
+		return "matched"
+	}
+	return "no_match"
+}
@@ -0,0 +1,188 @@
+// internal/rules/explain.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Rule explain/dry-run surface.
+ *
+ * ExplainRule turns the cost model (cost.go) and evaluation order
+ * (compile.go's ascending-cost sort) into a structured, user-visible plan
+ * instead of leaving them as an internal-only side effect of Compile. When
+ * sampleEvent is supplied, it additionally runs the rule against it and
+ * attaches a per-condition trace, so a rule author can validate field_ref,
+ * wildcard limits, and on_missing_field/on_coercion_fail policies before
+ * deploying a rule, without needing a live event stream to test against.
+ *
+ * ExplainRule deliberately doesn't reuse Evaluate/evaluateGroup: those
+ * short-circuit as soon as the answer is known and discard per-condition
+ * detail along the way, which is exactly the detail this is for. It still
+ * preserves the same short-circuit *semantics* (a condition after the
+ * first non-match in its AND group, or in any OR group after the first
+ * group that matched, is reported ShortCircuited rather than evaluated) so
+ * the trace reflects what Evaluate would actually have done, not a
+ * from-scratch re-evaluation of every condition.
+ */
+
+// ExplainedCondition mirrors a CompiledCondition plus its cost breakdown
+// and, when ExplainRule was given a sample event, its evaluation trace.
+type ExplainedCondition struct {
+	Path      []types.PathSegment
+	Operator  Operator
+	FieldType FieldType
+	Cost      ConditionCostBreakdown
+
+	// Trace fields below are only meaningful when ExplainRule received a
+	// non-nil sampleEvent; otherwise they're left at their zero values.
+	Evaluated      bool
+	Matched        bool
+	ShortCircuited bool
+	CoercedValue   any
+	Policy         string // e.g. "on_missing_field:skip" - set only when that policy decided the outcome
+}
+
+// ExplainedOrGroup mirrors a CompiledOrGroup with per-condition explain detail.
+type ExplainedOrGroup struct {
+	Conditions []ExplainedCondition
+}
+
+// ExplainResult is ExplainRule's return value: CompiledRule's plan -
+// priority and its components, plus each OrGroup's cost-ordered
+// conditions - and, when a sample event was supplied, whether the rule
+// matched it overall.
+type ExplainResult struct {
+	RuleID        types.RuleID
+	Priority      int
+	OrPenalty     int
+	SamplePenalty int
+	OrGroups      []ExplainedOrGroup
+
+	// Matched is only set (and only meaningful) when ExplainRule was given
+	// a sample event.
+	Matched bool
+}
+
+// ExplainRule compiles rule the same way the engine would and returns its
+// plan: per-condition cost breakdown in cost-ordered position, and the
+// priority/OR-penalty/sample-penalty that ordering across rules depends
+// on. When sampleEvent is non-nil, it also evaluates rule against it and
+// fills in each condition's trace - see ExplainedCondition.
+func ExplainRule(ctx context.Context, rule *types.Rule, sampleEvent json.RawMessage) (*ExplainResult, error) {
+	compiled, err := Compile(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExplainResult{
+		RuleID:        compiled.RuleID,
+		Priority:      compiled.Priority,
+		OrPenalty:     len(compiled.OrGroups) * 10,
+		SamplePenalty: int((1.0 - compiled.SampleRate) * 50),
+		OrGroups:      make([]ExplainedOrGroup, len(compiled.OrGroups)),
+	}
+
+	ruleMatchedSoFar := false
+
+	for gi, group := range compiled.OrGroups {
+		eg := ExplainedOrGroup{Conditions: make([]ExplainedCondition, len(group.Conditions))}
+		// An OR group after the first that already matched is never
+		// evaluated by Evaluate - mirror that rather than tracing it too.
+		groupReachable := sampleEvent != nil && !ruleMatchedSoFar
+		andFailedSoFar := false
+
+		for ci, cond := range group.Conditions {
+			ec := ExplainedCondition{
+				Path:      cond.Path,
+				Operator:  cond.Operator,
+				FieldType: cond.FieldType,
+				Cost:      conditionCostBreakdown(cond.Path, cond.Operator, cond.FieldType),
+			}
+
+			switch {
+			case groupReachable && !andFailedSoFar:
+				matched, _, value, err := evaluateCondition(ctx, cond, sampleEvent)
+				if err != nil {
+					return nil, err
+				}
+				ec.Evaluated = true
+				ec.Matched = matched
+				ec.CoercedValue = value
+				ec.Policy = tracePolicy(ctx, cond, sampleEvent)
+				if !matched {
+					andFailedSoFar = true
+				}
+			case sampleEvent != nil:
+				ec.ShortCircuited = true
+			}
+
+			eg.Conditions[ci] = ec
+		}
+
+		if groupReachable && !andFailedSoFar {
+			ruleMatchedSoFar = true
+		}
+		result.OrGroups[gi] = eg
+	}
+
+	if sampleEvent != nil {
+		result.Matched = ruleMatchedSoFar
+	}
+
+	return result, nil
+}
+
+// tracePolicy reports which on_missing_field/on_coercion_fail policy (if
+// any) decided cond's outcome against payload, for ExplainRule's trace.
+// It independently re-resolves/re-coerces cond's field rather than
+// threading this detail back out of evaluateCondition - ExplainRule is a
+// debugging surface, not a hot path, so paying for a second resolve here
+// to keep evaluateCondition's signature untouched is the cheaper tradeoff.
+func tracePolicy(ctx context.Context, cond CompiledCondition, payload json.RawMessage) string {
+	if cond.Operator == OpAnyOf || cond.Operator == OpAllOf || cond.Operator == OpCount || cond.Operator == OpNoneOf {
+		return ""
+	}
+
+	resolved, err := Resolve(ctx, cond.Path, payload)
+	if err != nil || !resolved.Found {
+		return missingPolicyLabel(cond.OnMissing)
+	}
+
+	coerced, err := CoerceWithOptions(resolved.Value, cond.FieldType, CoerceOptions{PreserveIntegers: cond.PreserveIntegers})
+	if err != nil {
+		return coercionPolicyLabel(cond.OnCoercion)
+	}
+	if coerced.IsNull {
+		return missingPolicyLabel(cond.OnMissing)
+	}
+
+	return ""
+}
+
+// missingPolicyLabel renders an OnMissingField policy for ExplainRule's trace.
+func missingPolicyLabel(policy OnMissingField) string {
+	switch policy {
+	case OnMissingMatch:
+		return "on_missing_field:match"
+	case OnMissingFail:
+		return "on_missing_field:fail"
+	default:
+		return "on_missing_field:skip"
+	}
+}
+
+// coercionPolicyLabel renders an OnCoercionPolicy for ExplainRule's trace.
+func coercionPolicyLabel(policy OnCoercionPolicy) string {
+	switch policy {
+	case OnCoercionMatch:
+		return "on_coercion_fail:match"
+	case OnCoercionError:
+		return "on_coercion_fail:error"
+	default:
+		return "on_coercion_fail:skip"
+	}
+}
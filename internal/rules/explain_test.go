@@ -0,0 +1,155 @@
+// internal/rules/explain_test.go
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+func TestExplainRule_CostBreakdownAndPriority(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-explain-1",
+		Name:       "explain-rule",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "active",
+					},
+				},
+			},
+		},
+	}
+
+	result, err := ExplainRule(context.Background(), rule, nil)
+	if err != nil {
+		t.Fatalf("ExplainRule() error = %v, want nil", err)
+	}
+	wantPriority := BasePriority + CostLookupPerSegment + (CostEq * MultiplierString) + result.OrPenalty + result.SamplePenalty
+	if result.Priority != wantPriority {
+		t.Errorf("Priority = %v, want %v", result.Priority, wantPriority)
+	}
+	if len(result.OrGroups) != 1 || len(result.OrGroups[0].Conditions) != 1 {
+		t.Fatalf("unexpected OrGroups shape: %+v", result.OrGroups)
+	}
+
+	cond := result.OrGroups[0].Conditions[0]
+	if cond.Cost.LookupCost != CostLookupPerSegment {
+		t.Errorf("LookupCost = %v, want %v", cond.Cost.LookupCost, CostLookupPerSegment)
+	}
+	if cond.Cost.OpCost != CostEq {
+		t.Errorf("OpCost = %v, want %v", cond.Cost.OpCost, CostEq)
+	}
+	if cond.Cost.TypeMultiplier != MultiplierString {
+		t.Errorf("TypeMultiplier = %v, want %v", cond.Cost.TypeMultiplier, MultiplierString)
+	}
+	if cond.Cost.WildcardExecMult != 1 {
+		t.Errorf("WildcardExecMult = %v, want 1", cond.Cost.WildcardExecMult)
+	}
+	// No sample event: trace fields stay at their zero values.
+	if cond.Evaluated || cond.Matched || cond.ShortCircuited {
+		t.Errorf("expected no trace without a sample event, got %+v", cond)
+	}
+}
+
+func TestExplainRule_TraceWithSampleEvent(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-explain-2",
+		Name:       "explain-trace",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "active",
+					},
+					{
+						FieldPath: []types.PathSegment{{Key: "missing"}},
+						Operator:  int(OpExists),
+						FieldType: int(FieldTypeAny),
+					},
+				},
+			},
+		},
+	}
+
+	result, err := ExplainRule(context.Background(), rule, []byte(`{"status":"active"}`))
+	if err != nil {
+		t.Fatalf("ExplainRule() error = %v, want nil", err)
+	}
+	// Compile sorts conditions by cost, cheapest first: "missing"/OpExists
+	// (lookup 128 + exists 1 * any 128 = 256) sorts ahead of "status"/OpEq
+	// (lookup 128 + eq 5 * string 48 = 368). "missing" isn't in the payload
+	// and on_missing_field defaults to skip, so it evaluates false and
+	// short-circuits the AND group before "status" is ever evaluated -
+	// matching what Evaluate() itself returns for this rule/payload.
+	if result.Matched {
+		t.Fatalf("result.Matched = true, want false")
+	}
+
+	conds := result.OrGroups[0].Conditions
+	if len(conds) != 2 {
+		t.Fatalf("len(Conditions) = %v, want 2", len(conds))
+	}
+	first, second := conds[0], conds[1]
+	if !first.Evaluated || first.Matched {
+		t.Errorf("first condition (missing/exists) = %+v, want Evaluated=true Matched=false", first)
+	}
+	if second.Evaluated || !second.ShortCircuited {
+		t.Errorf("second condition (status/eq) should be short-circuited after the first non-match: %+v", second)
+	}
+}
+
+func TestExplainRule_ShortCircuitsAfterFirstNonMatch(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-explain-3",
+		Name:       "explain-short-circuit",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "active",
+					},
+					{
+						FieldPath: []types.PathSegment{{Key: "role"}},
+						Operator:  int(OpEq),
+						FieldType: int(FieldTypeText),
+						Value:     "admin",
+					},
+				},
+			},
+		},
+	}
+
+	result, err := ExplainRule(context.Background(), rule, []byte(`{"status":"inactive","role":"admin"}`))
+	if err != nil {
+		t.Fatalf("ExplainRule() error = %v, want nil", err)
+	}
+	if result.Matched {
+		t.Fatalf("result.Matched = true, want false")
+	}
+
+	conds := result.OrGroups[0].Conditions
+	if conds[0].Matched {
+		t.Errorf("first condition matched unexpectedly: %+v", conds[0])
+	}
+	if conds[1].Evaluated || !conds[1].ShortCircuited {
+		t.Errorf("second condition should be short-circuited after the first non-match: %+v", conds[1])
+	}
+}
@@ -2,6 +2,8 @@
 package rules
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"sort"
 
@@ -13,8 +15,10 @@ import (
  *
  * Resolves arbitrary paths through nested objects and arrays with wildcard
  * support. Implements ANY semantics for wildcards (first match wins) per
- * doc/04-rule-engine/field-path-resolution.md. Enforces MaxPathDepth (16)
- * and MaxNestedWildcards (2) at resolution time.
+ * doc/04-rule-engine/field-path-resolution.md. Enforces MaxPathDepth and
+ * MaxNestedWildcards at resolution time, pulled from the types.Limits
+ * installed into ctx via types.WithLimits (types.DefaultLimits() if ctx
+ * carries none).
  *
  * Key functions:
  *   - Resolve: Traverses JSON following PathSegment chain
@@ -25,6 +29,22 @@ import (
  *
  * Performance: Wildcard on object requires sorted key iteration for
  * deterministic order (Decision Log: evaluation order stability).
+ *
+ * Recursive descent (PathSegment.RecursiveDescent, ".." in JSONPath syntax)
+ * searches every node in the subtree at that position, at any depth, for a
+ * key match - unlike Wildcard/Filter, which only look at the immediate
+ * children. resolveRecursiveDescent/resolveAllRecursiveDescent own that
+ * search and its own MaxRecursiveDescentNodes visit budget, independent of
+ * the MaxWildcardMatches result cap ResolveAll otherwise enforces.
+ *
+ * Resolve dispatches to the token-stream walk in resolve_stream.go
+ * (pathQualifiesForStream) for paths with no filter or recursive-descent
+ * segment and whose wildcards, if any, are a leading run before the first
+ * concrete segment - the common "grab one shallow field out of a large
+ * payload" case ResolveStream was built for. Paths with filters, recursive
+ * descent, or a wildcard after a concrete segment still fall back to a
+ * full json.Unmarshal, since those already materialize one level at a time
+ * in resolveRecursive and gain little from the extra indirection.
  */
 
 // ResolveResult contains the resolved value and the actual path taken.
@@ -34,37 +54,83 @@ type ResolveResult struct {
 	Found        bool                // true if path resolved to a value
 }
 
-// Resolve traverses data following path segments.
+// Resolve traverses data following path segments, enforcing the resource
+// limits installed into ctx via types.WithLimits (types.DefaultLimits() if
+// ctx carries none).
 // Returns ErrPathTooDeep if path exceeds MaxPathDepth.
 // Returns ErrTooManyWildcards if path contains > MaxNestedWildcards wildcards.
 // Returns ErrFieldNotFound if path does not exist in data.
-func Resolve(path []types.PathSegment, data json.RawMessage) (ResolveResult, error) {
-	if len(path) > types.MaxPathDepth {
+func Resolve(ctx context.Context, path []types.PathSegment, data json.RawMessage) (ResolveResult, error) {
+	limits := types.LimitsFromContext(ctx)
+	if len(path) > limits.MaxPathDepth {
 		return ResolveResult{}, types.ErrPathTooDeep
 	}
 
 	wildcardCount := 0
+	recursiveDescentCount := 0
 	for _, seg := range path {
 		if seg.Wildcard {
 			wildcardCount++
 		}
+		if seg.RecursiveDescent {
+			recursiveDescentCount++
+		}
+		if seg.Filter != nil {
+			if err := validateFilterExpr(limits, seg.Filter); err != nil {
+				return ResolveResult{}, err
+			}
+		}
 	}
-	if wildcardCount > types.MaxNestedWildcards {
+	if wildcardCount > limits.MaxNestedWildcards {
 		return ResolveResult{}, types.ErrTooManyWildcards
 	}
+	if recursiveDescentCount > limits.MaxRecursiveDescents {
+		return ResolveResult{}, types.ErrTooManyRecursiveDescents
+	}
+
+	if pathQualifiesForStream(path) {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		return resolveStreamRecursive(dec, path, nil)
+	}
 
 	var parsed any
 	if err := json.Unmarshal(data, &parsed); err != nil {
 		return ResolveResult{}, err
 	}
 
-	return resolveRecursive(path, parsed, nil)
+	return resolveRecursive(limits, path, parsed, nil)
+}
+
+// pathQualifiesForStream reports whether path can be resolved via the
+// token-stream walk (resolveStreamRecursive) instead of a full
+// json.Unmarshal. Filters and recursive descent already need to inspect or
+// search more than the next container in resolveRecursive's tree-walking
+// form, so they're left on the full-unmarshal path; a wildcard is only
+// eligible while it's still part of the path's leading run, since a
+// wildcard following a concrete segment means the stream walk would have
+// to re-derive sorted-key order at multiple levels for no real savings
+// over unmarshaling once.
+func pathQualifiesForStream(path []types.PathSegment) bool {
+	sawConcrete := false
+	for _, seg := range path {
+		if seg.Filter != nil || seg.RecursiveDescent {
+			return false
+		}
+		if seg.Wildcard {
+			if sawConcrete {
+				return false
+			}
+			continue
+		}
+		sawConcrete = true
+	}
+	return true
 }
 
 // resolveRecursive traverses nested JSON structures following path segments.
 // Returns first match for wildcards (ANY semantics). Accumulates resolved path
 // with actual indices/keys replacing wildcards for match diagnostics.
-func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+func resolveRecursive(limits types.Limits, path []types.PathSegment, current any, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
 	if len(path) == 0 {
 		return ResolveResult{
 			Value:        current,
@@ -76,8 +142,32 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 	seg := path[0]
 	remaining := path[1:]
 
+	if seg.RecursiveDescent {
+		visited := 0
+		return resolveRecursiveDescent(limits, seg, remaining, current, resolvedSoFar, &visited)
+	}
+
 	switch v := current.(type) {
 	case map[string]any:
+		if seg.Filter != nil {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				val := v[key]
+				if !evaluateFilterExpr(seg.Filter, val) {
+					continue
+				}
+				resolved := append(resolvedSoFar, types.PathSegment{Key: key})
+				result, err := resolveRecursive(limits, remaining, val, resolved)
+				if err == nil && result.Found {
+					return result, nil
+				}
+			}
+			return ResolveResult{}, types.ErrFieldNotFound
+		}
 		if seg.Wildcard {
 			// Sort keys for deterministic iteration order (stable evaluation invariant)
 			keys := make([]string, 0, len(v))
@@ -88,7 +178,7 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 			for _, key := range keys {
 				val := v[key]
 				resolved := append(resolvedSoFar, types.PathSegment{Key: key})
-				result, err := resolveRecursive(remaining, val, resolved)
+				result, err := resolveRecursive(limits, remaining, val, resolved)
 				if err == nil && result.Found {
 					return result, nil
 				}
@@ -103,9 +193,22 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 		if !ok {
 			return ResolveResult{}, types.ErrFieldNotFound
 		}
-		return resolveRecursive(remaining, val, append(resolvedSoFar, seg))
+		return resolveRecursive(limits, remaining, val, append(resolvedSoFar, seg))
 
 	case []any:
+		if seg.Filter != nil {
+			for i, elem := range v {
+				if !evaluateFilterExpr(seg.Filter, elem) {
+					continue
+				}
+				resolved := append(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
+				result, err := resolveRecursive(limits, remaining, elem, resolved)
+				if err == nil && result.Found {
+					return result, nil
+				}
+			}
+			return ResolveResult{}, types.ErrFieldNotFound
+		}
 		if seg.Wildcard {
 			if len(v) == 0 {
 				// Empty array: all elements missing, defer to on_missing_field
@@ -114,7 +217,7 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 			// ANY semantics: return first match (short-circuit optimization)
 			for i, elem := range v {
 				resolved := append(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
-				result, err := resolveRecursive(remaining, elem, resolved)
+				result, err := resolveRecursive(limits, remaining, elem, resolved)
 				if err == nil && result.Found {
 					return result, nil
 				}
@@ -125,10 +228,16 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 			// Cannot use string key on array
 			return ResolveResult{}, types.ErrFieldNotFound
 		}
-		if seg.Index < 0 || seg.Index >= len(v) {
+		idx := seg.Index
+		if idx < 0 {
+			// Negative index is relative from end ([-N] in JSONPath syntax)
+			idx = len(v) + idx
+		}
+		if idx < 0 || idx >= len(v) {
 			return ResolveResult{}, types.ErrFieldNotFound
 		}
-		return resolveRecursive(remaining, v[seg.Index], append(resolvedSoFar, seg))
+		resolvedSeg := types.PathSegment{Index: idx, IsIndex: true}
+		return resolveRecursive(limits, remaining, v[idx], append(resolvedSoFar, resolvedSeg))
 
 	case nil:
 		// Null value at intermediate position
@@ -139,3 +248,271 @@ func resolveRecursive(path []types.PathSegment, current any, resolvedSoFar []typ
 		return ResolveResult{}, types.ErrFieldNotFound
 	}
 }
+
+// ResolveAll returns every leaf reachable through path, expanding all
+// wildcard matches instead of returning only the first (the ANY semantics
+// Resolve uses). Each result carries its own concrete ResolvedPath with
+// indices/keys substituted for wildcards, in the same deterministic order
+// (sorted object keys, ascending array indices, depth-first) as Resolve.
+// Returns ErrPathTooDeep / ErrTooManyWildcards under the same conditions
+// as Resolve. A path segment that doesn't match anything yields zero
+// results rather than an error - callers distinguish "no matches" from
+// "not found" the same way Resolve's on_missing_field policy does.
+// Returns ErrTooManyMatches if more than MaxWildcardMatches leaves match.
+// Enforces the resource limits installed into ctx via types.WithLimits
+// (types.DefaultLimits() if ctx carries none).
+func ResolveAll(ctx context.Context, path []types.PathSegment, data json.RawMessage) ([]ResolveResult, error) {
+	limits := types.LimitsFromContext(ctx)
+	if len(path) > limits.MaxPathDepth {
+		return nil, types.ErrPathTooDeep
+	}
+
+	wildcardCount := 0
+	recursiveDescentCount := 0
+	for _, seg := range path {
+		if seg.Wildcard {
+			wildcardCount++
+		}
+		if seg.RecursiveDescent {
+			recursiveDescentCount++
+		}
+		if seg.Filter != nil {
+			if err := validateFilterExpr(limits, seg.Filter); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if wildcardCount > limits.MaxNestedWildcards {
+		return nil, types.ErrTooManyWildcards
+	}
+	if recursiveDescentCount > limits.MaxRecursiveDescents {
+		return nil, types.ErrTooManyRecursiveDescents
+	}
+
+	var parsed any
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, err
+	}
+
+	var results []ResolveResult
+	if err := resolveAllRecursive(limits, path, parsed, nil, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// resolveAllRecursive accumulates every leaf path resolves to into results.
+// Copies resolvedSoFar before extending it so sibling branches under a
+// wildcard don't alias and corrupt each other's accumulated path.
+func resolveAllRecursive(limits types.Limits, path []types.PathSegment, current any, resolvedSoFar []types.PathSegment, results *[]ResolveResult) error {
+	if len(path) == 0 {
+		if len(*results) >= limits.MaxWildcardMatches {
+			return types.ErrTooManyMatches
+		}
+		*results = append(*results, ResolveResult{Value: current, ResolvedPath: resolvedSoFar, Found: true})
+		return nil
+	}
+
+	seg := path[0]
+	remaining := path[1:]
+
+	if seg.RecursiveDescent {
+		visited := 0
+		return resolveAllRecursiveDescent(limits, seg, remaining, current, resolvedSoFar, &visited, results)
+	}
+
+	switch v := current.(type) {
+	case map[string]any:
+		if seg.Filter != nil {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				val := v[key]
+				if !evaluateFilterExpr(seg.Filter, val) {
+					continue
+				}
+				resolved := extendPath(resolvedSoFar, types.PathSegment{Key: key})
+				if err := resolveAllRecursive(limits, remaining, val, resolved, results); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if seg.Wildcard {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				resolved := extendPath(resolvedSoFar, types.PathSegment{Key: key})
+				if err := resolveAllRecursive(limits, remaining, v[key], resolved, results); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if seg.IsIndex {
+			return nil
+		}
+		val, ok := v[seg.Key]
+		if !ok {
+			return nil
+		}
+		return resolveAllRecursive(limits, remaining, val, extendPath(resolvedSoFar, seg), results)
+
+	case []any:
+		if seg.Filter != nil {
+			for i, elem := range v {
+				if !evaluateFilterExpr(seg.Filter, elem) {
+					continue
+				}
+				resolved := extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
+				if err := resolveAllRecursive(limits, remaining, elem, resolved, results); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if seg.Wildcard {
+			for i, elem := range v {
+				resolved := extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
+				if err := resolveAllRecursive(limits, remaining, elem, resolved, results); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if !seg.IsIndex {
+			return nil
+		}
+		idx := seg.Index
+		if idx < 0 {
+			idx = len(v) + idx
+		}
+		if idx < 0 || idx >= len(v) {
+			return nil
+		}
+		resolvedSeg := types.PathSegment{Index: idx, IsIndex: true}
+		return resolveAllRecursive(limits, remaining, v[idx], extendPath(resolvedSoFar, resolvedSeg), results)
+
+	default:
+		return nil
+	}
+}
+
+// extendPath returns a copy of path with seg appended, so callers can
+// branch into multiple children without sharing a backing array.
+func extendPath(path []types.PathSegment, seg types.PathSegment) []types.PathSegment {
+	extended := make([]types.PathSegment, len(path)+1)
+	copy(extended, path)
+	extended[len(path)] = seg
+	return extended
+}
+
+// resolveRecursiveDescent searches every node in the subtree rooted at
+// current, at any depth, for a key matching seg.Key, resuming normal
+// resolution on remaining from the first match found (ANY semantics). The
+// search is depth-first in sorted-key / ascending-index order; a node's own
+// key is tested before its children's, but a deep match under an earlier
+// sibling is found before a shallower one under a later sibling. Enforces limits.MaxRecursiveDescentNodes across the whole
+// search via visited, independent of the MaxWildcardMatches result cap.
+func resolveRecursiveDescent(limits types.Limits, seg types.PathSegment, remaining []types.PathSegment, current any, resolvedSoFar []types.PathSegment, visited *int) (ResolveResult, error) {
+	*visited++
+	if *visited > limits.MaxRecursiveDescentNodes {
+		return ResolveResult{}, types.ErrRecursiveDescentBudgetExceeded
+	}
+
+	switch v := current.(type) {
+	case map[string]any:
+		if val, ok := v[seg.Key]; ok {
+			result, err := resolveRecursive(limits, remaining, val, extendPath(resolvedSoFar, types.PathSegment{Key: seg.Key}))
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result, err := resolveRecursiveDescent(limits, seg, remaining, v[key], extendPath(resolvedSoFar, types.PathSegment{Key: key}), visited)
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+
+	case []any:
+		for i, elem := range v {
+			result, err := resolveRecursiveDescent(limits, seg, remaining, elem, extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true}), visited)
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+
+	default:
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+}
+
+// resolveAllRecursiveDescent is resolveRecursiveDescent's ResolveAll
+// counterpart: it accumulates every match into results instead of
+// short-circuiting on the first, sharing the same visited budget and
+// deterministic (sorted key, ascending index) traversal order.
+func resolveAllRecursiveDescent(limits types.Limits, seg types.PathSegment, remaining []types.PathSegment, current any, resolvedSoFar []types.PathSegment, visited *int, results *[]ResolveResult) error {
+	*visited++
+	if *visited > limits.MaxRecursiveDescentNodes {
+		return types.ErrRecursiveDescentBudgetExceeded
+	}
+
+	switch v := current.(type) {
+	case map[string]any:
+		if val, ok := v[seg.Key]; ok {
+			resolved := extendPath(resolvedSoFar, types.PathSegment{Key: seg.Key})
+			if err := resolveAllRecursive(limits, remaining, val, resolved, results); err != nil {
+				return err
+			}
+		}
+
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			resolved := extendPath(resolvedSoFar, types.PathSegment{Key: key})
+			if err := resolveAllRecursiveDescent(limits, seg, remaining, v[key], resolved, visited, results); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case []any:
+		for i, elem := range v {
+			resolved := extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
+			if err := resolveAllRecursiveDescent(limits, seg, remaining, elem, resolved, visited, results); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return nil
+	}
+}
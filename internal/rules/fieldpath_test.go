@@ -1,7 +1,9 @@
 package rules
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/leanovate/gopter"
@@ -47,6 +49,13 @@ func TestResolve_Normal(t *testing.T) {
 			expected: float64(2), // 'a' comes first alphabetically
 			wantErr:  nil,
 		},
+		{
+			name:     "negative array index relative from end",
+			path:     []types.PathSegment{{Index: -1, IsIndex: true}},
+			data:     `[1, 2, 3]`,
+			expected: float64(3),
+			wantErr:  nil,
+		},
 		{
 			name:     "deep nesting",
 			path:     []types.PathSegment{{Key: "a"}, {Key: "b"}, {Key: "c"}, {Key: "d"}},
@@ -65,7 +74,7 @@ func TestResolve_Normal(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := Resolve(tt.path, json.RawMessage(tt.data))
+			result, err := Resolve(context.Background(), tt.path, json.RawMessage(tt.data))
 			if err != tt.wantErr {
 				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -86,7 +95,7 @@ func TestResolve_ResolvedPath(t *testing.T) {
 	path := []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}}
 	data := `{"items": [{"price": 10}, {"price": 20}]}`
 
-	result, err := Resolve(path, json.RawMessage(data))
+	result, err := Resolve(context.Background(), path, json.RawMessage(data))
 	if err != nil {
 		t.Fatalf("Resolve() error = %v", err)
 	}
@@ -154,8 +163,8 @@ func TestResolve_EdgeCases(t *testing.T) {
 			wantErr: types.ErrFieldNotFound,
 		},
 		{
-			name:    "negative array index",
-			path:    []types.PathSegment{{Index: -1, IsIndex: true}},
+			name:    "negative array index past start of array",
+			path:    []types.PathSegment{{Index: -5, IsIndex: true}},
 			data:    `[1, 2, 3]`,
 			wantErr: types.ErrFieldNotFound,
 		},
@@ -181,7 +190,7 @@ func TestResolve_EdgeCases(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := Resolve(tt.path, json.RawMessage(tt.data))
+			result, err := Resolve(context.Background(), tt.path, json.RawMessage(tt.data))
 			if err != tt.wantErr {
 				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -238,7 +247,7 @@ func TestResolve_Errors(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := Resolve(tt.path, json.RawMessage(tt.data))
+			_, err := Resolve(context.Background(), tt.path, json.RawMessage(tt.data))
 			if tt.wantErr != nil && err != tt.wantErr {
 				t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -249,6 +258,204 @@ func TestResolve_Errors(t *testing.T) {
 	}
 }
 
+// Test ResolveAll yields every wildcard match, not just the first.
+func TestResolveAll(t *testing.T) {
+	t.Run("all matches under single wildcard", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}}
+		data := `{"items": [{"price": 10}, {"price": 20}, {"price": 30}]}`
+
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("ResolveAll() len = %d, want 3", len(results))
+		}
+		for i, expected := range []float64{10, 20, 30} {
+			if results[i].Value != expected {
+				t.Errorf("results[%d].Value = %v, want %v", i, results[i].Value, expected)
+			}
+			wantIdx := i
+			if results[i].ResolvedPath[1].Index != wantIdx {
+				t.Errorf("results[%d].ResolvedPath[1].Index = %d, want %d", i, results[i].ResolvedPath[1].Index, wantIdx)
+			}
+		}
+	})
+
+	t.Run("nested wildcards expand to cross product", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "orders"}, {Wildcard: true}, {Key: "items"}, {Wildcard: true}, {Key: "price"}}
+		data := `{"orders": [{"items": [{"price": 1}, {"price": 2}]}, {"items": [{"price": 3}]}]}`
+
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("ResolveAll() len = %d, want 3", len(results))
+		}
+	})
+
+	t.Run("no matches yields empty slice, not an error", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "missing"}, {Wildcard: true}}
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("ResolveAll() len = %d, want 0", len(results))
+		}
+	})
+
+	t.Run("sibling branches don't alias resolved paths", func(t *testing.T) {
+		path := []types.PathSegment{{Wildcard: true}, {Key: "value"}}
+		data := `{"a": {"value": 1}, "b": {"value": 2}}`
+
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("ResolveAll() len = %d, want 2", len(results))
+		}
+		if results[0].ResolvedPath[0].Key != "a" || results[1].ResolvedPath[0].Key != "b" {
+			t.Errorf("ResolvedPath keys = %q, %q, want a, b", results[0].ResolvedPath[0].Key, results[1].ResolvedPath[0].Key)
+		}
+	})
+
+	t.Run("exceeding MaxWildcardMatches returns ErrTooManyMatches", func(t *testing.T) {
+		var items []string
+		for i := 0; i < types.MaxWildcardMatches+1; i++ {
+			items = append(items, `{"price": 1}`)
+		}
+		data := `{"items": [` + strings.Join(items, ",") + `]}`
+		path := []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}}
+
+		_, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != types.ErrTooManyMatches {
+			t.Errorf("ResolveAll() error = %v, want ErrTooManyMatches", err)
+		}
+	})
+
+	t.Run("fires once per namespace instead of only the first", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "status"}, {Key: "namespaces"}, {Wildcard: true}, {Key: "status"}}
+		data := `{"status": {"namespaces": [{"status": "Ready"}, {"status": "Pending"}, {"status": "Ready"}]}}`
+
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		want := []string{"Ready", "Pending", "Ready"}
+		if len(results) != len(want) {
+			t.Fatalf("ResolveAll() len = %d, want %d", len(results), len(want))
+		}
+		for i, w := range want {
+			if results[i].Value != w {
+				t.Errorf("results[%d].Value = %v, want %v", i, results[i].Value, w)
+			}
+			if results[i].ResolvedPath[2].Index != i {
+				t.Errorf("results[%d].ResolvedPath[2].Index = %d, want %d", i, results[i].ResolvedPath[2].Index, i)
+			}
+		}
+	})
+}
+
+func TestResolve_RecursiveDescent(t *testing.T) {
+	t.Run("finds key at varying depth", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "spec"}, {Key: "image", RecursiveDescent: true}}
+		data := `{"spec": {"containers": [{"name": "app", "image": "app:v1"}]}}`
+
+		result, err := Resolve(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != "app:v1" {
+			t.Errorf("Resolve() = %v, found=%v, want \"app:v1\", found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("matches a key on the starting node itself", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "status", RecursiveDescent: true}}
+		data := `{"status": "Ready"}`
+
+		result, err := Resolve(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != "Ready" {
+			t.Errorf("Resolve() = %v, found=%v, want \"Ready\", found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("no match yields ErrFieldNotFound", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "missing", RecursiveDescent: true}}
+		_, err := Resolve(context.Background(), path, json.RawMessage(`{"a": {"b": 1}}`))
+		if err != types.ErrFieldNotFound {
+			t.Errorf("Resolve() error = %v, want ErrFieldNotFound", err)
+		}
+	})
+
+	t.Run("continues resolution past the match", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "containers", RecursiveDescent: true},
+			{Wildcard: true},
+			{Key: "name"},
+		}
+		data := `{"spec": {"containers": [{"name": "app"}, {"name": "sidecar"}]}}`
+
+		result, err := Resolve(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != "app" {
+			t.Errorf("Resolve() = %v, found=%v, want \"app\", found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("too many recursive descent segments returns error", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "a", RecursiveDescent: true},
+			{Key: "b", RecursiveDescent: true},
+		}
+		_, err := Resolve(context.Background(), path, json.RawMessage(`{}`))
+		if err != types.ErrTooManyRecursiveDescents {
+			t.Errorf("Resolve() error = %v, want ErrTooManyRecursiveDescents", err)
+		}
+	})
+
+	t.Run("exceeding MaxRecursiveDescentNodes returns error", func(t *testing.T) {
+		limits := types.DefaultLimits()
+		limits.MaxRecursiveDescentNodes = 2
+		ctx := types.WithLimits(context.Background(), limits)
+
+		path := []types.PathSegment{{Key: "missing", RecursiveDescent: true}}
+		data := `{"a": {"b": {"c": 1}}}`
+
+		_, err := Resolve(ctx, path, json.RawMessage(data))
+		if err != types.ErrRecursiveDescentBudgetExceeded {
+			t.Errorf("Resolve() error = %v, want ErrRecursiveDescentBudgetExceeded", err)
+		}
+	})
+
+	t.Run("ResolveAll accumulates every match", func(t *testing.T) {
+		path := []types.PathSegment{{Key: "name", RecursiveDescent: true}}
+		data := `{"containers": [{"name": "app"}, {"name": "sidecar"}], "name": "pod"}`
+
+		results, err := ResolveAll(context.Background(), path, json.RawMessage(data))
+		if err != nil {
+			t.Fatalf("ResolveAll() error = %v", err)
+		}
+		want := []string{"pod", "app", "sidecar"}
+		if len(results) != len(want) {
+			t.Fatalf("ResolveAll() len = %d, want %d", len(results), len(want))
+		}
+		for i, w := range want {
+			if results[i].Value != w {
+				t.Errorf("results[%d].Value = %v, want %v", i, results[i].Value, w)
+			}
+		}
+	})
+}
+
 // Property-based test: resolution never crashes
 func TestResolve_PropertyNeverCrashes(t *testing.T) {
 	parameters := gopter.DefaultTestParameters()
@@ -281,7 +488,7 @@ func TestResolve_PropertyNeverCrashes(t *testing.T) {
 				}
 			}()
 
-			_, _ = Resolve(path, json.RawMessage(data))
+			_, _ = Resolve(context.Background(), path, json.RawMessage(data))
 			return true
 		},
 		gen.IntRange(0, 20),
@@ -321,7 +528,7 @@ func TestResolve_PropertySchemaVariations(t *testing.T) {
 				}
 			}()
 
-			_, _ = Resolve(path, json.RawMessage(data))
+			_, _ = Resolve(context.Background(), path, json.RawMessage(data))
 			return true
 		},
 		gen.IntRange(0, 10),
@@ -344,8 +551,8 @@ func TestResolve_PropertyWildcardDeterminism(t *testing.T) {
 			path := []types.PathSegment{{Wildcard: true}, {Key: "value"}}
 			data := `{"z": {"value": 1}, "a": {"value": 2}, "m": {"value": 3}}`
 
-			result1, err1 := Resolve(path, json.RawMessage(data))
-			result2, err2 := Resolve(path, json.RawMessage(data))
+			result1, err1 := Resolve(context.Background(), path, json.RawMessage(data))
+			result2, err2 := Resolve(context.Background(), path, json.RawMessage(data))
 
 			if err1 != err2 {
 				return false
@@ -365,3 +572,62 @@ func TestResolve_PropertyWildcardDeterminism(t *testing.T) {
 
 	properties.TestingRun(t)
 }
+
+// TestResolve_StreamPathAgreesWithTreeWalk cross-checks Resolve's dispatch
+// into the token-stream walk (pathQualifiesForStream) against
+// resolveRecursive's tree-walking form on the same parsed data, for paths
+// that qualify for streaming. Resolve only ever takes the stream branch, so
+// without this check a bug specific to resolveStreamRecursive (as opposed
+// to resolveRecursive) could slip back in unnoticed.
+func TestResolve_StreamPathAgreesWithTreeWalk(t *testing.T) {
+	tests := []struct {
+		name string
+		path []types.PathSegment
+		data string
+	}{
+		{
+			name: "wildcard on object sorted keys",
+			path: []types.PathSegment{{Wildcard: true}, {Key: "value"}},
+			data: `{"z": {"value": 1}, "a": {"value": 2}, "m": {"value": 3}}`,
+		},
+		{
+			name: "negative array index relative from end",
+			path: []types.PathSegment{{Index: -1, IsIndex: true}},
+			data: `[1, 2, 3]`,
+		},
+		{
+			name: "wildcard on empty object",
+			path: []types.PathSegment{{Wildcard: true}},
+			data: `{}`,
+		},
+		{
+			name: "negative array index past start of array",
+			path: []types.PathSegment{{Index: -5, IsIndex: true}},
+			data: `[1, 2, 3]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !pathQualifiesForStream(tt.path) {
+				t.Fatalf("path does not qualify for streaming, test no longer exercises the stream branch")
+			}
+
+			streamed, streamErr := Resolve(context.Background(), tt.path, json.RawMessage(tt.data))
+
+			var parsed any
+			if err := json.Unmarshal([]byte(tt.data), &parsed); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			treeWalked, treeErr := resolveRecursive(types.DefaultLimits(), tt.path, parsed, nil)
+
+			if streamErr != treeErr {
+				t.Fatalf("Resolve() error = %v, resolveRecursive() error = %v", streamErr, treeErr)
+			}
+			if streamed.Found != treeWalked.Found || streamed.Value != treeWalked.Value {
+				t.Errorf("Resolve() = %v (found=%v), resolveRecursive() = %v (found=%v)",
+					streamed.Value, streamed.Found, treeWalked.Value, treeWalked.Found)
+			}
+		})
+	}
+}
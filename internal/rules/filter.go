@@ -0,0 +1,126 @@
+// internal/rules/filter.go
+package rules
+
+import "github.com/solatis/trapperkeeper/internal/types"
+
+/*
+ * Filter segment ([?(...)]) evaluation.
+ *
+ * A filter segment iterates the children of the array/object it's applied
+ * to and keeps only those whose FilterExpr predicate matches, continuing
+ * descent on each match the same way a wildcard continues descent on every
+ * element. Comparisons reuse Compare(), so filter predicates stay
+ * consistent with regular condition evaluation.
+ *
+ * Depth: validateFilterExpr rejects a filter segment whose LHS contains
+ * another filter segment (MaxFilterDepth), and caps the predicate tree size
+ * at MaxFilterPredicates, so evaluation stays linear in payload size.
+ */
+
+// evaluateFilterExpr evaluates expr against candidate (the @ element).
+func evaluateFilterExpr(expr *types.FilterExpr, candidate any) bool {
+	if expr == nil {
+		return false
+	}
+
+	switch expr.Op {
+	case types.FilterOpAnd:
+		for _, child := range expr.Children {
+			if !evaluateFilterExpr(child, candidate) {
+				return false
+			}
+		}
+		return true
+	case types.FilterOpOr:
+		for _, child := range expr.Children {
+			if evaluateFilterExpr(child, candidate) {
+				return true
+			}
+		}
+		return false
+	case types.FilterOpNot:
+		if len(expr.Children) != 1 {
+			return false
+		}
+		return !evaluateFilterExpr(expr.Children[0], candidate)
+	case types.FilterOpExists:
+		value, found := resolveFilterLHS(expr.LHS, candidate)
+		return found && value != nil
+	case types.FilterOpEq, types.FilterOpNeq, types.FilterOpLt, types.FilterOpLte, types.FilterOpGt, types.FilterOpGte,
+		types.FilterOpIn, types.FilterOpPrefix:
+		value, found := resolveFilterLHS(expr.LHS, candidate)
+		if !found {
+			return false
+		}
+		return Compare(filterCompareOp(expr.Op), value, expr.RHS)
+	default:
+		return false
+	}
+}
+
+// filterCompareOp maps a comparison FilterOp to the Operator enum Compare()
+// understands, so filter predicates reuse the same comparison logic as
+// regular conditions.
+func filterCompareOp(op types.FilterOp) Operator {
+	switch op {
+	case types.FilterOpEq:
+		return OpEq
+	case types.FilterOpNeq:
+		return OpNeq
+	case types.FilterOpLt:
+		return OpLt
+	case types.FilterOpLte:
+		return OpLte
+	case types.FilterOpGt:
+		return OpGt
+	case types.FilterOpGte:
+		return OpGte
+	case types.FilterOpIn:
+		return OpIn
+	case types.FilterOpPrefix:
+		return OpPrefix
+	default:
+		return OpUnspecified
+	}
+}
+
+// resolveFilterLHS resolves lhs relative to candidate (the @ element).
+// A nil/empty LHS refers to @ itself.
+func resolveFilterLHS(lhs []types.PathSegment, candidate any) (any, bool) {
+	if len(lhs) == 0 {
+		return candidate, true
+	}
+	result, err := resolveRecursive(types.DefaultLimits(), lhs, candidate, nil)
+	if err != nil || !result.Found {
+		return nil, false
+	}
+	return result.Value, true
+}
+
+// validateFilterExpr enforces limits.MaxFilterPredicates and rejects
+// nesting beyond MaxFilterDepth before a filter segment is evaluated.
+func validateFilterExpr(limits types.Limits, expr *types.FilterExpr) error {
+	if expr.CountPredicates() > limits.MaxFilterPredicates {
+		return types.ErrTooManyFilterPredicates
+	}
+	return validateFilterNoNesting(expr)
+}
+
+// validateFilterNoNesting rejects a filter whose LHS sub-path contains
+// another filter segment (MaxFilterDepth = 1).
+func validateFilterNoNesting(expr *types.FilterExpr) error {
+	if expr == nil {
+		return nil
+	}
+	for _, seg := range expr.LHS {
+		if seg.Filter != nil {
+			return types.ErrNestedFilterTooDeep
+		}
+	}
+	for _, child := range expr.Children {
+		if err := validateFilterNoNesting(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
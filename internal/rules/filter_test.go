@@ -0,0 +1,268 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+func TestResolve_FilterSegment(t *testing.T) {
+	t.Run("array filter selects matching element", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{
+				Op:  types.FilterOpEq,
+				LHS: []types.PathSegment{{Key: "status"}},
+				RHS: "active",
+			}},
+			{Key: "price"},
+		}
+		data := json.RawMessage(`{"items": [{"status": "inactive", "price": 1}, {"status": "active", "price": 42}]}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != float64(42) {
+			t.Errorf("Resolve() = %v, found=%v, want 42, found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("object filter selects matching value", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "accounts"},
+			{Filter: &types.FilterExpr{
+				Op:  types.FilterOpGt,
+				LHS: []types.PathSegment{{Key: "balance"}},
+				RHS: float64(100),
+			}},
+			{Key: "id"},
+		}
+		data := json.RawMessage(`{"accounts": {"a": {"balance": 10, "id": "a"}, "b": {"balance": 500, "id": "b"}}}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != "b" {
+			t.Errorf("Resolve() = %v, found=%v, want \"b\", found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("existence check with no operator", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{LHS: []types.PathSegment{{Key: "discount"}}, Op: types.FilterOpExists}},
+			{Key: "price"},
+		}
+		data := json.RawMessage(`{"items": [{"price": 1}, {"price": 2, "discount": 0.1}]}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != float64(2) {
+			t.Errorf("Resolve() = %v, found=%v, want 2, found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("no element matches yields ErrFieldNotFound", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"}},
+		}
+		data := json.RawMessage(`{"items": [{"status": "inactive"}]}`)
+
+		_, err := Resolve(context.Background(), path, data)
+		if err != types.ErrFieldNotFound {
+			t.Errorf("Resolve() error = %v, want ErrFieldNotFound", err)
+		}
+	})
+
+	t.Run("AND combinator requires both sides", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{
+				Op: types.FilterOpAnd,
+				Children: []*types.FilterExpr{
+					{Op: types.FilterOpGt, LHS: []types.PathSegment{{Key: "price"}}, RHS: float64(10)},
+					{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"},
+				},
+			}},
+			{Key: "id"},
+		}
+		data := json.RawMessage(`{"items": [{"price": 5, "status": "active", "id": "a"}, {"price": 50, "status": "active", "id": "b"}]}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != "b" {
+			t.Errorf("Resolve() = %v, found=%v, want \"b\", found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("in selects an element whose field is one of a set", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "containers"},
+			{Filter: &types.FilterExpr{
+				Op:  types.FilterOpIn,
+				LHS: []types.PathSegment{{Key: "name"}},
+				RHS: []any{"envoy", "nginx"},
+			}},
+			{Key: "ready"},
+		}
+		data := json.RawMessage(`{"containers": [{"name": "app", "ready": false}, {"name": "nginx", "ready": true}]}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != true {
+			t.Errorf("Resolve() = %v, found=%v, want true, found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("prefix selects an element whose field starts with a string", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "containers"},
+			{Filter: &types.FilterExpr{
+				Op:  types.FilterOpPrefix,
+				LHS: []types.PathSegment{{Key: "name"}},
+				RHS: "nginx-",
+			}},
+			{Key: "ready"},
+		}
+		data := json.RawMessage(`{"containers": [{"name": "app", "ready": false}, {"name": "nginx-sidecar", "ready": true}]}`)
+
+		result, err := Resolve(context.Background(), path, data)
+		if err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+		if !result.Found || result.Value != true {
+			t.Errorf("Resolve() = %v, found=%v, want true, found=true", result.Value, result.Found)
+		}
+	})
+
+	t.Run("exceeding MaxFilterPredicates returns error", func(t *testing.T) {
+		children := make([]*types.FilterExpr, 0, types.MaxFilterPredicates+1)
+		for i := 0; i <= types.MaxFilterPredicates; i++ {
+			children = append(children, &types.FilterExpr{Op: types.FilterOpExists, LHS: []types.PathSegment{{Key: "x"}}})
+		}
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{Op: types.FilterOpAnd, Children: children}},
+		}
+		data := json.RawMessage(`{"items": [{"x": 1}]}`)
+
+		_, err := Resolve(context.Background(), path, data)
+		if err != types.ErrTooManyFilterPredicates {
+			t.Errorf("Resolve() error = %v, want ErrTooManyFilterPredicates", err)
+		}
+	})
+
+	t.Run("nested filter in LHS beyond depth 1 is rejected", func(t *testing.T) {
+		path := []types.PathSegment{
+			{Key: "items"},
+			{Filter: &types.FilterExpr{
+				Op: types.FilterOpExists,
+				LHS: []types.PathSegment{
+					{Key: "sub"},
+					{Filter: &types.FilterExpr{Op: types.FilterOpExists, LHS: []types.PathSegment{{Key: "y"}}}},
+				},
+			}},
+		}
+		data := json.RawMessage(`{"items": [{"sub": [{"y": 1}]}]}`)
+
+		_, err := Resolve(context.Background(), path, data)
+		if err != types.ErrNestedFilterTooDeep {
+			t.Errorf("Resolve() error = %v, want ErrNestedFilterTooDeep", err)
+		}
+	})
+}
+
+func TestResolveAll_FilterSegment(t *testing.T) {
+	path := []types.PathSegment{
+		{Key: "items"},
+		{Filter: &types.FilterExpr{Op: types.FilterOpGte, LHS: []types.PathSegment{{Key: "price"}}, RHS: float64(10)}},
+		{Key: "id"},
+	}
+	data := json.RawMessage(`{"items": [{"id": "a", "price": 5}, {"id": "b", "price": 10}, {"id": "c", "price": 20}]}`)
+
+	results, err := ResolveAll(context.Background(), path, data)
+	if err != nil {
+		t.Fatalf("ResolveAll() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("ResolveAll() returned %d results, want 2", len(results))
+	}
+	if results[0].Value != "b" || results[1].Value != "c" {
+		t.Errorf("ResolveAll() = [%v, %v], want [b, c]", results[0].Value, results[1].Value)
+	}
+}
+
+func TestEvaluateFilterExpr(t *testing.T) {
+	candidate := map[string]any{"status": "active", "price": float64(42)}
+
+	tests := []struct {
+		name string
+		expr *types.FilterExpr
+		want bool
+	}{
+		{
+			name: "eq match",
+			expr: &types.FilterExpr{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"},
+			want: true,
+		},
+		{
+			name: "neq no match",
+			expr: &types.FilterExpr{Op: types.FilterOpNeq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"},
+			want: false,
+		},
+		{
+			name: "not inverts",
+			expr: &types.FilterExpr{Op: types.FilterOpNot, Children: []*types.FilterExpr{
+				{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"},
+			}},
+			want: false,
+		},
+		{
+			name: "or with one true branch",
+			expr: &types.FilterExpr{Op: types.FilterOpOr, Children: []*types.FilterExpr{
+				{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "inactive"},
+				{Op: types.FilterOpGt, LHS: []types.PathSegment{{Key: "price"}}, RHS: float64(10)},
+			}},
+			want: true,
+		},
+		{
+			name: "missing LHS fails comparison",
+			expr: &types.FilterExpr{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "missing"}}, RHS: "x"},
+			want: false,
+		},
+		{
+			name: "in matches one of a set",
+			expr: &types.FilterExpr{Op: types.FilterOpIn, LHS: []types.PathSegment{{Key: "status"}}, RHS: []any{"active", "idle"}},
+			want: true,
+		},
+		{
+			name: "in no match",
+			expr: &types.FilterExpr{Op: types.FilterOpIn, LHS: []types.PathSegment{{Key: "status"}}, RHS: []any{"inactive"}},
+			want: false,
+		},
+		{
+			name: "prefix match",
+			expr: &types.FilterExpr{Op: types.FilterOpPrefix, LHS: []types.PathSegment{{Key: "status"}}, RHS: "act"},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := evaluateFilterExpr(tt.expr, candidate); got != tt.want {
+				t.Errorf("evaluateFilterExpr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// internal/rules/limits_test.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+// Test that a context-installed Limits overrides DefaultLimits() for Resolve.
+func TestResolve_ContextLimitsOverridePathDepth(t *testing.T) {
+	path := []types.PathSegment{{Key: "a"}, {Key: "b"}, {Key: "c"}}
+	data := json.RawMessage(`{"a": {"b": {"c": "deep"}}}`)
+
+	if _, err := Resolve(context.Background(), path, data); err != nil {
+		t.Fatalf("Resolve() with default limits error = %v, want nil", err)
+	}
+
+	limits := types.DefaultLimits()
+	limits.MaxPathDepth = 2
+	ctx := types.WithLimits(context.Background(), limits)
+
+	_, err := Resolve(ctx, path, data)
+	if err != types.ErrPathTooDeep {
+		t.Errorf("Resolve() with tightened MaxPathDepth error = %v, want ErrPathTooDeep", err)
+	}
+}
+
+// Test that a context-installed Limits overrides DefaultLimits() for ResolveAll's match cap.
+func TestResolveAll_ContextLimitsOverrideWildcardMatches(t *testing.T) {
+	path := []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}}
+	data := json.RawMessage(`{"items": [{"price": 1}, {"price": 2}, {"price": 3}]}`)
+
+	limits := types.DefaultLimits()
+	limits.MaxWildcardMatches = 2
+	ctx := types.WithLimits(context.Background(), limits)
+
+	_, err := ResolveAll(ctx, path, data)
+	if err != types.ErrTooManyMatches {
+		t.Errorf("ResolveAll() with tightened MaxWildcardMatches error = %v, want ErrTooManyMatches", err)
+	}
+}
+
+// Test that Engine.WithLimits installs its DefaultLimits into context for
+// downstream Resolve/Compile calls.
+func TestEngine_WithLimitsInstallsDefaultLimits(t *testing.T) {
+	engine := NewEngine()
+	engine.DefaultLimits.MaxPathDepth = 1
+	ctx := engine.WithLimits(context.Background())
+
+	path := []types.PathSegment{{Key: "a"}, {Key: "b"}}
+	_, err := Resolve(ctx, path, json.RawMessage(`{"a": {"b": 1}}`))
+	if err != types.ErrPathTooDeep {
+		t.Errorf("Resolve() under engine-installed limits error = %v, want ErrPathTooDeep", err)
+	}
+}
+
+// Test that Compile enforces limits installed via context, not just the
+// package-level constants.
+func TestCompile_ContextLimitsOverrideInOperatorValues(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-limits",
+		Name:       "limits-override",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{
+				Conditions: []types.Condition{
+					{
+						FieldPath: []types.PathSegment{{Key: "status"}},
+						Operator:  int(OpIn),
+						FieldType: int(FieldTypeNumeric),
+						Values:    []any{1, 2, 3},
+					},
+				},
+			},
+		},
+	}
+
+	limits := types.DefaultLimits()
+	limits.MaxInOperatorValues = 2
+	ctx := types.WithLimits(context.Background(), limits)
+
+	_, err := Compile(ctx, rule)
+	if err != types.ErrTooManyInValues {
+		t.Errorf("Compile() with tightened MaxInOperatorValues error = %v, want ErrTooManyInValues", err)
+	}
+}
@@ -0,0 +1,52 @@
+// internal/rules/network.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Network is CompileSet/EvaluateSet (see planner.go) under the names this
+ * package's RETE-like design more naturally goes by once a caller is
+ * thinking in terms of a discrimination network rather than a "set of
+ * compiled rules": an alpha layer keyed by FieldPath, hashed eq/in
+ * dispatch, sorted-and-binary-searched range/prefix dispatch, and a
+ * per-(rule, OrGroup) bitset of satisfied condition tokens that fires the
+ * rule as soon as any group's bitset fills. CompileSet already built all of
+ * that for the chunk1-2 cross-rule planner; Network/CompileNetwork/Result
+ * are a thin naming layer over it rather than a second implementation, so
+ * the two call sites - "amortize path traversal across many rules" and
+ * "build a rule network" - don't drift into subtly different engines.
+ */
+
+// Network is a compiled discrimination network over a RuleSet, built by
+// CompileNetwork. It is exactly a *CompiledRuleSet under another name.
+type Network = CompiledRuleSet
+
+// Result is one rule's outcome from Network.Evaluate. It is exactly a
+// MatchResult under another name.
+type Result = MatchResult
+
+// CompileNetwork builds a Network from rules: every distinct FieldPath gets
+// an alpha node (fieldSlot), literal equality/IN predicates hash into it,
+// range and prefix predicates sort into it for binary search, and every
+// condition files an (ruleIdx, orGroupIdx, condIdx) token into its alpha
+// node's satisfied-condition bitset. See CompileSet for the full design.
+func CompileNetwork(ctx context.Context, rules []*types.Rule) (*Network, error) {
+	return CompileSet(ctx, rules)
+}
+
+// Evaluate walks payload through net's alpha layer exactly once per
+// distinct FieldPath, accumulates each OrGroup's AND-count via its bitset,
+// and marks a rule matched as soon as any of its groups' bitsets fill -
+// short-circuiting the remaining groups the same way Evaluate does for a
+// single rule. OnMissingMatch/OnCoercionMatch are preserved: a condition
+// whose field is missing, or whose resolved value fails coercion, still
+// fires its token when the condition is configured to match on that
+// outcome (see reconcilePolicies in planner.go).
+func (net *Network) Evaluate(ctx context.Context, payload json.RawMessage, eventKey string) ([]Result, error) {
+	return EvaluateSet(ctx, net, payload, eventKey)
+}
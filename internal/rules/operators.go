@@ -2,7 +2,12 @@
 package rules
 
 import (
+	"encoding/json"
+	"net"
+	"net/netip"
+	"regexp"
 	"strings"
+	"time"
 )
 
 /*
@@ -17,6 +22,10 @@ import (
  *   - lt/lte/gt/gte: Numeric comparison only (cost 7)
  *   - prefix/suffix: String prefix/suffix matching (cost 10)
  *   - in: Membership test with equality semantics (cost 8)
+ *   - regex/glob: Pattern matching against a *regexp.Regexp/*globPattern
+ *     precompiled at Compile time (cost 20)
+ *   - cidr: IP membership against a *net.IPNet precompiled at Compile time
+ *     (cost 12)
  *
  * Numeric comparison: Handles float64/int/int64 mixing for JSON compatibility.
  * String comparison: Prefix/suffix operators reject non-string types (return false).
@@ -52,23 +61,146 @@ func Compare(op Operator, value, target any) bool {
 		return compareSuffix(value, target)
 	case OpIn:
 		return compareIn(value, target)
+	case OpRegex:
+		return compareRegex(value, target)
+	case OpGlob:
+		return compareGlob(value, target)
+	case OpCIDR:
+		return compareCIDR(value, target)
 	default:
 		return false
 	}
 }
 
 // compareEqual performs equality comparison with numeric type coercion.
-// Handles float64/int/int64 mixing for JSON compatibility.
+// Handles float64/int/int64 mixing for JSON compatibility. Prefers exact
+// int64 comparison over json.Number/int64 values so IDs beyond float64's
+// 2^53 integer range (from CoerceWithOptions' precision-preserving modes)
+// don't false-positive or false-negative due to float64 rounding.
+// FieldTypeTimestamp/FieldTypeDuration/FieldTypeIPAddr values (time.Time,
+// time.Duration, netip.Addr, netip.Prefix) are handled ahead of the
+// general-purpose numeric/== fallback below: time.Time must use Equal()
+// rather than == (a wall/monotonic-reading mismatch would otherwise
+// false-negative two timestamps representing the same instant), and IP
+// values need CIDR-as-containment semantics rather than plain equality.
 func compareEqual(a, b any) bool {
+	if ta, ok := a.(time.Time); ok {
+		if tb, ok := b.(time.Time); ok {
+			return ta.Equal(tb)
+		}
+		return false
+	}
+	if _, ok := a.(netip.Addr); ok {
+		return compareIPAddr(a, b)
+	}
+	if _, ok := a.(netip.Prefix); ok {
+		return compareIPAddr(a, b)
+	}
+	if ia, ok := toInt64(a); ok {
+		if ib, ok := toInt64(b); ok {
+			return ia == ib
+		}
+	}
 	if na, nb, ok := asNumbers(a, b); ok {
 		return na == nb
 	}
 	return a == b
 }
 
-// compareNumeric performs three-way numeric comparison (-1/0/1).
+// compareIPAddr implements FieldTypeIPAddr's OpEq/OpIn (via compareIn's
+// per-element compareEqual) semantics: a CIDR on either side is a
+// containment test against the plain address on the other, not a string
+// match. value is expected to already be a netip.Addr/netip.Prefix
+// (coerceIPAddr's canonical output); target is normally one as well, since
+// compileCondition canonicalizes IPAddr literals (each OpIn element too) and
+// field_ref targets run through Coerce at evaluation time. A string target
+// is still parsed here.
+func compareIPAddr(value, target any) bool {
+	targetParsed := target
+	if s, ok := target.(string); ok {
+		if p, err := netip.ParsePrefix(s); err == nil {
+			targetParsed = p
+		} else if a, err := netip.ParseAddr(s); err == nil {
+			targetParsed = a
+		} else {
+			return false
+		}
+	}
+
+	switch v := value.(type) {
+	case netip.Addr:
+		switch t := targetParsed.(type) {
+		case netip.Addr:
+			return v == t
+		case netip.Prefix:
+			return t.Contains(v)
+		default:
+			return false
+		}
+	case netip.Prefix:
+		switch t := targetParsed.(type) {
+		case netip.Prefix:
+			return v == t
+		case netip.Addr:
+			return v.Contains(t)
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+}
+
+// toInt64 converts int/int64/json.Number to int64 exactly, without a lossy
+// float64 round-trip. Returns ok=false for non-integral or unconvertible values.
+func toInt64(v any) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// compareNumeric performs three-way numeric comparison (-1/0/1), also
+// covering FieldTypeTimestamp/FieldTypeDuration's LT/LTE/GT/GTE (time.Time
+// compared via Before/Equal/After, time.Duration via its underlying int64).
 // Returns 0 for incomparable types.
 func compareNumeric(a, b any) int {
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		if !ok {
+			return 0
+		}
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		default:
+			return 0
+		}
+	}
+	if da, ok := a.(time.Duration); ok {
+		db, ok := b.(time.Duration)
+		if !ok {
+			return 0
+		}
+		switch {
+		case da < db:
+			return -1
+		case da > db:
+			return 1
+		default:
+			return 0
+		}
+	}
+
 	na, nb, ok := asNumbers(a, b)
 	if !ok {
 		return 0
@@ -92,7 +224,10 @@ func asNumbers(a, b any) (float64, float64, bool) {
 }
 
 // toFloat64 converts value to float64 if it's a numeric type.
-// Handles float64, int, int64 from JSON unmarshaling.
+// Handles float64, int, int64, and json.Number (from CoerceWithOptions'
+// precision-preserving modes). Ordering comparisons (lt/gt/...) tolerate
+// json.Number's float64 rounding; compareEqual routes through toInt64
+// first to avoid it for equality.
 func toFloat64(v any) (float64, bool) {
 	switch n := v.(type) {
 	case float64:
@@ -101,6 +236,9 @@ func toFloat64(v any) (float64, bool) {
 		return float64(n), true
 	case int64:
 		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
 	default:
 		return 0, false
 	}
@@ -142,3 +280,57 @@ func compareIn(value, set any) bool {
 	}
 	return false
 }
+
+// compareRegex reports whether value matches target's pattern. target must
+// be the *regexp.Regexp Compile produced for this condition; value must be
+// a string. Either mismatch returns false, the same non-error convention
+// comparePrefix/compareSuffix use for a non-string value.
+func compareRegex(value, target any) bool {
+	re, ok := target.(*regexp.Regexp)
+	if !ok {
+		return false
+	}
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	return re.MatchString(s)
+}
+
+// compareGlob reports whether value matches target's glob pattern. target
+// must be the *globPattern Compile produced for this condition (see
+// compileGlob).
+func compareGlob(value, target any) bool {
+	g, ok := target.(*globPattern)
+	if !ok {
+		return false
+	}
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	return g.MatchString(s)
+}
+
+// compareCIDR reports whether value, parsed as an IP address, falls within
+// target's network. target must be the *net.IPNet Compile produced for
+// this condition (see net.ParseCIDR in compileCondition). A value that
+// isn't a parseable IP address returns false rather than erroring - the
+// same convention as every other type-mismatch case in this file - since
+// there's no FieldType coercion step that would otherwise have caught it
+// (see the ErrInvalidOperator/ErrCoercionFailed note in compile.go).
+func compareCIDR(value, target any) bool {
+	network, ok := target.(*net.IPNet)
+	if !ok {
+		return false
+	}
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	return network.Contains(ip)
+}
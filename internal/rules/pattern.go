@@ -0,0 +1,98 @@
+// internal/rules/pattern.go
+package rules
+
+import (
+	"regexp"
+	"strings"
+)
+
+/*
+ * OpGlob's compiled artifact.
+ *
+ * A glob is translated to an equivalent, anchored regexp and compiled once
+ * via regexp.Compile (the same RE2-backed, backtracking-free engine OpRegex
+ * uses), so matching stays linear-time regardless of input. globPattern
+ * exists as its own type (rather than just storing the *regexp.Regexp
+ * directly) so Compare/matchCompiledPattern can tell an OpGlob condition's
+ * artifact apart from an OpRegex condition's at the type-switch level.
+ */
+
+// globPattern is an OpGlob condition's compiled artifact.
+type globPattern struct {
+	re *regexp.Regexp
+}
+
+// compileGlob translates pattern (supporting * for any run of characters, ?
+// for a single character, and [abc]/[a-z]/[!abc]/[^abc] for a character
+// class) to a regexp and compiles it.
+func compileGlob(pattern string) (*globPattern, error) {
+	re, err := regexp.Compile(globToRegexPattern(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return &globPattern{re: re}, nil
+}
+
+// MatchString reports whether s matches g's glob pattern in full.
+func (g *globPattern) MatchString(s string) bool {
+	return g.re.MatchString(s)
+}
+
+// globToRegexPattern translates a shell-style glob into an equivalent,
+// fully-anchored regexp source string.
+func globToRegexPattern(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			class, end, ok := parseGlobClass(runes, i)
+			if !ok {
+				b.WriteString(regexp.QuoteMeta("["))
+				continue
+			}
+			b.WriteString(class)
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return b.String()
+}
+
+// parseGlobClass parses the character class starting at runes[start] (which
+// must be '['), translating glob's "!"-or-"^" negation into regexp's "^"
+// and preserving everything else (including "-" ranges) verbatim. Returns
+// the translated "[...]" text, the index of the closing ']', and ok=false
+// for an unterminated or empty class (caller then treats '[' literally).
+func parseGlobClass(runes []rune, start int) (class string, end int, ok bool) {
+	j := start + 1
+	negate := j < len(runes) && (runes[j] == '!' || runes[j] == '^')
+	if negate {
+		j++
+	}
+	bodyStart := j
+	for j < len(runes) && runes[j] != ']' {
+		j++
+	}
+	if j >= len(runes) || j == bodyStart {
+		return "", 0, false
+	}
+
+	var b strings.Builder
+	b.WriteString("[")
+	if negate {
+		b.WriteString("^")
+	}
+	b.WriteString(regexp.QuoteMeta(string(runes[bodyStart:j])))
+	b.WriteString("]")
+	return b.String(), j, true
+}
@@ -0,0 +1,48 @@
+// internal/rules/pattern_test.go
+package rules
+
+import "testing"
+
+func TestCompileGlob_MatchString(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		input   string
+		want    bool
+	}{
+		{"star_matches_any_run", "/api/*.json", "/api/v1/orders.json", true},
+		{"star_requires_suffix", "/api/*.json", "/api/v1/orders.xml", false},
+		{"question_matches_one_char", "file?.txt", "file1.txt", true},
+		{"question_rejects_extra_chars", "file?.txt", "file12.txt", false},
+		{"class_matches_member", "file[abc].txt", "fileb.txt", true},
+		{"class_rejects_non_member", "file[abc].txt", "filed.txt", false},
+		{"class_range", "file[a-z].txt", "filem.txt", true},
+		{"negated_class_bang", "file[!abc].txt", "filed.txt", true},
+		{"negated_class_bang_rejects_member", "file[!abc].txt", "filea.txt", false},
+		{"negated_class_caret", "file[^abc].txt", "filed.txt", true},
+		{"literal_dot_not_wildcard", "file.txt", "fileXtxt", false},
+		{"anchored_full_match_only", "abc", "xabcx", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := compileGlob(tt.pattern)
+			if err != nil {
+				t.Fatalf("compileGlob(%q) error = %v, want nil", tt.pattern, err)
+			}
+			if got := g.MatchString(tt.input); got != tt.want {
+				t.Errorf("compileGlob(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileGlob_UnterminatedClassTreatedLiterally(t *testing.T) {
+	g, err := compileGlob("file[abc.txt")
+	if err != nil {
+		t.Fatalf("compileGlob() error = %v, want nil", err)
+	}
+	if !g.MatchString("file[abc.txt") {
+		t.Error("MatchString(\"file[abc.txt\") = false, want true (unterminated class matches literal '[')")
+	}
+}
@@ -0,0 +1,388 @@
+// internal/rules/payload_view.go
+package rules
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Compiled paths over a shared, lazily-decoded payload view.
+ *
+ * Resolve (fieldpath.go) re-parses data from scratch on every call, which
+ * is the right default for a single lookup but wasteful once many rules
+ * evaluate against the same event: CompileSet already amortizes repeated
+ * conditions across rules via shared fieldSlots (planner.go), but even a
+ * slot-per-rule plan still pays json.Unmarshal once per distinct path
+ * prefix. CompilePath/PayloadView amortize at a lower level instead:
+ *
+ *   - CompilePath validates a path's limits (depth, wildcards, recursive
+ *     descents, filter predicates) once, producing a CompiledPath that
+ *     skips straight to traversal on every subsequent Resolve call - the
+ *     same role Compile plays for a whole rule, just for one path.
+ *   - PayloadView wraps one event's raw JSON and decodes it lazily, one
+ *     container level at a time, memoizing each decoded level by its
+ *     canonical pointer path (types.Path.String() of the concrete
+ *     segments walked to reach it). Two CompiledPaths sharing a prefix -
+ *     e.g. $.spec.containers[*].image and $.spec.containers[*].name -
+ *     decode that shared spec.containers level once between them instead
+ *     of once each.
+ *   - Object keys decoded while building a level are interned (see
+ *     intern) so thousands of sibling objects reusing the same field
+ *     names ("sku", "price", ...) share one string instead of allocating
+ *     a fresh one per occurrence.
+ *   - Memoized levels are bounded by the types.MaxCachedSubtrees constant
+ *     (a Limits override from types.WithLimits does not change it) via a
+ *     least-recently-used eviction list, so a CompiledPath with a
+ *     wildcard or filter segment walking a payload with many siblings
+ *     can't grow the view's memory proportional to payload size.
+ *
+ * Recursive descent segments don't benefit from level-at-a-time
+ * memoization - the search visits whatever subtree shape it finds, not a
+ * prefix shared across paths - so CompiledPath.Resolve decodes that one
+ * subtree in full and hands it to fieldpath.go's resolveRecursiveDescent,
+ * the same helper Resolve uses.
+ *
+ * A PayloadView is scoped to one event and is not safe for concurrent
+ * use; callers evaluating rules against the same event from multiple
+ * goroutines need one PayloadView per goroutine.
+ */
+
+// CompiledPath is a field path whose resource limits have already been
+// validated, ready to Resolve against any number of PayloadViews without
+// re-checking MaxPathDepth/MaxNestedWildcards/MaxRecursiveDescents/filter
+// predicates on every call.
+type CompiledPath struct {
+	path   []types.PathSegment
+	limits types.Limits
+}
+
+// CompilePath validates path once against the resource limits installed
+// into ctx via types.WithLimits (types.DefaultLimits() if ctx carries
+// none) and returns a CompiledPath ready for repeated Resolve calls.
+// Returns the same errors Resolve validates up front: ErrPathTooDeep,
+// ErrTooManyWildcards, ErrTooManyRecursiveDescents, or a filter validation
+// error from validateFilterExpr.
+func CompilePath(ctx context.Context, path []types.PathSegment) (*CompiledPath, error) {
+	limits := types.LimitsFromContext(ctx)
+	if len(path) > limits.MaxPathDepth {
+		return nil, types.ErrPathTooDeep
+	}
+
+	wildcardCount := 0
+	recursiveDescentCount := 0
+	for _, seg := range path {
+		if seg.Wildcard {
+			wildcardCount++
+		}
+		if seg.RecursiveDescent {
+			recursiveDescentCount++
+		}
+		if seg.Filter != nil {
+			if err := validateFilterExpr(limits, seg.Filter); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if wildcardCount > limits.MaxNestedWildcards {
+		return nil, types.ErrTooManyWildcards
+	}
+	if recursiveDescentCount > limits.MaxRecursiveDescents {
+		return nil, types.ErrTooManyRecursiveDescents
+	}
+
+	return &CompiledPath{path: path, limits: limits}, nil
+}
+
+// Resolve walks cp's path against view, decoding and memoizing only the
+// subtrees actually visited. Semantics match Resolve: ANY semantics for
+// wildcards/filters (first match wins), ErrFieldNotFound if the path
+// doesn't resolve.
+func (cp *CompiledPath) Resolve(view *PayloadView) (ResolveResult, error) {
+	return cp.resolveAt(view, cp.path, view.root, nil)
+}
+
+// resolveAt resolves path against raw, the not-yet-decoded JSON value at
+// resolvedSoFar's position in the payload.
+func (cp *CompiledPath) resolveAt(view *PayloadView, path []types.PathSegment, raw json.RawMessage, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if len(path) == 0 {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return ResolveResult{}, err
+		}
+		return ResolveResult{Value: v, ResolvedPath: resolvedSoFar, Found: true}, nil
+	}
+
+	seg := path[0]
+	remaining := path[1:]
+
+	if seg.RecursiveDescent {
+		var current any
+		if err := json.Unmarshal(raw, &current); err != nil {
+			return ResolveResult{}, err
+		}
+		visited := 0
+		return resolveRecursiveDescent(cp.limits, seg, remaining, current, resolvedSoFar, &visited)
+	}
+
+	lvl, err := view.level(types.Path(resolvedSoFar).String(), raw)
+	if err != nil {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	switch {
+	case lvl.isObject:
+		return cp.resolveObject(view, seg, remaining, lvl, resolvedSoFar)
+	case lvl.isArray:
+		return cp.resolveArray(view, seg, remaining, lvl, resolvedSoFar)
+	default:
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+}
+
+// resolveObject handles seg against an already-decoded object level.
+func (cp *CompiledPath) resolveObject(view *PayloadView, seg types.PathSegment, remaining []types.PathSegment, lvl *payloadLevel, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if seg.IsIndex {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if seg.Filter != nil {
+		for _, key := range sortedObjectKeys(lvl.object) {
+			raw := lvl.object[key]
+			var candidate any
+			if err := json.Unmarshal(raw, &candidate); err != nil {
+				return ResolveResult{}, err
+			}
+			if !evaluateFilterExpr(seg.Filter, candidate) {
+				continue
+			}
+			result, err := cp.resolveAt(view, remaining, raw, extendPath(resolvedSoFar, types.PathSegment{Key: key}))
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if seg.Wildcard {
+		for _, key := range sortedObjectKeys(lvl.object) {
+			raw := lvl.object[key]
+			result, err := cp.resolveAt(view, remaining, raw, extendPath(resolvedSoFar, types.PathSegment{Key: key}))
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	raw, ok := lvl.object[seg.Key]
+	if !ok {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+	return cp.resolveAt(view, remaining, raw, append(resolvedSoFar, seg))
+}
+
+// resolveArray handles seg against an already-decoded array level.
+func (cp *CompiledPath) resolveArray(view *PayloadView, seg types.PathSegment, remaining []types.PathSegment, lvl *payloadLevel, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if seg.Filter != nil {
+		for i, raw := range lvl.array {
+			var candidate any
+			if err := json.Unmarshal(raw, &candidate); err != nil {
+				return ResolveResult{}, err
+			}
+			if !evaluateFilterExpr(seg.Filter, candidate) {
+				continue
+			}
+			result, err := cp.resolveAt(view, remaining, raw, extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true}))
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if seg.Wildcard {
+		if len(lvl.array) == 0 {
+			return ResolveResult{}, types.ErrFieldNotFound
+		}
+		for i, raw := range lvl.array {
+			result, err := cp.resolveAt(view, remaining, raw, extendPath(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true}))
+			if err != nil && err != types.ErrFieldNotFound {
+				return ResolveResult{}, err
+			}
+			if err == nil && result.Found {
+				return result, nil
+			}
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if !seg.IsIndex {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	idx := seg.Index
+	if idx < 0 {
+		idx = len(lvl.array) + idx
+	}
+	if idx < 0 || idx >= len(lvl.array) {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+	resolvedSeg := types.PathSegment{Index: idx, IsIndex: true}
+	return cp.resolveAt(view, remaining, lvl.array[idx], append(resolvedSoFar, resolvedSeg))
+}
+
+// sortedObjectKeys returns obj's keys in sorted order, the same
+// deterministic iteration order Resolve/resolveRecursive use for wildcard
+// and filter segments.
+func sortedObjectKeys(obj map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// payloadLevel is one lazily-decoded level of a payload: an object or
+// array whose values are still-undecoded json.RawMessage. A scalar or null
+// is left undecoded and yields a level with neither flag set.
+type payloadLevel struct {
+	object   map[string]json.RawMessage
+	array    []json.RawMessage
+	isObject bool
+	isArray  bool
+}
+
+// decodePayloadLevel decodes exactly one level of raw: an object or array
+// decode into json.RawMessage children (left undecoded); anything else is
+// not decoded at all and returns an empty payloadLevel.
+func decodePayloadLevel(raw json.RawMessage) (*payloadLevel, error) {
+	trimmed := bytes.TrimLeft(raw, " \t\r\n")
+	if len(trimmed) == 0 {
+		return nil, types.ErrFieldNotFound
+	}
+
+	switch trimmed[0] {
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, err
+		}
+		return &payloadLevel{object: obj, isObject: true}, nil
+	case '[':
+		var arr []json.RawMessage
+		if err := json.Unmarshal(raw, &arr); err != nil {
+			return nil, err
+		}
+		return &payloadLevel{array: arr, isArray: true}, nil
+	default:
+		// Scalar or null: nothing to defer, but decodePayloadLevel is only
+		// ever reached for a segment that still needs to index into this
+		// value, so the caller treats a non-object/non-array level as
+		// ErrFieldNotFound the same way resolveRecursive does for a scalar.
+		return &payloadLevel{}, nil
+	}
+}
+
+// PayloadView is one event's raw JSON, decoded lazily one level at a time
+// as CompiledPath.Resolve calls need it, with decoded levels memoized by
+// canonical pointer path so multiple CompiledPaths sharing a prefix reuse
+// the same decode. Not safe for concurrent use.
+type PayloadView struct {
+	root json.RawMessage
+
+	cache     map[string]*payloadLevel
+	lru       *list.List
+	lruElems  map[string]*list.Element
+	maxCached int
+
+	keys map[string]string
+}
+
+// NewPayloadView creates a view over data with nothing decoded yet.
+// Bounds memoized subtrees to types.MaxCachedSubtrees.
+func NewPayloadView(data json.RawMessage) *PayloadView {
+	return &PayloadView{
+		root:      data,
+		cache:     make(map[string]*payloadLevel),
+		lru:       list.New(),
+		lruElems:  make(map[string]*list.Element),
+		maxCached: types.MaxCachedSubtrees,
+		keys:      make(map[string]string),
+	}
+}
+
+// level returns the decoded level at pointerPath, decoding and memoizing
+// raw (interning its keys if it's an object) on first access.
+func (v *PayloadView) level(pointerPath string, raw json.RawMessage) (*payloadLevel, error) {
+	if lvl, ok := v.cache[pointerPath]; ok {
+		v.touch(pointerPath)
+		return lvl, nil
+	}
+
+	lvl, err := decodePayloadLevel(raw)
+	if err != nil {
+		return nil, err
+	}
+	if lvl.isObject {
+		interned := make(map[string]json.RawMessage, len(lvl.object))
+		for k, val := range lvl.object {
+			interned[v.intern(k)] = val
+		}
+		lvl.object = interned
+	}
+
+	v.store(pointerPath, lvl)
+	return lvl, nil
+}
+
+// intern returns the canonical shared copy of key, so repeated key
+// strings decoded across many sibling objects allocate once instead of
+// once per occurrence.
+func (v *PayloadView) intern(key string) string {
+	if canonical, ok := v.keys[key]; ok {
+		return canonical
+	}
+	v.keys[key] = key
+	return key
+}
+
+// touch marks pointerPath as most recently used.
+func (v *PayloadView) touch(pointerPath string) {
+	if elem, ok := v.lruElems[pointerPath]; ok {
+		v.lru.MoveToFront(elem)
+	}
+}
+
+// store memoizes lvl at pointerPath, evicting the least-recently-used
+// entry if that would grow the cache past maxCached.
+func (v *PayloadView) store(pointerPath string, lvl *payloadLevel) {
+	v.cache[pointerPath] = lvl
+	v.lruElems[pointerPath] = v.lru.PushFront(pointerPath)
+
+	for len(v.cache) > v.maxCached {
+		oldest := v.lru.Back()
+		if oldest == nil {
+			break
+		}
+		key := oldest.Value.(string)
+		v.lru.Remove(oldest)
+		delete(v.lruElems, key)
+		delete(v.cache, key)
+	}
+}
@@ -0,0 +1,212 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+// TestCompiledPath_Resolve cross-checks CompiledPath.Resolve against
+// Resolve for the same cases fieldpath_test.go's TestResolve_Normal
+// covers, since CompiledPath must agree with Resolve on every payload
+// shape, just via the lazily-decoded PayloadView path instead.
+func TestCompiledPath_Resolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     []types.PathSegment
+		data     string
+		expected any
+		wantErr  error
+	}{
+		{
+			name:     "nested object traversal",
+			path:     []types.PathSegment{{Key: "user"}, {Key: "name"}},
+			data:     `{"user": {"name": "Alice"}}`,
+			expected: "Alice",
+		},
+		{
+			name:     "array index access",
+			path:     []types.PathSegment{{Key: "users"}, {Index: 0, IsIndex: true}, {Key: "name"}},
+			data:     `{"users": [{"name": "Bob"}]}`,
+			expected: "Bob",
+		},
+		{
+			name:     "negative array index relative from end",
+			path:     []types.PathSegment{{Index: -1, IsIndex: true}},
+			data:     `[1, 2, 3]`,
+			expected: float64(3),
+		},
+		{
+			name:     "wildcard on object sorted keys",
+			path:     []types.PathSegment{{Wildcard: true}, {Key: "value"}},
+			data:     `{"z": {"value": 1}, "a": {"value": 2}, "m": {"value": 3}}`,
+			expected: float64(2),
+		},
+		{
+			name: "filter segment on array",
+			path: []types.PathSegment{
+				{Key: "items"},
+				{Filter: &types.FilterExpr{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"}},
+				{Key: "price"},
+			},
+			data:     `{"items": [{"status": "inactive", "price": 1}, {"status": "active", "price": 42}]}`,
+			expected: float64(42),
+		},
+		{
+			name:    "missing field",
+			path:    []types.PathSegment{{Key: "missing"}},
+			data:    `{}`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:     "recursive descent",
+			path:     []types.PathSegment{{Key: "status", RecursiveDescent: true}},
+			data:     `{"spec": {"containers": [{"status": "running"}]}}`,
+			expected: "running",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp, err := CompilePath(context.Background(), tt.path)
+			if err != nil {
+				t.Fatalf("CompilePath() error = %v, want nil", err)
+			}
+
+			view := NewPayloadView(json.RawMessage(tt.data))
+			result, err := cp.Resolve(view)
+			if err != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !result.Found || result.Value != tt.expected {
+				t.Errorf("Resolve() = %v (found=%v), want %v", result.Value, result.Found, tt.expected)
+			}
+
+			want, err := Resolve(context.Background(), tt.path, json.RawMessage(tt.data))
+			if err != nil {
+				t.Fatalf("Resolve() (uncompiled) error = %v", err)
+			}
+			if want.Value != result.Value {
+				t.Errorf("CompiledPath.Resolve() diverged from Resolve(): %v != %v", result.Value, want.Value)
+			}
+		})
+	}
+}
+
+// TestCompilePath_Errors checks CompilePath enforces the same limits
+// Resolve validates at call time.
+func TestCompilePath_Errors(t *testing.T) {
+	path := make([]types.PathSegment, types.MaxPathDepth+1)
+	for i := range path {
+		path[i] = types.PathSegment{Key: "a"}
+	}
+	if _, err := CompilePath(context.Background(), path); err != types.ErrPathTooDeep {
+		t.Errorf("CompilePath() error = %v, want ErrPathTooDeep", err)
+	}
+
+	wildcards := []types.PathSegment{{Wildcard: true}, {Wildcard: true}, {Wildcard: true}}
+	if _, err := CompilePath(context.Background(), wildcards); err != types.ErrTooManyWildcards {
+		t.Errorf("CompilePath() error = %v, want ErrTooManyWildcards", err)
+	}
+}
+
+// TestPayloadView_SharesDecodedSubtrees verifies that two CompiledPaths
+// sharing a prefix only decode that prefix once between them.
+func TestPayloadView_SharesDecodedSubtrees(t *testing.T) {
+	data := json.RawMessage(`{"spec": {"containers": [{"name": "app", "image": "app:v1"}]}}`)
+	view := NewPayloadView(data)
+
+	namePath, err := CompilePath(context.Background(), []types.PathSegment{
+		{Key: "spec"}, {Key: "containers"}, {Index: 0, IsIndex: true}, {Key: "name"},
+	})
+	if err != nil {
+		t.Fatalf("CompilePath() error = %v", err)
+	}
+	imagePath, err := CompilePath(context.Background(), []types.PathSegment{
+		{Key: "spec"}, {Key: "containers"}, {Index: 0, IsIndex: true}, {Key: "image"},
+	})
+	if err != nil {
+		t.Fatalf("CompilePath() error = %v", err)
+	}
+
+	if _, err := namePath.Resolve(view); err != nil {
+		t.Fatalf("namePath.Resolve() error = %v", err)
+	}
+	cachedAfterFirst := len(view.cache)
+
+	result, err := imagePath.Resolve(view)
+	if err != nil {
+		t.Fatalf("imagePath.Resolve() error = %v", err)
+	}
+	if result.Value != "app:v1" {
+		t.Errorf("Resolve() = %v, want app:v1", result.Value)
+	}
+
+	// imagePath's last segment is a leaf (no further container to decode),
+	// and every container level above it - root, spec, containers,
+	// containers[0] - is already cached from namePath's resolve, so this
+	// second resolve shouldn't add any new cache entries at all.
+	if got := len(view.cache); got != cachedAfterFirst {
+		t.Errorf("len(view.cache) after second resolve = %v, want %v (shared prefix should not redecode)", got, cachedAfterFirst)
+	}
+}
+
+// TestPayloadView_InternsObjectKeys verifies that identical key strings
+// decoded from separate sibling objects share one underlying string.
+func TestPayloadView_InternsObjectKeys(t *testing.T) {
+	data := json.RawMessage(`{"items": [{"sku": "a"}, {"sku": "b"}]}`)
+	view := NewPayloadView(data)
+
+	cp, err := CompilePath(context.Background(), []types.PathSegment{
+		{Key: "items"}, {Index: 0, IsIndex: true}, {Key: "sku"},
+	})
+	if err != nil {
+		t.Fatalf("CompilePath() error = %v", err)
+	}
+	if _, err := cp.Resolve(view); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	cp2, err := CompilePath(context.Background(), []types.PathSegment{
+		{Key: "items"}, {Index: 1, IsIndex: true}, {Key: "sku"},
+	})
+	if err != nil {
+		t.Fatalf("CompilePath() error = %v", err)
+	}
+	if _, err := cp2.Resolve(view); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	// "items" is interned once decoding the root object; "sku" is interned
+	// once decoding the first element and reused (not re-added) for the
+	// second, so the table holds exactly these two distinct keys.
+	if len(view.keys) != 2 {
+		t.Errorf("len(view.keys) = %v, want 2 (distinct keys \"items\" and \"sku\", the latter shared across elements)", len(view.keys))
+	}
+}
+
+// TestPayloadView_EvictsLeastRecentlyUsed verifies the subtree cache
+// stays bounded at MaxCachedSubtrees by evicting the oldest entry.
+func TestPayloadView_EvictsLeastRecentlyUsed(t *testing.T) {
+	view := NewPayloadView(json.RawMessage(`{}`))
+	view.maxCached = 2
+
+	view.store("$.a", &payloadLevel{})
+	view.store("$.b", &payloadLevel{})
+	view.store("$.c", &payloadLevel{})
+
+	if len(view.cache) != 2 {
+		t.Fatalf("len(view.cache) = %v, want 2", len(view.cache))
+	}
+	if _, ok := view.cache["$.a"]; ok {
+		t.Errorf("$.a should have been evicted as least-recently-used")
+	}
+	if _, ok := view.cache["$.c"]; !ok {
+		t.Errorf("$.c (most recently stored) should still be cached")
+	}
+}
@@ -0,0 +1,534 @@
+// internal/rules/planner.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Cross-rule query planner.
+ *
+ * Compile optimizes one rule in isolation. At hundreds of rules, the
+ * bigger win is that many rules share the same FieldPath and the same
+ * OpEq/OpIn/OpPrefix/OpGt-family predicates on it. CompileSet builds a
+ * shared execution plan across a whole RuleSet:
+ *
+ *   1. Every distinct FieldPath (canonicalized via types.Path.String(), so
+ *      wildcards included) gets a stable fieldSlot; a payload is traversed
+ *      once per slot via EvaluateSet instead of once per condition.
+ *   2. OpEq/OpIn predicates on a slot build a value -> []condRef inverted
+ *      index (slotPlan.eq), so matching becomes a map lookup instead of a
+ *      scan over every rule referencing that slot.
+ *   3. OpPrefix predicates on a slot are kept sorted by prefix string so a
+ *      resolved value only needs to consider the prefixes lexicographically
+ *      <= itself - a genuine radix trie would share storage across
+ *      overlapping prefixes, but at the predicate counts this planner
+ *      targets the simpler sorted-slice-plus-prune gets the same
+ *      "don't scan every rule" property.
+ *   4. OpGt/OpGte/OpLt/OpLte predicates on a slot are kept in their own
+ *      threshold-sorted lists (one list per operator) so EvaluateSet binary
+ *      searches straight to the satisfying sub-slice instead of comparing
+ *      against every threshold.
+ *   5. DNF is preserved via a per-(rule, orGroup) bitset: each satisfied
+ *      condition sets its bit, and a rule matches as soon as any of its
+ *      group bitsets is full - the same short-circuit-on-any-group
+ *      semantics as Evaluate, just computed slot-by-slot instead of
+ *      rule-by-rule.
+ *
+ * Acceleration requires the resolved JSON value's native type to already
+ * match what Coerce would produce for the condition's declared FieldType
+ * (float64 for NUMERIC, string for TEXT, bool for BOOLEAN - exactly what
+ * encoding/json hands back for a well-typed payload). A condition whose
+ * literal doesn't satisfy that - along with OpNeq/OpSuffix/OpExists/
+ * OpIsNull/OpAnyOf/OpAllOf/OpCount/OpNoneOf and any FieldRef (cross-field)
+ * comparison - falls into slotPlan.other and is evaluated the same way
+ * Evaluate already does (evaluateCondition), once per slot-visit. Such a
+ * condition resolves its own path inside evaluateCondition, so it does not
+ * share the slot's single traversal.
+ *
+ * OnMissingField/OnCoercionFail policies (see compile.go/evaluate.go) are
+ * honored for accelerated conditions too: reconcilePolicies revisits any
+ * accelerated condRef the indices above left unmarked and applies
+ * applyMissingPolicy/applyCoercionPolicy the same way evaluateCondition
+ * would, so a rule authored with on_missing_field: MATCH behaves
+ * identically whether it's evaluated via Evaluate or EvaluateSet/Network.
+ *
+ * CompiledRule.Priority remains the correct standalone per-rule cost
+ * (Compile's fallback path, used when a rule is evaluated on its own via
+ * Evaluate). CompileSet's amortization shows up at the set level instead:
+ * SlotCost is the traversal cost actually paid (once per distinct slot),
+ * and NaiveLookupCost is what the same rules would have paid without
+ * sharing (once per condition) - see BenchmarkCompileSet_vs_Compile.
+ */
+
+// fieldSlot is a stable index into CompiledRuleSet's internal slot table,
+// shared by every condition (across every rule in the set) whose FieldPath
+// canonicalizes to the same string.
+type fieldSlot int
+
+// condRef identifies one condition within a CompiledRuleSet.
+type condRef struct {
+	ruleIdx  int
+	groupIdx int
+	condIdx  int
+}
+
+// prefixEntry is one OpPrefix predicate on a slot.
+type prefixEntry struct {
+	prefix string
+	ref    condRef
+}
+
+// rangeEntry is one numeric range predicate on a slot.
+type rangeEntry struct {
+	threshold float64
+	ref       condRef
+}
+
+// slotPlan holds every accelerated index for one fieldSlot, plus the
+// conditions on it that fall back to per-condition evaluation.
+type slotPlan struct {
+	path []types.PathSegment
+
+	eq  map[any][]condRef // OpEq/OpIn, keyed by the native-typed literal
+	gt  []rangeEntry       // sorted ascending by threshold
+	gte []rangeEntry       // sorted ascending by threshold
+	lt  []rangeEntry       // sorted ascending by threshold
+	lte []rangeEntry       // sorted ascending by threshold
+
+	prefix []prefixEntry // sorted ascending by prefix
+
+	other []condRef // not accelerated: evaluated via evaluateCondition
+
+	// accelerated mirrors every condRef filed into eq/gt/gte/lt/lte/prefix
+	// above (but not other). EvaluateSet walks it once per slot-visit to
+	// reconcile OnMissingMatch/OnCoercionMatch for conditions the fast path
+	// can't otherwise force-satisfy: the map/binary-search indices above
+	// only ever mark a condRef when the resolved value actually satisfies
+	// the operator, so a missing field or a value the literal's native
+	// type can't compare against leaves it unmarked with no further
+	// signal - the same as a genuine non-match, unless the condition's
+	// policy says otherwise.
+	accelerated []condRef
+
+	lookupCost int // pathLookupCost(path); amortized once per slot
+}
+
+// CompiledRuleSet is the shared execution plan CompileSet builds across a
+// RuleSet. Rules is parallel to the []*types.Rule passed to CompileSet.
+type CompiledRuleSet struct {
+	Rules []*CompiledRule
+
+	slots     []*slotPlan
+	slotIndex map[string]fieldSlot
+
+	// SlotCost is the total path-traversal cost (pathLookupCost) paid once
+	// per distinct slot - the amortized cost EvaluateSet actually incurs.
+	SlotCost int
+}
+
+// CompileSet compiles every rule (via Compile, so every existing
+// invariant - MaxPathDepth, MaxInOperatorValues, ErrWildcardInFieldRef,
+// and the rest - still applies per rule) and builds the shared slot
+// indices described in the package doc comment. Enforces
+// limits.MaxGroupConditions (types.ErrTooManyConditions) since the
+// per-group match state is a uint64 bitset, one bit per condition.
+func CompileSet(ctx context.Context, inputRules []*types.Rule) (*CompiledRuleSet, error) {
+	limits := types.LimitsFromContext(ctx)
+
+	set := &CompiledRuleSet{
+		Rules:     make([]*CompiledRule, len(inputRules)),
+		slotIndex: make(map[string]fieldSlot),
+	}
+
+	for ruleIdx, rule := range inputRules {
+		compiled, err := Compile(ctx, rule)
+		if err != nil {
+			return nil, err
+		}
+		set.Rules[ruleIdx] = compiled
+
+		for groupIdx, group := range compiled.OrGroups {
+			if len(group.Conditions) > limits.MaxGroupConditions {
+				return nil, types.ErrTooManyConditions
+			}
+			for condIdx, cond := range group.Conditions {
+				ref := condRef{ruleIdx: ruleIdx, groupIdx: groupIdx, condIdx: condIdx}
+				set.indexCondition(set.slotFor(cond.Path), cond, ref)
+			}
+		}
+	}
+
+	for _, sp := range set.slots {
+		sort.Slice(sp.prefix, func(i, j int) bool { return sp.prefix[i].prefix < sp.prefix[j].prefix })
+		sortRangeEntries(sp.gt)
+		sortRangeEntries(sp.gte)
+		sortRangeEntries(sp.lt)
+		sortRangeEntries(sp.lte)
+		sp.lookupCost = pathLookupCost(sp.path)
+		set.SlotCost += sp.lookupCost
+	}
+
+	return set, nil
+}
+
+func sortRangeEntries(entries []rangeEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].threshold < entries[j].threshold })
+}
+
+// slotFor returns the fieldSlot for path, creating one if this is the
+// first condition seen against this canonical path.
+func (set *CompiledRuleSet) slotFor(path []types.PathSegment) fieldSlot {
+	key := types.Path(path).String()
+	if slot, ok := set.slotIndex[key]; ok {
+		return slot
+	}
+	slot := fieldSlot(len(set.slots))
+	set.slots = append(set.slots, &slotPlan{path: path, eq: make(map[any][]condRef)})
+	set.slotIndex[key] = slot
+	return slot
+}
+
+// indexCondition files cond into slot's accelerated index for its
+// operator, falling back to slotPlan.other when the literal's type can't
+// be matched without running Coerce (see package doc comment).
+func (set *CompiledRuleSet) indexCondition(slot fieldSlot, cond CompiledCondition, ref condRef) {
+	sp := set.slots[slot]
+
+	if len(cond.FieldRef) == 0 {
+		switch cond.Operator {
+		case OpEq:
+			if isNativeValue(cond.Value, cond.FieldType) {
+				sp.eq[cond.Value] = append(sp.eq[cond.Value], ref)
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpIn:
+			if allNativeValues(cond.Values, cond.FieldType) {
+				for _, v := range cond.Values {
+					sp.eq[v] = append(sp.eq[v], ref)
+				}
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpPrefix:
+			if s, ok := cond.Value.(string); ok {
+				sp.prefix = append(sp.prefix, prefixEntry{prefix: s, ref: ref})
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpGt:
+			if f, ok := numericValue(cond.Value); ok {
+				sp.gt = append(sp.gt, rangeEntry{threshold: f, ref: ref})
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpGte:
+			if f, ok := numericValue(cond.Value); ok {
+				sp.gte = append(sp.gte, rangeEntry{threshold: f, ref: ref})
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpLt:
+			if f, ok := numericValue(cond.Value); ok {
+				sp.lt = append(sp.lt, rangeEntry{threshold: f, ref: ref})
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		case OpLte:
+			if f, ok := numericValue(cond.Value); ok {
+				sp.lte = append(sp.lte, rangeEntry{threshold: f, ref: ref})
+				sp.accelerated = append(sp.accelerated, ref)
+				return
+			}
+		}
+	}
+
+	sp.other = append(sp.other, ref)
+}
+
+// isNativeValue reports whether v is already the Go type Coerce would
+// produce for ft, so the accelerated eq index can compare it directly
+// without running Coerce per lookup.
+func isNativeValue(v any, ft FieldType) bool {
+	switch ft {
+	case FieldTypeNumeric:
+		_, ok := v.(float64)
+		return ok
+	case FieldTypeText:
+		_, ok := v.(string)
+		return ok
+	case FieldTypeBoolean:
+		_, ok := v.(bool)
+		return ok
+	default:
+		// FieldTypeAny/Unspecified: Coerce itself is a no-op (coerceAny),
+		// so raw equality already matches Coerce's semantics.
+		return true
+	}
+}
+
+// isHashable reports whether v can safely key the sp.eq map. Resolve/
+// ResolveAll can yield map[string]any or []any for a path ending on an
+// object or array, and neither is a valid Go map key - everything else
+// Coerce can produce (string, float64, bool, nil) is.
+func isHashable(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return false
+	default:
+		return true
+	}
+}
+
+func allNativeValues(values []any, ft FieldType) bool {
+	for _, v := range values {
+		if !isNativeValue(v, ft) {
+			return false
+		}
+	}
+	return true
+}
+
+// numericValue reports v as a float64 if it's one of the numeric types
+// Resolve/json.Unmarshal or a literal can produce.
+func numericValue(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	default:
+		return 0, false
+	}
+}
+
+// EvaluateSet evaluates every rule in set against payload using the shared
+// slot plan: each slot's path is resolved exactly once (via Resolve, or
+// ResolveAll when the slot's path contains a wildcard), and every
+// condition sharing that slot is matched against the resolved value(s)
+// through the accelerated indices (or, for slotPlan.other, via
+// evaluateCondition, which resolves the path again itself). Results are parallel to set.Rules. Enforces the same
+// resource limits as Evaluate, pulled from ctx the same way. eventKey is
+// forwarded to shouldSampleRule for every rule, exactly as Evaluate's does.
+func EvaluateSet(ctx context.Context, set *CompiledRuleSet, payload json.RawMessage, eventKey string) ([]MatchResult, error) {
+	bitsets := make([][]uint64, len(set.Rules))
+	for ruleIdx, rule := range set.Rules {
+		bitsets[ruleIdx] = make([]uint64, len(rule.OrGroups))
+	}
+
+	for slotIdx, sp := range set.slots {
+		values, err := set.resolveSlotValues(ctx, fieldSlot(slotIdx), payload)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range values {
+			if isHashable(v) {
+				for _, ref := range sp.eq[v] {
+					markSatisfied(bitsets, ref)
+				}
+			}
+			if s, ok := v.(string); ok {
+				for _, ref := range matchingPrefixes(sp.prefix, s) {
+					markSatisfied(bitsets, ref)
+				}
+			}
+			if f, ok := numericValue(v); ok {
+				for _, ref := range satisfyingRanges(sp, f) {
+					markSatisfied(bitsets, ref)
+				}
+			}
+		}
+
+		for _, ref := range sp.other {
+			cond := set.Rules[ref.ruleIdx].OrGroups[ref.groupIdx].Conditions[ref.condIdx]
+			matched, _, _, err := evaluateCondition(ctx, cond, payload)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				markSatisfied(bitsets, ref)
+			}
+		}
+
+		set.reconcilePolicies(bitsets, sp, values)
+	}
+
+	results := make([]MatchResult, len(set.Rules))
+	for ruleIdx, rule := range set.Rules {
+		result := MatchResult{RuleID: rule.RuleID, RuleName: rule.Name, Action: rule.Action}
+
+		if shouldSampleRule(ctx, rule, payload, eventKey) {
+			for groupIdx, group := range rule.OrGroups {
+				if len(group.Conditions) == 0 {
+					continue
+				}
+				full := uint64(1)<<uint(len(group.Conditions)) - 1
+				if bitsets[ruleIdx][groupIdx] == full {
+					result.Matched = true
+					result.MatchedCondition = []any{"any", groupIdx, "all"}
+					break
+				}
+			}
+		}
+
+		results[ruleIdx] = result
+	}
+	return results, nil
+}
+
+func markSatisfied(bitsets [][]uint64, ref condRef) {
+	bitsets[ref.ruleIdx][ref.groupIdx] |= 1 << uint(ref.condIdx)
+}
+
+func isSatisfied(bitsets [][]uint64, ref condRef) bool {
+	return bitsets[ref.ruleIdx][ref.groupIdx]&(1<<uint(ref.condIdx)) != 0
+}
+
+// reconcilePolicies applies OnMissingMatch/OnCoercionMatch to sp's
+// accelerated condRefs that the map/binary-search indices left unmarked.
+// The indices only ever mark a condRef when the resolved value genuinely
+// satisfies the operator, so they can't distinguish "field missing" or
+// "value isn't the literal's native type" from an ordinary non-match - both
+// look identical to evaluateCondition's fallback, whose policy handling
+// this mirrors (see applyMissingPolicy/applyCoercionPolicy).
+func (set *CompiledRuleSet) reconcilePolicies(bitsets [][]uint64, sp *slotPlan, values []any) {
+	for _, ref := range sp.accelerated {
+		if isSatisfied(bitsets, ref) {
+			continue
+		}
+		cond := set.Rules[ref.ruleIdx].OrGroups[ref.groupIdx].Conditions[ref.condIdx]
+
+		if len(values) == 0 {
+			if applyMissingPolicy(cond.OnMissing) {
+				markSatisfied(bitsets, ref)
+			}
+			continue
+		}
+
+		if !coercesToFieldType(values[0], cond.FieldType, cond.PreserveIntegers) {
+			if applyCoercionPolicy(cond.OnCoercion) {
+				markSatisfied(bitsets, ref)
+			}
+		}
+	}
+}
+
+// coercesToFieldType reports whether CoerceWithOptions would succeed for v
+// against ft, without needing the resulting value - reconcilePolicies only
+// cares whether a non-match was a genuine comparison failure or a coercion
+// failure.
+func coercesToFieldType(v any, ft FieldType, preserveIntegers bool) bool {
+	_, err := CoerceWithOptions(v, ft, CoerceOptions{PreserveIntegers: preserveIntegers})
+	return err == nil
+}
+
+// matchingPrefixes returns every prefixEntry whose prefix is an actual
+// prefix of s. sorted is ascending by prefix string; any genuine prefix of
+// s is lexicographically <= s, so entries after the first one > s can be
+// skipped without checking strings.HasPrefix.
+func matchingPrefixes(sorted []prefixEntry, s string) []condRef {
+	limit := sort.Search(len(sorted), func(i int) bool { return sorted[i].prefix > s })
+	var refs []condRef
+	for _, entry := range sorted[:limit] {
+		if strings.HasPrefix(s, entry.prefix) {
+			refs = append(refs, entry.ref)
+		}
+	}
+	return refs
+}
+
+// satisfyingRanges binary-searches sp's four threshold-sorted lists for
+// the entries f satisfies, instead of testing every numeric predicate on
+// the slot.
+func satisfyingRanges(sp *slotPlan, f float64) []condRef {
+	var refs []condRef
+	// f > threshold: thresholds strictly less than f.
+	idx := sort.Search(len(sp.gt), func(i int) bool { return sp.gt[i].threshold >= f })
+	for _, e := range sp.gt[:idx] {
+		refs = append(refs, e.ref)
+	}
+	// f >= threshold: thresholds less than or equal to f.
+	idx = sort.Search(len(sp.gte), func(i int) bool { return sp.gte[i].threshold > f })
+	for _, e := range sp.gte[:idx] {
+		refs = append(refs, e.ref)
+	}
+	// f < threshold: thresholds strictly greater than f.
+	idx = sort.Search(len(sp.lt), func(i int) bool { return sp.lt[i].threshold > f })
+	for _, e := range sp.lt[idx:] {
+		refs = append(refs, e.ref)
+	}
+	// f <= threshold: thresholds greater than or equal to f.
+	idx = sort.Search(len(sp.lte), func(i int) bool { return sp.lte[i].threshold >= f })
+	for _, e := range sp.lte[idx:] {
+		refs = append(refs, e.ref)
+	}
+	return refs
+}
+
+// resolveSlotValues resolves slot's path against payload exactly once:
+// Resolve for a wildcard-free path (single value), ResolveAll when the
+// path contains a wildcard (every matching leaf).
+func (set *CompiledRuleSet) resolveSlotValues(ctx context.Context, slot fieldSlot, payload json.RawMessage) ([]any, error) {
+	sp := set.slots[slot]
+
+	hasWildcard := false
+	for _, seg := range sp.path {
+		if seg.Wildcard {
+			hasWildcard = true
+			break
+		}
+	}
+
+	if hasWildcard {
+		results, err := ResolveAll(ctx, sp.path, payload)
+		if err != nil {
+			if err == types.ErrFieldNotFound {
+				return nil, nil
+			}
+			return nil, err
+		}
+		values := make([]any, 0, len(results))
+		for _, r := range results {
+			values = append(values, r.Value)
+		}
+		return values, nil
+	}
+
+	result, err := Resolve(ctx, sp.path, payload)
+	if err != nil {
+		if err == types.ErrFieldNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !result.Found {
+		return nil, nil
+	}
+	return []any{result.Value}, nil
+}
+
+// NaiveLookupCost sums pathLookupCost(cond.Path) once per (rule,
+// condition) across compiledRules - what evaluating each rule standalone
+// pays in path traversal, with no sharing across rules. Comparing it
+// against CompiledRuleSet.SlotCost is what demonstrates CompileSet's
+// traversal-sharing win as rule count grows.
+func NaiveLookupCost(compiledRules []*CompiledRule) int {
+	total := 0
+	for _, rule := range compiledRules {
+		for _, group := range rule.OrGroups {
+			for _, cond := range group.Conditions {
+				total += pathLookupCost(cond.Path)
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,341 @@
+// internal/rules/planner_test.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+func eqRule(ruleID types.RuleID, path string, value any, ft FieldType) *types.Rule {
+	return &types.Rule{
+		RuleID:     ruleID,
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: path}}, Operator: int(OpEq), FieldType: int(ft), Value: value},
+			}},
+		},
+	}
+}
+
+func TestCompileSet_SharesFieldSlotAcrossRules(t *testing.T) {
+	rules := []*types.Rule{
+		eqRule("rule-a", "status", "active", FieldTypeText),
+		eqRule("rule-b", "status", "inactive", FieldTypeText),
+	}
+
+	set, err := CompileSet(context.Background(), rules)
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+	if len(set.slots) != 1 {
+		t.Fatalf("len(slots) = %v, want 1 (both rules share the status slot)", len(set.slots))
+	}
+	if len(set.slots[0].eq) != 2 {
+		t.Errorf("len(slots[0].eq) = %v, want 2 distinct values indexed", len(set.slots[0].eq))
+	}
+}
+
+func TestEvaluateSet_EqIndexMatches(t *testing.T) {
+	rules := []*types.Rule{
+		eqRule("rule-active", "status", "active", FieldTypeText),
+		eqRule("rule-inactive", "status", "inactive", FieldTypeText),
+	}
+	set, err := CompileSet(context.Background(), rules)
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"status": "active"}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if !results[0].Matched {
+		t.Errorf("results[0].Matched = false, want true (status == active)")
+	}
+	if results[1].Matched {
+		t.Errorf("results[1].Matched = true, want false (status != inactive)")
+	}
+}
+
+func TestEvaluateSet_PrefixIndexMatches(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-prefix",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+			{FieldPath: []types.PathSegment{{Key: "path"}}, Operator: int(OpPrefix), FieldType: int(FieldTypeText), Value: "/api/"},
+		}}},
+	}
+	set, err := CompileSet(context.Background(), []*types.Rule{rule})
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"path": "/api/v1/users"}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if !results[0].Matched {
+		t.Errorf("results[0].Matched = false, want true (/api/v1/users has prefix /api/)")
+	}
+}
+
+func TestEvaluateSet_RangeIndexMatches(t *testing.T) {
+	rule := func(id types.RuleID, op Operator, threshold float64) *types.Rule {
+		return &types.Rule{
+			RuleID:     id,
+			SampleRate: 1.0,
+			Action:     int(ActionObserve),
+			OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: "amount"}}, Operator: int(op), FieldType: int(FieldTypeNumeric), Value: threshold},
+			}}},
+		}
+	}
+	rules := []*types.Rule{
+		rule("rule-gt", OpGt, 100),
+		rule("rule-lt", OpLt, 100),
+		rule("rule-gte", OpGte, 150),
+		rule("rule-lte", OpLte, 50),
+	}
+	set, err := CompileSet(context.Background(), rules)
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"amount": 150}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	want := map[string]bool{"rule-gt": true, "rule-lt": false, "rule-gte": true, "rule-lte": false}
+	for _, r := range results {
+		if r.Matched != want[string(r.RuleID)] {
+			t.Errorf("rule %s: Matched = %v, want %v", r.RuleID, r.Matched, want[string(r.RuleID)])
+		}
+	}
+}
+
+func TestEvaluateSet_PreservesDNFOrSemantics(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-dnf",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: "status"}}, Operator: int(OpEq), FieldType: int(FieldTypeText), Value: "active"},
+				{FieldPath: []types.PathSegment{{Key: "amount"}}, Operator: int(OpGt), FieldType: int(FieldTypeNumeric), Value: 1000.0},
+			}},
+			{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: "priority"}}, Operator: int(OpEq), FieldType: int(FieldTypeNumeric), Value: 5.0},
+			}},
+		},
+	}
+	set, err := CompileSet(context.Background(), []*types.Rule{rule})
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	// Neither group fully satisfied: first group's amount condition fails,
+	// second group's priority doesn't match.
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"status": "active", "amount": 10, "priority": 1}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if results[0].Matched {
+		t.Errorf("Matched = true, want false (neither OR group fully satisfied)")
+	}
+
+	// Second group alone satisfied.
+	results, err = EvaluateSet(context.Background(), set, json.RawMessage(`{"priority": 5}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if !results[0].Matched {
+		t.Errorf("Matched = false, want true (second OR group satisfied)")
+	}
+}
+
+func TestEvaluateSet_MatchesEvaluate(t *testing.T) {
+	ruleSpecs := []*types.Rule{
+		eqRule("rule-1", "status", "active", FieldTypeText),
+		{
+			RuleID:     "rule-2",
+			SampleRate: 1.0,
+			Action:     int(ActionObserve),
+			OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: "user"}}, Operator: int(OpExists)},
+			}}},
+		},
+		{
+			RuleID:     "rule-3",
+			SampleRate: 1.0,
+			Action:     int(ActionDrop),
+			OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+				{FieldPath: []types.PathSegment{{Key: "amount"}}, Operator: int(OpGte), FieldType: int(FieldTypeNumeric), Value: 50.0},
+			}}},
+		},
+	}
+	payload := json.RawMessage(`{"status": "active", "user": {"id": 1}, "amount": 75}`)
+
+	set, err := CompileSet(context.Background(), ruleSpecs)
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+	setResults, err := EvaluateSet(context.Background(), set, payload, "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+
+	for i, spec := range ruleSpecs {
+		compiled, err := Compile(context.Background(), spec)
+		if err != nil {
+			t.Fatalf("Compile() error = %v, want nil", err)
+		}
+		want, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v, want nil", err)
+		}
+		if setResults[i].Matched != want.Matched {
+			t.Errorf("rule %d: EvaluateSet Matched = %v, want %v (from Evaluate)", i, setResults[i].Matched, want.Matched)
+		}
+	}
+}
+
+func TestEvaluateSet_OnMissingMatch(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-on-missing",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+			{FieldPath: []types.PathSegment{{Key: "absent"}}, Operator: int(OpEq), FieldType: int(FieldTypeText), Value: "x", OnMissingField: int(OnMissingMatch)},
+		}}},
+	}
+	set, err := CompileSet(context.Background(), []*types.Rule{rule})
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"other": 1}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if !results[0].Matched {
+		t.Errorf("Matched = false, want true (missing field, OnMissingMatch)")
+	}
+}
+
+func TestEvaluateSet_OnCoercionMatch(t *testing.T) {
+	rule := &types.Rule{
+		RuleID:     "rule-on-coercion",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+			{FieldPath: []types.PathSegment{{Key: "amount"}}, Operator: int(OpGt), FieldType: int(FieldTypeNumeric), Value: 100.0, OnCoercionFail: int(OnCoercionMatch)},
+		}}},
+	}
+	set, err := CompileSet(context.Background(), []*types.Rule{rule})
+	if err != nil {
+		t.Fatalf("CompileSet() error = %v, want nil", err)
+	}
+
+	// "amount" resolves but is a bool, which OpGt's NUMERIC coercion can't
+	// produce a number from - OnCoercionMatch should still fire the token.
+	results, err := EvaluateSet(context.Background(), set, json.RawMessage(`{"amount": true}`), "")
+	if err != nil {
+		t.Fatalf("EvaluateSet() error = %v, want nil", err)
+	}
+	if !results[0].Matched {
+		t.Errorf("Matched = false, want true (coercion fails, OnCoercionMatch)")
+	}
+}
+
+func TestCompileSet_RejectsOversizedGroup(t *testing.T) {
+	conditions := make([]types.Condition, types.MaxGroupConditions+1)
+	for i := range conditions {
+		conditions[i] = types.Condition{
+			FieldPath: []types.PathSegment{{Key: fmt.Sprintf("field%d", i)}},
+			Operator:  int(OpExists),
+		}
+	}
+	rule := &types.Rule{
+		RuleID:     "rule-huge",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups:   []types.OrGroup{{Conditions: conditions}},
+	}
+
+	_, err := CompileSet(context.Background(), []*types.Rule{rule})
+	if err != types.ErrTooManyConditions {
+		t.Errorf("CompileSet() error = %v, want ErrTooManyConditions", err)
+	}
+}
+
+// BenchmarkCompileSet_vs_Compile compares the traversal cost CompileSet
+// amortizes across a RuleSet (SlotCost: once per distinct slot) against
+// what the same rules pay with no sharing (NaiveLookupCost: once per
+// condition) - and runs EvaluateSet against the per-rule Evaluate loop it
+// replaces, at increasing rule counts, to show the planner's win scaling
+// with rule count on a synthetic sensor workload (rules sharing a small
+// set of fields: status/amount/path, as a real sensor ruleset would).
+func BenchmarkCompileSet_vs_Compile(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("rules=%d", n), func(b *testing.B) {
+			ruleSpecs := make([]*types.Rule, n)
+			for i := 0; i < n; i++ {
+				ruleSpecs[i] = syntheticSensorRule(i)
+			}
+			payload := json.RawMessage(`{"status": "active", "amount": 150, "path": "/api/v1/orders", "user": {"id": 1}}`)
+
+			b.Run("NaiveLookupCost", func(b *testing.B) {
+				compiled := make([]*CompiledRule, n)
+				for i, spec := range ruleSpecs {
+					c, err := Compile(context.Background(), spec)
+					if err != nil {
+						b.Fatalf("Compile() error = %v", err)
+					}
+					compiled[i] = c
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					for _, c := range compiled {
+						_, _ = Evaluate(context.Background(), c, payload, "")
+					}
+					_ = NaiveLookupCost(compiled)
+				}
+			})
+
+			b.Run("CompileSet", func(b *testing.B) {
+				set, err := CompileSet(context.Background(), ruleSpecs)
+				if err != nil {
+					b.Fatalf("CompileSet() error = %v", err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					_, _ = EvaluateSet(context.Background(), set, payload, "")
+				}
+			})
+		})
+	}
+}
+
+// syntheticSensorRule builds a rule over a small, shared field set (status/
+// amount/path) the way a real sensor's hundreds of rules would, so
+// BenchmarkCompileSet_vs_Compile exercises CompileSet's slot sharing
+// instead of measuring hundreds of disjoint fields.
+func syntheticSensorRule(i int) *types.Rule {
+	return &types.Rule{
+		RuleID:     types.RuleID(fmt.Sprintf("synthetic-%d", i)),
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{{Conditions: []types.Condition{
+			{FieldPath: []types.PathSegment{{Key: "status"}}, Operator: int(OpEq), FieldType: int(FieldTypeText), Value: "active"},
+			{FieldPath: []types.PathSegment{{Key: "amount"}}, Operator: int(OpGt), FieldType: int(FieldTypeNumeric), Value: float64(i % 200)},
+			{FieldPath: []types.PathSegment{{Key: "path"}}, Operator: int(OpPrefix), FieldType: int(FieldTypeText), Value: "/api/"},
+		}}},
+	}
+}
@@ -0,0 +1,134 @@
+// internal/rules/quantifier.go
+package rules
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * ANY_OF / ALL_OF / COUNT / NONE_OF quantified conditions.
+ *
+ * These operate over every leaf a wildcard path reaches via ResolveAll,
+ * instead of the first-match Resolve() the other operators use. Each
+ * matched leaf is compared with Quantifier.Operator/Value (e.g. GT 100
+ * for "any item over $100"); COUNT additionally compares how many leaves
+ * satisfied that per-element comparison against Quantifier.CountValue.
+ *
+ * Empty-result handling distinguishes two cases:
+ *   - The wildcard's array container itself is absent (the field the
+ *     wildcard segment walks doesn't exist at all): defers to
+ *     on_missing_field, same as a single-value condition whose field is
+ *     absent.
+ *   - The array container is present but has zero elements: the quantifier
+ *     itself defines the answer regardless of on_missing_field - ANY_OF is
+ *     false (nothing satisfied it), ALL_OF and NONE_OF are true (vacuously,
+ *     nothing failed to satisfy/violate it), and COUNT compares a count of
+ *     zero against Quantifier.CountValue same as it would any other count.
+ */
+
+// evaluateQuantifiedCondition evaluates ANY_OF/ALL_OF/COUNT/NONE_OF over
+// every leaf cond.Path resolves to.
+func evaluateQuantifiedCondition(ctx context.Context, cond CompiledCondition, payload json.RawMessage) (bool, []types.PathSegment, any, error) {
+	if cond.Quantifier == nil {
+		return false, nil, nil, types.ErrInvalidOperator
+	}
+
+	results, err := ResolveAll(ctx, cond.Path, payload)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	if len(results) == 0 {
+		if containerIsEmptyArray(ctx, cond.Path, payload) {
+			return evaluateEmptyQuantifier(cond), nil, nil, nil
+		}
+		return applyMissingPolicy(cond.OnMissing), nil, nil, nil
+	}
+
+	elemOp := Operator(cond.Quantifier.Operator)
+	matchCount := 0
+	var firstField []types.PathSegment
+	var firstValue any
+
+	for _, r := range results {
+		coerced, err := Coerce(r.Value, cond.FieldType)
+		if err != nil {
+			if err == types.ErrCoercionFailed {
+				continue
+			}
+			return false, nil, nil, err
+		}
+		if coerced.IsNull {
+			continue
+		}
+		if Compare(elemOp, coerced.Value, cond.Quantifier.Value) {
+			matchCount++
+			if firstField == nil {
+				firstField = r.ResolvedPath
+				firstValue = coerced.Value
+			}
+		}
+	}
+
+	switch cond.Operator {
+	case OpAnyOf:
+		return matchCount > 0, firstField, firstValue, nil
+	case OpAllOf:
+		return matchCount == len(results), firstField, firstValue, nil
+	case OpNoneOf:
+		return matchCount == 0, firstField, firstValue, nil
+	case OpCount:
+		countOp := Operator(cond.Quantifier.CountOperator)
+		matched := Compare(countOp, float64(matchCount), cond.Quantifier.CountValue)
+		return matched, firstField, firstValue, nil
+	default:
+		return false, nil, nil, types.ErrInvalidOperator
+	}
+}
+
+// evaluateEmptyQuantifier returns the quantifier's defined answer for a
+// wildcard container that's present but has zero elements: ANY_OF is false
+// (nothing satisfied it), ALL_OF/NONE_OF are vacuously true, and COUNT
+// compares zero matches against Quantifier.CountValue like any other count.
+func evaluateEmptyQuantifier(cond CompiledCondition) bool {
+	switch cond.Operator {
+	case OpAnyOf:
+		return false
+	case OpAllOf, OpNoneOf:
+		return true
+	case OpCount:
+		countOp := Operator(cond.Quantifier.CountOperator)
+		return Compare(countOp, float64(0), cond.Quantifier.CountValue)
+	default:
+		return false
+	}
+}
+
+// containerIsEmptyArray reports whether the array a wildcard path walks is
+// present with zero elements, as opposed to absent entirely. path is
+// truncated at its first wildcard segment and resolved with plain Resolve
+// (no wildcard expansion needed for a single-container lookup); a resolve
+// error or a non-array/non-empty result both report false, deferring to
+// on_missing_field the same as before this distinction existed.
+func containerIsEmptyArray(ctx context.Context, path []types.PathSegment, payload json.RawMessage) bool {
+	idx := -1
+	for i, seg := range path {
+		if seg.Wildcard {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	resolved, err := Resolve(ctx, path[:idx], payload)
+	if err != nil || !resolved.Found {
+		return false
+	}
+
+	arr, ok := resolved.Value.([]any)
+	return ok && len(arr) == 0
+}
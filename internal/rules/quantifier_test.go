@@ -0,0 +1,361 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+func compileSingleConditionRule(t *testing.T, cond types.Condition) *CompiledRule {
+	t.Helper()
+	rule := &types.Rule{
+		RuleID:     "quantifier-rule",
+		Name:       "quantifier-rule",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{Conditions: []types.Condition{cond}},
+		},
+	}
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		t.Fatalf("Compile() error = %v, want nil", err)
+	}
+	return compiled
+}
+
+func TestEvaluate_AnyOf(t *testing.T) {
+	cond := types.Condition{
+		FieldPath: []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+		Operator:  int(OpAnyOf),
+		FieldType: int(FieldTypeNumeric),
+		Quantifier: &types.QuantifierClause{
+			Operator: int(OpGt),
+			Value:    float64(100),
+		},
+		OnMissingField: int(OnMissingSkip),
+		OnCoercionFail: int(OnCoercionSkip),
+	}
+	compiled := compileSingleConditionRule(t, cond)
+
+	t.Run("matches when any item exceeds threshold", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": 150}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("no match when every item under threshold", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": 20}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false")
+		}
+	})
+
+	t.Run("missing field defers to on_missing_field", func(t *testing.T) {
+		payload := json.RawMessage(`{}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false (OnMissingSkip)")
+		}
+	})
+}
+
+func TestEvaluate_AllOf(t *testing.T) {
+	cond := types.Condition{
+		FieldPath: []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+		Operator:  int(OpAllOf),
+		FieldType: int(FieldTypeNumeric),
+		Quantifier: &types.QuantifierClause{
+			Operator: int(OpGt),
+			Value:    float64(0),
+		},
+		OnMissingField: int(OnMissingSkip),
+		OnCoercionFail: int(OnCoercionSkip),
+	}
+	compiled := compileSingleConditionRule(t, cond)
+
+	t.Run("matches when every item satisfies", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": 20}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("no match when one item fails", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": -5}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false")
+		}
+	})
+}
+
+func TestEvaluate_Count(t *testing.T) {
+	cond := types.Condition{
+		FieldPath: []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+		Operator:  int(OpCount),
+		FieldType: int(FieldTypeNumeric),
+		Quantifier: &types.QuantifierClause{
+			Operator:      int(OpGt),
+			Value:         float64(100),
+			CountOperator: int(OpGte),
+			CountValue:    float64(2),
+		},
+		OnMissingField: int(OnMissingSkip),
+		OnCoercionFail: int(OnCoercionSkip),
+	}
+	compiled := compileSingleConditionRule(t, cond)
+
+	t.Run("matches when count threshold met", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 150}, {"price": 200}, {"price": 10}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("no match when count threshold not met", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 150}, {"price": 10}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false")
+		}
+	})
+}
+
+func TestEvaluate_NoneOf(t *testing.T) {
+	cond := types.Condition{
+		FieldPath: []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+		Operator:  int(OpNoneOf),
+		FieldType: int(FieldTypeNumeric),
+		Quantifier: &types.QuantifierClause{
+			Operator: int(OpLt),
+			Value:    float64(0),
+		},
+		OnMissingField: int(OnMissingSkip),
+		OnCoercionFail: int(OnCoercionSkip),
+	}
+	compiled := compileSingleConditionRule(t, cond)
+
+	t.Run("matches when no item satisfies", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": 20}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("no match when one item satisfies", func(t *testing.T) {
+		payload := json.RawMessage(`{"items": [{"price": 10}, {"price": -5}]}`)
+		result, err := Evaluate(context.Background(), compiled, payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false")
+		}
+	})
+}
+
+// TestEvaluate_Quantifier_EmptyArraySemantics covers the case the array the
+// wildcard walks is present but has zero elements - distinct from the
+// field being absent entirely, which still defers to on_missing_field (see
+// TestEvaluate_AnyOf's "missing field" subtest).
+func TestEvaluate_Quantifier_EmptyArraySemantics(t *testing.T) {
+	payload := json.RawMessage(`{"items": []}`)
+
+	t.Run("ANY_OF is false on empty array", func(t *testing.T) {
+		cond := types.Condition{
+			FieldPath:      []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			Operator:       int(OpAnyOf),
+			FieldType:      int(FieldTypeNumeric),
+			Quantifier:     &types.QuantifierClause{Operator: int(OpGt), Value: float64(0)},
+			OnMissingField: int(OnMissingMatch), // would flip the result if empty fell through to on_missing_field
+		}
+		result, err := Evaluate(context.Background(), compileSingleConditionRule(t, cond), payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if result.Matched {
+			t.Error("Matched = true, want false")
+		}
+	})
+
+	t.Run("ALL_OF is true on empty array", func(t *testing.T) {
+		cond := types.Condition{
+			FieldPath:      []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			Operator:       int(OpAllOf),
+			FieldType:      int(FieldTypeNumeric),
+			Quantifier:     &types.QuantifierClause{Operator: int(OpGt), Value: float64(0)},
+			OnMissingField: int(OnMissingSkip), // would flip the result if empty fell through to on_missing_field
+		}
+		result, err := Evaluate(context.Background(), compileSingleConditionRule(t, cond), payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("NONE_OF is true on empty array", func(t *testing.T) {
+		cond := types.Condition{
+			FieldPath:      []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			Operator:       int(OpNoneOf),
+			FieldType:      int(FieldTypeNumeric),
+			Quantifier:     &types.QuantifierClause{Operator: int(OpGt), Value: float64(0)},
+			OnMissingField: int(OnMissingSkip),
+		}
+		result, err := Evaluate(context.Background(), compileSingleConditionRule(t, cond), payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true")
+		}
+	})
+
+	t.Run("COUNT compares zero matches on empty array", func(t *testing.T) {
+		cond := types.Condition{
+			FieldPath: []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			Operator:  int(OpCount),
+			FieldType: int(FieldTypeNumeric),
+			Quantifier: &types.QuantifierClause{
+				Operator:      int(OpGt),
+				Value:         float64(0),
+				CountOperator: int(OpEq),
+				CountValue:    float64(0),
+			},
+			OnMissingField: int(OnMissingSkip),
+		}
+		result, err := Evaluate(context.Background(), compileSingleConditionRule(t, cond), payload, "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true (count of 0 == 0)")
+		}
+	})
+
+	t.Run("absent field (not an empty array) still defers to on_missing_field", func(t *testing.T) {
+		cond := types.Condition{
+			FieldPath:      []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			Operator:       int(OpAnyOf),
+			FieldType:      int(FieldTypeNumeric),
+			Quantifier:     &types.QuantifierClause{Operator: int(OpGt), Value: float64(0)},
+			OnMissingField: int(OnMissingMatch),
+		}
+		result, err := Evaluate(context.Background(), compileSingleConditionRule(t, cond), json.RawMessage(`{}`), "")
+		if err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+		if !result.Matched {
+			t.Error("Matched = false, want true (OnMissingMatch)")
+		}
+	})
+}
+
+func BenchmarkEvaluate_QuantifierDeepArray(b *testing.B) {
+	const n = 1000
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"price": %d, "tags": {"nested": {"deep": [%d, %d, %d]}}}`, i, i, i+1, i+2)
+	}
+	payload := json.RawMessage(`{"items": [` + strings.Join(items, ",") + `]}`)
+
+	cond := types.Condition{
+		FieldPath:      []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+		Operator:       int(OpAnyOf),
+		FieldType:      int(FieldTypeNumeric),
+		Quantifier:     &types.QuantifierClause{Operator: int(OpGt), Value: float64(n + 1)},
+		OnMissingField: int(OnMissingSkip),
+		OnCoercionFail: int(OnCoercionSkip),
+	}
+	compiled := compileSingleConditionRuleB(b, cond)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Evaluate(context.Background(), compiled, payload, "")
+	}
+}
+
+func compileSingleConditionRuleB(b *testing.B, cond types.Condition) *CompiledRule {
+	b.Helper()
+	rule := &types.Rule{
+		RuleID:     "quantifier-bench-rule",
+		Name:       "quantifier-bench-rule",
+		SampleRate: 1.0,
+		Action:     int(ActionObserve),
+		OrGroups: []types.OrGroup{
+			{Conditions: []types.Condition{cond}},
+		},
+	}
+	compiled, err := Compile(context.Background(), rule)
+	if err != nil {
+		b.Fatalf("Compile() error = %v, want nil", err)
+	}
+	return compiled
+}
+
+func TestCompile_QuantifierRequiresWildcardAndClause(t *testing.T) {
+	t.Run("rejects ANY_OF without a wildcard", func(t *testing.T) {
+		rule := &types.Rule{
+			RuleID: "bad-rule", SampleRate: 1.0,
+			OrGroups: []types.OrGroup{{Conditions: []types.Condition{{
+				FieldPath:  []types.PathSegment{{Key: "price"}},
+				Operator:   int(OpAnyOf),
+				FieldType:  int(FieldTypeNumeric),
+				Quantifier: &types.QuantifierClause{Operator: int(OpGt), Value: float64(1)},
+			}}}},
+		}
+		if _, err := Compile(context.Background(), rule); err != types.ErrInvalidOperator {
+			t.Errorf("Compile() error = %v, want ErrInvalidOperator", err)
+		}
+	})
+
+	t.Run("rejects ANY_OF without a quantifier clause", func(t *testing.T) {
+		rule := &types.Rule{
+			RuleID: "bad-rule", SampleRate: 1.0,
+			OrGroups: []types.OrGroup{{Conditions: []types.Condition{{
+				FieldPath: []types.PathSegment{{Wildcard: true}, {Key: "price"}},
+				Operator:  int(OpAnyOf),
+				FieldType: int(FieldTypeNumeric),
+			}}}},
+		}
+		if _, err := Compile(context.Background(), rule); err != types.ErrInvalidOperator {
+			t.Errorf("Compile() error = %v, want ErrInvalidOperator", err)
+		}
+	})
+}
@@ -0,0 +1,398 @@
+// internal/rules/resolve_stream.go
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+/*
+ * Streaming field path resolution for large payloads.
+ *
+ * ResolveStream walks JSON token-by-token via json.Decoder instead of
+ * unmarshaling the full tree into `any`, skipping subtrees that don't
+ * match the next path segment. This avoids the allocation Resolve() pays
+ * even when only a single leaf is needed, which matters for payloads
+ * approaching MaxPayloadSize and for batch pipelines resolving many
+ * independent paths against the same event.
+ *
+ * Wildcard semantics match Resolve(): first match wins. Arrays are
+ * consumed in stream order (index 0 first), which is already the
+ * deterministic order. Objects are not guaranteed to arrive in sorted-key
+ * order over the wire, so a wildcard-on-object segment materializes just
+ * that one level into a map (not the whole payload) to recover the same
+ * sorted-key determinism Resolve() provides.
+ *
+ * Limits: MaxPathDepth and MaxNestedWildcards are enforced up front from
+ * the path itself, same as Resolve() - the full path is known before
+ * descent begins so there is nothing gained by re-checking per container.
+ * Both are pulled from the types.Limits installed into ctx via
+ * types.WithLimits (types.DefaultLimits() if ctx carries none).
+ *
+ * Resolve() itself dispatches straight into resolveStreamRecursive for any
+ * path pathQualifiesForStream (fieldpath.go) accepts, so most callers get
+ * this package's benefit without calling ResolveStream directly; it stays
+ * exported for callers that already have an io.Reader (e.g. an HTTP body)
+ * and want to skip buffering it into a json.RawMessage first.
+ */
+
+// ResolverContext holds a decoder reusable across many ResolveStream calls.
+// Reuse avoids re-allocating the decoder and its internal buffers per path
+// when a batch pipeline resolves many paths against the same payload shape.
+// Call Reset before resolving against a new reader.
+type ResolverContext struct {
+	dec *json.Decoder
+}
+
+// NewResolverContext creates an empty, reusable streaming resolver context.
+func NewResolverContext() *ResolverContext {
+	return &ResolverContext{}
+}
+
+// Reset rebinds the context to a new reader, discarding any decoder state
+// left over from a previous ResolveStream call.
+func (rc *ResolverContext) Reset(r io.Reader) {
+	rc.dec = json.NewDecoder(r)
+}
+
+// ResolveStream traverses r following path segments, decoding only as much
+// JSON as needed to reach the target leaf. Semantics mirror Resolve().
+// Enforces the resource limits installed into ctx via types.WithLimits
+// (types.DefaultLimits() if ctx carries none).
+// Returns ErrPathTooDeep if path exceeds MaxPathDepth.
+// Returns ErrTooManyWildcards if path contains > MaxNestedWildcards wildcards.
+// Returns ErrFieldNotFound if path does not exist in data.
+func ResolveStream(ctx context.Context, path []types.PathSegment, r io.Reader) (ResolveResult, error) {
+	rctx := NewResolverContext()
+	rctx.Reset(r)
+	return rctx.ResolveStream(ctx, path)
+}
+
+// ResolveStream resolves path against the context's current reader.
+// The decoder is left positioned wherever traversal stopped; callers must
+// Reset() before resolving another path against a different payload.
+func (rc *ResolverContext) ResolveStream(ctx context.Context, path []types.PathSegment) (ResolveResult, error) {
+	limits := types.LimitsFromContext(ctx)
+	if len(path) > limits.MaxPathDepth {
+		return ResolveResult{}, types.ErrPathTooDeep
+	}
+
+	wildcardCount := 0
+	for _, seg := range path {
+		if seg.Wildcard {
+			wildcardCount++
+		}
+		if seg.Filter != nil {
+			if err := validateFilterExpr(limits, seg.Filter); err != nil {
+				return ResolveResult{}, err
+			}
+		}
+	}
+	if wildcardCount > limits.MaxNestedWildcards {
+		return ResolveResult{}, types.ErrTooManyWildcards
+	}
+
+	return resolveStreamRecursive(rc.dec, path, nil)
+}
+
+// resolveStreamRecursive consumes exactly one JSON value from dec - the
+// value path[0] should select into, or the final leaf if path is empty.
+func resolveStreamRecursive(dec *json.Decoder, path []types.PathSegment, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if len(path) == 0 {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return ResolveResult{}, err
+		}
+		return ResolveResult{Value: v, ResolvedPath: resolvedSoFar, Found: true}, nil
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return ResolveResult{}, err
+	}
+
+	seg := path[0]
+	remaining := path[1:]
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// Scalar or null, but path continues.
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	switch delim {
+	case '{':
+		return resolveStreamObject(dec, seg, remaining, resolvedSoFar)
+	case '[':
+		return resolveStreamArray(dec, seg, remaining, resolvedSoFar)
+	default:
+		// '}' or ']' where a value was expected (shouldn't normally happen
+		// since the caller only hands us tokens fresh off dec.Token()).
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+}
+
+// resolveStreamObject handles a segment against an object whose opening
+// '{' has already been consumed.
+func resolveStreamObject(dec *json.Decoder, seg types.PathSegment, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if seg.IsIndex {
+		// Cannot index into object with integer.
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if seg.Filter != nil {
+		return resolveStreamObjectFilter(dec, seg.Filter, remaining, resolvedSoFar)
+	}
+
+	if seg.Wildcard {
+		return resolveStreamObjectWildcard(dec, remaining, resolvedSoFar)
+	}
+
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return ResolveResult{}, err
+		}
+		key, _ := keyTok.(string)
+		if key == seg.Key {
+			return resolveStreamRecursive(dec, remaining, append(resolvedSoFar, seg))
+		}
+		if err := skipValue(dec); err != nil {
+			return ResolveResult{}, err
+		}
+	}
+	return ResolveResult{}, types.ErrFieldNotFound
+}
+
+// resolveStreamObjectWildcard materializes the remaining object (one level
+// only) to recover sorted-key iteration order, then resolves the rest of
+// the path against each value in turn, short-circuiting on first match.
+func resolveStreamObjectWildcard(dec *json.Decoder, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	obj, err := decodeRemainingObject(dec)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	if len(obj) == 0 {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		resolved := append(resolvedSoFar, types.PathSegment{Key: k})
+		sub := json.NewDecoder(bytes.NewReader(obj[k]))
+		result, err := resolveStreamRecursive(sub, remaining, resolved)
+		if err == nil && result.Found {
+			return result, nil
+		}
+	}
+	return ResolveResult{}, types.ErrFieldNotFound
+}
+
+// resolveStreamObjectFilter materializes the remaining object (one level
+// only) to recover sorted-key iteration order, evaluates filter against
+// each value, and resolves the rest of the path for the first that
+// matches (ANY semantics, same as wildcard).
+func resolveStreamObjectFilter(dec *json.Decoder, filter *types.FilterExpr, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	obj, err := decodeRemainingObject(dec)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		var candidate any
+		if err := json.Unmarshal(obj[k], &candidate); err != nil {
+			return ResolveResult{}, err
+		}
+		if !evaluateFilterExpr(filter, candidate) {
+			continue
+		}
+		resolved := append(resolvedSoFar, types.PathSegment{Key: k})
+		sub := json.NewDecoder(bytes.NewReader(obj[k]))
+		result, err := resolveStreamRecursive(sub, remaining, resolved)
+		if err == nil && result.Found {
+			return result, nil
+		}
+	}
+	return ResolveResult{}, types.ErrFieldNotFound
+}
+
+// resolveStreamArray handles a segment against an array whose opening '['
+// has already been consumed.
+func resolveStreamArray(dec *json.Decoder, seg types.PathSegment, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	if seg.Filter != nil {
+		return resolveStreamArrayFilter(dec, seg.Filter, remaining, resolvedSoFar)
+	}
+
+	if seg.Wildcard {
+		// Each element is read whole as raw JSON and resolved through its own
+		// decoder: an element that doesn't match may be left part-consumed,
+		// and that must not leave dec mid-element for the next iteration.
+		idx := 0
+		for dec.More() {
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return ResolveResult{}, err
+			}
+			resolved := append(resolvedSoFar, types.PathSegment{Index: idx, IsIndex: true})
+			sub := json.NewDecoder(bytes.NewReader(raw))
+			result, err := resolveStreamRecursive(sub, remaining, resolved)
+			if err == nil && result.Found {
+				return result, nil
+			}
+			idx++
+		}
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if !seg.IsIndex {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+
+	if seg.Index < 0 {
+		return resolveStreamArrayNegativeIndex(dec, seg, remaining, resolvedSoFar)
+	}
+
+	idx := 0
+	for dec.More() {
+		if idx == seg.Index {
+			return resolveStreamRecursive(dec, remaining, append(resolvedSoFar, seg))
+		}
+		if err := skipValue(dec); err != nil {
+			return ResolveResult{}, err
+		}
+		idx++
+	}
+	return ResolveResult{}, types.ErrFieldNotFound
+}
+
+// resolveStreamArrayNegativeIndex resolves a relative-from-end index.
+// Unlike forward indices this needs the array's total length, so it
+// materializes the remaining elements as raw messages (one level only)
+// rather than streaming past them one at a time.
+func resolveStreamArrayNegativeIndex(dec *json.Decoder, seg types.PathSegment, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	elems, err := decodeRemainingArray(dec)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	idx := len(elems) + seg.Index
+	if idx < 0 || idx >= len(elems) {
+		return ResolveResult{}, types.ErrFieldNotFound
+	}
+	resolvedSeg := types.PathSegment{Index: idx, IsIndex: true}
+	sub := json.NewDecoder(bytes.NewReader(elems[idx]))
+	return resolveStreamRecursive(sub, remaining, append(resolvedSoFar, resolvedSeg))
+}
+
+// resolveStreamArrayFilter materializes the remaining array (one level
+// only), since a filter needs to inspect each candidate before deciding
+// whether to descend, and resolves the rest of the path for the first
+// element that matches filter (ANY semantics, same as wildcard).
+func resolveStreamArrayFilter(dec *json.Decoder, filter *types.FilterExpr, remaining, resolvedSoFar []types.PathSegment) (ResolveResult, error) {
+	elems, err := decodeRemainingArray(dec)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+
+	for i, raw := range elems {
+		var candidate any
+		if err := json.Unmarshal(raw, &candidate); err != nil {
+			return ResolveResult{}, err
+		}
+		if !evaluateFilterExpr(filter, candidate) {
+			continue
+		}
+		resolved := append(resolvedSoFar, types.PathSegment{Index: i, IsIndex: true})
+		sub := json.NewDecoder(bytes.NewReader(raw))
+		result, err := resolveStreamRecursive(sub, remaining, resolved)
+		if err == nil && result.Found {
+			return result, nil
+		}
+	}
+	return ResolveResult{}, types.ErrFieldNotFound
+}
+
+// decodeRemainingObject finishes decoding an object whose opening '{' has
+// already been consumed via dec.Token(), reading each key/value pair
+// token-by-token (dec.Decode on a *json.RawMessage reads exactly one
+// complete value from the decoder's current position, so it composes with
+// a preceding Token() call the same way skipValue does) and consuming the
+// closing '}'. A plain dec.Decode(&obj) here would fail - Decode expects
+// to start at a fresh value, not mid-object after Token() already ate the
+// opening delimiter.
+func decodeRemainingObject(dec *json.Decoder) (map[string]json.RawMessage, error) {
+	obj := make(map[string]json.RawMessage)
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := keyTok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		obj[key] = raw
+	}
+	if _, err := dec.Token(); err != nil { // consume closing '}'
+		return nil, err
+	}
+	return obj, nil
+}
+
+// decodeRemainingArray finishes decoding an array whose opening '[' has
+// already been consumed via dec.Token(), the array counterpart of
+// decodeRemainingObject.
+func decodeRemainingArray(dec *json.Decoder) ([]json.RawMessage, error) {
+	var elems []json.RawMessage
+	for dec.More() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		elems = append(elems, raw)
+	}
+	if _, err := dec.Token(); err != nil { // consume closing ']'
+		return nil, err
+	}
+	return elems, nil
+}
+
+// skipValue consumes and discards exactly one JSON value from dec, whether
+// scalar or a nested container, without materializing it. Used to skip
+// subtrees that don't match the next path segment.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/solatis/trapperkeeper/internal/types"
+)
+
+// Test that ResolveStream agrees with Resolve on normal cases.
+func TestResolveStream_Normal(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     []types.PathSegment
+		data     string
+		expected any
+		wantErr  error
+	}{
+		{
+			name:     "nested object traversal",
+			path:     []types.PathSegment{{Key: "user"}, {Key: "name"}},
+			data:     `{"user": {"name": "Alice"}}`,
+			expected: "Alice",
+		},
+		{
+			name:     "array index access",
+			path:     []types.PathSegment{{Key: "users"}, {Index: 0, IsIndex: true}, {Key: "name"}},
+			data:     `{"users": [{"name": "Bob"}]}`,
+			expected: "Bob",
+		},
+		{
+			name:     "single wildcard first match",
+			path:     []types.PathSegment{{Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			data:     `{"items": [{"price": 10}, {"price": 20}]}`,
+			expected: float64(10),
+		},
+		{
+			name:     "wildcard on object sorted keys",
+			path:     []types.PathSegment{{Wildcard: true}, {Key: "value"}},
+			data:     `{"z": {"value": 1}, "a": {"value": 2}, "m": {"value": 3}}`,
+			expected: float64(2),
+		},
+		{
+			name:     "nested wildcards",
+			path:     []types.PathSegment{{Key: "orders"}, {Wildcard: true}, {Key: "items"}, {Wildcard: true}, {Key: "price"}},
+			data:     `{"orders": [{"items": [{"price": 100}, {"price": 200}]}, {"items": [{"price": 300}]}]}`,
+			expected: float64(100),
+		},
+		{
+			name: "filter segment on array",
+			path: []types.PathSegment{
+				{Key: "items"},
+				{Filter: &types.FilterExpr{Op: types.FilterOpEq, LHS: []types.PathSegment{{Key: "status"}}, RHS: "active"}},
+				{Key: "price"},
+			},
+			data:     `{"items": [{"status": "inactive", "price": 1}, {"status": "active", "price": 42}]}`,
+			expected: float64(42),
+		},
+		{
+			name: "filter segment on object",
+			path: []types.PathSegment{
+				{Key: "accounts"},
+				{Filter: &types.FilterExpr{Op: types.FilterOpGt, LHS: []types.PathSegment{{Key: "balance"}}, RHS: float64(100)}},
+				{Key: "id"},
+			},
+			data:     `{"accounts": {"a": {"balance": 10, "id": "a"}, "b": {"balance": 500, "id": "b"}}}`,
+			expected: "b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ResolveStream(context.Background(), tt.path, strings.NewReader(tt.data))
+			if err != tt.wantErr {
+				t.Fatalf("ResolveStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !result.Found {
+				t.Fatalf("ResolveStream() Found = false, want true")
+			}
+			if result.Value != tt.expected {
+				t.Errorf("ResolveStream() Value = %v, expected %v", result.Value, tt.expected)
+			}
+
+			// Cross-check against the in-memory resolver for the same input.
+			want, err := Resolve(context.Background(), tt.path, []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if want.Value != result.Value {
+				t.Errorf("ResolveStream() diverged from Resolve(): %v != %v", result.Value, want.Value)
+			}
+		})
+	}
+}
+
+func TestResolveStream_EdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []types.PathSegment
+		data    string
+		wantErr error
+	}{
+		{
+			name:    "empty object",
+			path:    []types.PathSegment{{Key: "missing"}},
+			data:    `{}`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:    "empty array",
+			path:    []types.PathSegment{{Index: 0, IsIndex: true}},
+			data:    `[]`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:    "null value at intermediate level",
+			path:    []types.PathSegment{{Key: "user"}, {Key: "name"}},
+			data:    `{"user": null}`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:    "scalar value but path continues",
+			path:    []types.PathSegment{{Key: "value"}, {Key: "nested"}},
+			data:    `{"value": "scalar"}`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:    "array index out of bounds",
+			path:    []types.PathSegment{{Index: 5, IsIndex: true}},
+			data:    `[1, 2, 3]`,
+			wantErr: types.ErrFieldNotFound,
+		},
+		{
+			name:    "string key on array",
+			path:    []types.PathSegment{{Key: "key"}},
+			data:    `[1, 2, 3]`,
+			wantErr: types.ErrFieldNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ResolveStream(context.Background(), tt.path, strings.NewReader(tt.data))
+			if err != tt.wantErr {
+				t.Errorf("ResolveStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResolveStream_Errors(t *testing.T) {
+	path := make([]types.PathSegment, types.MaxPathDepth+1)
+	for i := range path {
+		path[i] = types.PathSegment{Key: "a"}
+	}
+	_, err := ResolveStream(context.Background(), path, strings.NewReader(`{}`))
+	if err != types.ErrPathTooDeep {
+		t.Errorf("expected ErrPathTooDeep, got %v", err)
+	}
+
+	wildcards := []types.PathSegment{{Wildcard: true}, {Wildcard: true}, {Wildcard: true}}
+	_, err = ResolveStream(context.Background(), wildcards, strings.NewReader(`[]`))
+	if err != types.ErrTooManyWildcards {
+		t.Errorf("expected ErrTooManyWildcards, got %v", err)
+	}
+}
+
+// TestResolverContext_Reuse verifies a context can be reset and reused
+// across multiple payloads without leaking decoder state.
+func TestResolverContext_Reuse(t *testing.T) {
+	path := []types.PathSegment{{Key: "value"}}
+	ctx := NewResolverContext()
+
+	ctx.Reset(strings.NewReader(`{"value": 1}`))
+	r1, err := ctx.ResolveStream(context.Background(), path)
+	if err != nil || r1.Value != float64(1) {
+		t.Fatalf("first resolve = %v, %v", r1, err)
+	}
+
+	ctx.Reset(strings.NewReader(`{"value": 2}`))
+	r2, err := ctx.ResolveStream(context.Background(), path)
+	if err != nil || r2.Value != float64(2) {
+		t.Fatalf("second resolve = %v, %v", r2, err)
+	}
+}
+
+// BenchmarkResolve_ShallowPathLargePayload measures the streaming fast
+// path's win over full unmarshaling per solatis/trapperkeeper#chunk6-4's
+// claim that resolving one shallow field shouldn't pay for materializing
+// a large payload's unrelated subtrees.
+func BenchmarkResolve_ShallowPathLargePayload(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString(`{"id": "evt-1", "items": [`)
+	for i := 0; i < 5000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`{"sku": "item-` + strconv.Itoa(i) + `", "price": 9.99, "tags": ["a", "b", "c"]}`)
+	}
+	sb.WriteString(`]}`)
+	data := json.RawMessage(sb.String())
+	path := []types.PathSegment{{Key: "id"}}
+
+	b.Run("Streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := ResolveStream(context.Background(), path, bytes.NewReader(data)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("FullUnmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var parsed any
+			if err := json.Unmarshal(data, &parsed); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := resolveRecursive(types.DefaultLimits(), path, parsed, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
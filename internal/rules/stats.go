@@ -0,0 +1,116 @@
+// internal/rules/stats.go
+package rules
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * Runtime condition statistics for adaptive reordering (see adaptive.go).
+ *
+ * CalculateConditionCost (cost.go) predicts a condition's relative cost
+ * statically, from its path/operator/field type alone. In practice static
+ * cost mispredicts short-circuit value: a cheap exists on a field that's
+ * almost always present is a worse first condition than an expensive eq
+ * that almost never matches, since AND-group evaluation short-circuits on
+ * the first non-match. conditionStats tracks, per compiled condition, how
+ * often it's evaluated and matches plus a decayed average of its observed
+ * wall-time cost, so Engine can fold both into an effective cost and
+ * re-sort toward what's actually paying off.
+ */
+
+// costEWMAAlpha weights each new observation against the running average:
+// higher reacts faster to a condition's cost changing (e.g. a cache
+// warming up) at the cost of more noise from any single slow event.
+const costEWMAAlpha = 0.2
+
+// effectiveCostEpsilon keeps effectiveCost finite for a condition whose
+// matchRate is 1.0 (every evaluation so far matched) - without it,
+// observedCost / (1 - matchRate) would divide by zero instead of merely
+// ranking that condition as comparatively expensive to place first.
+const effectiveCostEpsilon = 0.01
+
+// conditionStats is one CompiledCondition's runtime counters: an
+// evaluated/matched pair (plain atomics) plus a decayed EWMA of wall-time
+// cost in nanoseconds, stored as math.Float64bits under a CAS loop since
+// there's no atomic float64 in the standard library. Safe for concurrent
+// use by every goroutine evaluating the rule this condition belongs to.
+type conditionStats struct {
+	evaluated     atomic.Uint64
+	matched       atomic.Uint64
+	costNanosBits atomic.Uint64
+}
+
+// record folds one evaluation's outcome and latency into s.
+func (s *conditionStats) record(matched bool, dur time.Duration) {
+	s.evaluated.Add(1)
+	if matched {
+		s.matched.Add(1)
+	}
+
+	observed := float64(dur.Nanoseconds())
+	for {
+		old := s.costNanosBits.Load()
+		oldCost := math.Float64frombits(old)
+
+		var newCost float64
+		if old == 0 {
+			newCost = observed
+		} else {
+			newCost = oldCost + costEWMAAlpha*(observed-oldCost)
+		}
+
+		if s.costNanosBits.CompareAndSwap(old, math.Float64bits(newCost)) {
+			return
+		}
+	}
+}
+
+// snapshot returns s's current counters plus the derived match rate and
+// effective cost, without mutating s.
+func (s *conditionStats) snapshot() ConditionStats {
+	evaluated := s.evaluated.Load()
+	matched := s.matched.Load()
+	observedCost := math.Float64frombits(s.costNanosBits.Load())
+
+	var matchRate float64
+	if evaluated > 0 {
+		matchRate = float64(matched) / float64(evaluated)
+	}
+
+	return ConditionStats{
+		Evaluated:         evaluated,
+		Matched:           matched,
+		MatchRate:         matchRate,
+		ObservedCostNanos: observedCost,
+		EffectiveCost:     effectiveCost(observedCost, matchRate),
+	}
+}
+
+// effectiveCost is the score Engine's adaptive reordering sorts conditions
+// by, ascending (cheapest/most-likely-to-short-circuit first). An AND
+// group stops at its first non-matching condition, so a condition that
+// rarely matches is valuable to place early: its (1 - matchRate) stays
+// close to 1, leaving effectiveCost close to the observed cost itself. A
+// condition that almost always matches offers little short-circuit value
+// - (1 - matchRate) shrinks toward 0, inflating effectiveCost well above
+// the observed cost so it sorts later, regardless of how cheap it looked
+// statically.
+func effectiveCost(observedCostNanos, matchRate float64) float64 {
+	return observedCostNanos / (1 - matchRate + effectiveCostEpsilon)
+}
+
+// ConditionStats is a point-in-time snapshot of one condition's runtime
+// behavior, returned by Engine.GetConditionStats for observability -
+// dashboards, or explaining why a rule's conditions got reordered.
+type ConditionStats struct {
+	GroupIndex        int
+	OriginalIndex     int
+	Evaluated         uint64
+	Matched           uint64
+	MatchRate         float64
+	ObservedCostNanos float64
+	EffectiveCost     float64
+}
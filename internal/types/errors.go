@@ -45,4 +45,39 @@ var (
 
 	// ErrFieldNotFound indicates a field path could not be resolved.
 	ErrFieldNotFound = errors.New("field not found")
+
+	// ErrInvalidPathExpr indicates a JSONPath expression could not be parsed.
+	ErrInvalidPathExpr = errors.New("invalid path expression")
+
+	// ErrTooManyMatches indicates a wildcard path yielded more than MaxWildcardMatches leaves.
+	ErrTooManyMatches = errors.New("wildcard path has too many matches")
+
+	// ErrTooManyFilterPredicates indicates a filter segment's expression tree
+	// exceeds MaxFilterPredicates.
+	ErrTooManyFilterPredicates = errors.New("filter expression has too many predicates")
+
+	// ErrNestedFilterTooDeep indicates a filter segment's LHS sub-path
+	// contains another filter segment, which would make evaluation cost
+	// exponential in payload size.
+	ErrNestedFilterTooDeep = errors.New("filter expression nests another filter beyond the allowed depth")
+
+	// ErrTooManyConditions indicates an OrGroup exceeds MaxGroupConditions,
+	// the most CompileSet's per-group bitset can track.
+	ErrTooManyConditions = errors.New("OR group has too many conditions for the query planner's bitset")
+
+	// ErrPatternTooLarge indicates an OpRegex/OpGlob condition's pattern
+	// exceeds MaxRegexSize.
+	ErrPatternTooLarge = errors.New("pattern exceeds maximum size")
+
+	// ErrInvalidExpression indicates an ExpressionRule's Source failed to
+	// parse or type-check against the payload environment.
+	ErrInvalidExpression = errors.New("expression rule source is invalid")
+
+	// ErrTooManyRecursiveDescents indicates a field path exceeds
+	// MaxRecursiveDescents.
+	ErrTooManyRecursiveDescents = errors.New("field path has too many recursive descent segments")
+
+	// ErrRecursiveDescentBudgetExceeded indicates a ".." segment visited
+	// more than MaxRecursiveDescentNodes nodes while searching for a match.
+	ErrRecursiveDescentBudgetExceeded = errors.New("recursive descent exceeded its node visit budget")
 )
@@ -0,0 +1,32 @@
+// internal/types/expression_rule.go
+package types
+
+/*
+ * ExpressionRule is a second rule flavor alongside Rule's or_groups DNF
+ * (rules.go): instead of a fixed set of comparison operators, Source is a
+ * boolean expr-lang/expr expression evaluated against an environment derived
+ * from the payload, so arithmetic, string helpers (lower/upper/trim/len),
+ * and cross-field expressions are reachable without extending the DNF
+ * operator set. See internal/rules/expr.go for compilation/evaluation.
+ *
+ * A rule is exactly one flavor: a Rule with OrGroups, or an ExpressionRule
+ * with Source. They share RuleID/Action/SampleRate conventions so callers
+ * (SyncRules, the evaluator dispatch) treat them uniformly aside from how
+ * the match boolean is produced.
+ */
+
+// ExpressionRule represents a rule whose match condition is an expr-lang
+// expression rather than a DNF of Condition/OrGroup.
+type ExpressionRule struct {
+	RuleID     RuleID  // immutable identifier
+	Name       string  // human-readable name
+	Source     string  // expr-lang/expr boolean expression, evaluated against the payload
+	ASTHash    string  // sha256(Source) hex digest, computed by rules.CompileExpression
+	SampleRate float64 // [0.0, 1.0] sampling rate
+	Action     int     // action enum value
+
+	// OnMissingField governs the match result when Source's evaluation
+	// fails at runtime (e.g. dereferencing a field absent from the
+	// payload) - the same policy semantics as Condition.OnMissingField.
+	OnMissingField int
+}
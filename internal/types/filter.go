@@ -0,0 +1,168 @@
+// internal/types/filter.go
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ * Filter segment predicate AST ([?(@.status=="active")]).
+ *
+ * FilterExpr is a small comparison/boolean tree evaluated against each
+ * candidate element a filter segment iterates. LHS is resolved relative to
+ * the candidate (@), not the payload root, so the same []PathSegment shape
+ * used everywhere else in the resolver doubles as the filter's sub-path
+ * language.
+ *
+ * Nesting a filter segment inside another filter's LHS is rejected at
+ * MaxFilterDepth (see rules.Resolve/ResolveAll validation) to keep
+ * evaluation linear in payload size.
+ */
+
+// FilterOp identifies the comparison or boolean-combinator a FilterExpr
+// node performs.
+type FilterOp int
+
+const (
+	FilterOpUnspecified FilterOp = iota
+	FilterOpEq
+	FilterOpNeq
+	FilterOpLt
+	FilterOpLte
+	FilterOpGt
+	FilterOpGte
+	FilterOpExists
+	FilterOpAnd
+	FilterOpOr
+	FilterOpNot
+
+	// FilterOpIn and FilterOpPrefix mirror the condition operators OpIn/
+	// OpPrefix, e.g. @.name in ["nginx","envoy"] or @.name^="nginx-" - so a
+	// filter segment can select "the container named one of these" or "the
+	// container whose name starts with this" without a full equality match.
+	FilterOpIn
+	FilterOpPrefix
+)
+
+// FilterExpr is a predicate evaluated against each candidate element of a
+// filter segment. Comparison ops (Eq/Neq/Lt/Lte/Gt/Gte/Exists) use LHS/RHS
+// and ignore Children; combinator ops (And/Or/Not) use Children and ignore
+// LHS/RHS. Not requires exactly one child.
+type FilterExpr struct {
+	Op FilterOp
+
+	// LHS is a path relative to @ (the candidate element); nil means @ itself.
+	LHS []PathSegment
+	RHS any // comparison literal, used by comparison ops only
+
+	Children []*FilterExpr // operands for And/Or/Not
+}
+
+// CountPredicates returns the number of FilterExpr nodes in expr's tree,
+// including expr itself. Used to enforce MaxFilterPredicates.
+func (expr *FilterExpr) CountPredicates() int {
+	if expr == nil {
+		return 0
+	}
+	count := 1
+	for _, child := range expr.Children {
+		count += child.CountPredicates()
+	}
+	return count
+}
+
+// String renders expr back to canonical filter syntax, e.g.
+// @.status=="active" or @.a>1&&@.b<2, for round-tripping through ParsePath.
+func (expr *FilterExpr) String() string {
+	if expr == nil {
+		return ""
+	}
+	switch expr.Op {
+	case FilterOpAnd:
+		return joinFilterChildren(expr.Children, "&&")
+	case FilterOpOr:
+		return joinFilterChildren(expr.Children, "||")
+	case FilterOpNot:
+		if len(expr.Children) != 1 {
+			return ""
+		}
+		return "!(" + expr.Children[0].String() + ")"
+	case FilterOpExists:
+		return atPathString(expr.LHS)
+	case FilterOpIn:
+		return atPathString(expr.LHS) + " in " + filterInLiteralString(expr.RHS)
+	default:
+		return atPathString(expr.LHS) + filterOpToken(expr.Op) + filterLiteralString(expr.RHS)
+	}
+}
+
+// joinFilterChildren renders AND/OR operands, parenthesizing any child
+// that is itself an AND/OR so precedence survives the round trip.
+func joinFilterChildren(children []*FilterExpr, sep string) string {
+	parts := make([]string, len(children))
+	for i, child := range children {
+		s := child.String()
+		if child.Op == FilterOpAnd || child.Op == FilterOpOr {
+			s = "(" + s + ")"
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, sep)
+}
+
+// filterOpToken renders a comparison FilterOp back to its source token.
+func filterOpToken(op FilterOp) string {
+	switch op {
+	case FilterOpEq:
+		return "=="
+	case FilterOpNeq:
+		return "!="
+	case FilterOpLt:
+		return "<"
+	case FilterOpLte:
+		return "<="
+	case FilterOpGt:
+		return ">"
+	case FilterOpGte:
+		return ">="
+	case FilterOpPrefix:
+		return "^="
+	default:
+		return ""
+	}
+}
+
+// filterInLiteralString renders an "in" comparison's RHS (a []any of
+// literals) back to its ["a","b"] source form.
+func filterInLiteralString(v any) string {
+	arr, ok := v.([]any)
+	if !ok {
+		return "[]"
+	}
+	parts := make([]string, len(arr))
+	for i, elem := range arr {
+		parts[i] = filterLiteralString(elem)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+// filterLiteralString renders a comparison RHS literal back to source form.
+func filterLiteralString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return `"` + t + `"`
+	case bool:
+		if t {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
@@ -0,0 +1,86 @@
+// internal/types/limits.go
+package types
+
+import "context"
+
+/*
+ * Pluggable, per-tenant resource limits.
+ *
+ * The Max* constants in types.go are the limits TrapperKeeper enforces by
+ * default, but a single compiled-in budget can't serve a trusted internal
+ * sensor and an untrusted external one from the same binary, and can't be
+ * tightened for a single test without touching package state. Limits
+ * mirrors those constants as an ordinary value; WithLimits/LimitsFromContext
+ * thread an override through request-scoped context.Context instead of
+ * adding a parameter to every validating call in internal/rules.
+ *
+ * Limits mirrors the Metadata/Payload fields too, even though no validator
+ * currently enforces MaxMetadataPairs/MaxMetadataKeyLength/
+ * MaxMetadataValueLength/MaxMetadataTotalSize/MaxPayloadSize against an
+ * actual Metadata or Payload type in this tree - the sentinel errors exist
+ * (see errors.go) ahead of the validators that will return them.
+ */
+
+// Limits holds every resource limit the rule engine enforces. The zero
+// value is not a usable default - callers get today's constants from
+// DefaultLimits() and override individual fields from there.
+type Limits struct {
+	MaxMetadataPairs         int
+	MaxMetadataKeyLength     int
+	MaxMetadataValueLength   int
+	MaxMetadataTotalSize     int
+	MaxPayloadSize           int
+	MaxPathDepth             int
+	MaxNestedWildcards       int
+	MaxWildcardMatches       int
+	MaxInOperatorValues      int
+	MaxFilterPredicates      int
+	MaxFilterDepth           int
+	MaxGroupConditions       int
+	MaxRegexSize             int
+	MaxRecursiveDescents     int
+	MaxRecursiveDescentNodes int
+	MaxCachedSubtrees        int
+}
+
+// DefaultLimits returns the limits TrapperKeeper enforces today via its
+// package-level Max* constants.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxMetadataPairs:         MaxMetadataPairs,
+		MaxMetadataKeyLength:     MaxMetadataKeyLength,
+		MaxMetadataValueLength:   MaxMetadataValueLength,
+		MaxMetadataTotalSize:     MaxMetadataTotalSize,
+		MaxPayloadSize:           MaxPayloadSize,
+		MaxPathDepth:             MaxPathDepth,
+		MaxNestedWildcards:       MaxNestedWildcards,
+		MaxWildcardMatches:       MaxWildcardMatches,
+		MaxInOperatorValues:      MaxInOperatorValues,
+		MaxFilterPredicates:      MaxFilterPredicates,
+		MaxFilterDepth:           MaxFilterDepth,
+		MaxGroupConditions:       MaxGroupConditions,
+		MaxRegexSize:             MaxRegexSize,
+		MaxRecursiveDescents:     MaxRecursiveDescents,
+		MaxRecursiveDescentNodes: MaxRecursiveDescentNodes,
+		MaxCachedSubtrees:        MaxCachedSubtrees,
+	}
+}
+
+// limitsContextKey is unexported so only WithLimits can populate it.
+type limitsContextKey struct{}
+
+// WithLimits returns a copy of ctx carrying l, so Resolve/ResolveAll/
+// ResolveStream/Compile calls downstream enforce l instead of
+// DefaultLimits().
+func WithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsContextKey{}, l)
+}
+
+// LimitsFromContext returns the Limits installed into ctx via WithLimits,
+// or DefaultLimits() if ctx carries none.
+func LimitsFromContext(ctx context.Context) Limits {
+	if l, ok := ctx.Value(limitsContextKey{}).(Limits); ok {
+		return l
+	}
+	return DefaultLimits()
+}
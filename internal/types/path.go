@@ -0,0 +1,524 @@
+// internal/types/path.go
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+ * JSONPath string syntax for field paths.
+ *
+ * ParsePath accepts a practical subset of JSONPath - $, .name, ["quoted
+ * key"], [N], [-N] (relative from end), [*], and [?(...)] filter segments -
+ * and returns the same []PathSegment the resolver already consumes. This
+ * lets rule authors write $.orders[*].items[*].price instead of
+ * constructing segment structs by hand.
+ *
+ * Negative indices ([-N]) are relative to the end of the array, resolved
+ * against the concrete length at evaluation time; Resolve/ResolveStream
+ * replace them with the absolute index in ResolveResult.ResolvedPath.
+ *
+ * Filter segments ([?(@.status=="active")]) parse into a FilterExpr tree
+ * (see filter.go). The grammar is deliberately small: comparisons
+ * (==, !=, <, <=, >, >=, ^= for prefix, and in [...] for a literal list)
+ * or a bare @-path for an existence check, combined
+ * with &&/||/! and parens for grouping. LHS paths are relative to @ and
+ * reuse the same dot/bracket lexing as the top-level path.
+ *
+ * Recursive descent (..name) searches every node in the subtree at that
+ * position, at any depth, for a key named "name" - e.g. $..status matches
+ * "status" wherever it appears rather than only as a direct child. Only a
+ * bare or quoted name may follow ".."; wildcards, indices, and filters
+ * cannot (write the rest of the path as normal segments after the match).
+ *
+ * Limits are enforced at parse time so malformed rules fail at
+ * registration rather than at evaluation.
+ */
+
+// Path is a field path as a slice of segments with a canonical string form.
+// Assignable to/from []PathSegment since it shares the same underlying type.
+type Path []PathSegment
+
+// ParsePath parses expr into a PathSegment slice.
+// Returns ErrInvalidPathExpr for malformed syntax.
+// Returns ErrPathTooDeep if the path exceeds MaxPathDepth.
+// Returns ErrTooManyWildcards if the path exceeds MaxNestedWildcards.
+func ParsePath(expr string) ([]PathSegment, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "$")
+
+	var segments []PathSegment
+	wildcards := 0
+	recursiveDescents := 0
+
+	for len(s) > 0 {
+		var seg PathSegment
+		var rest string
+		var err error
+
+		switch {
+		case strings.HasPrefix(s, ".."):
+			seg, rest, err = lexRecursiveDescent(s[2:])
+		case s[0] == '.':
+			seg, rest, err = lexDotName(s[1:])
+		case s[0] == '[':
+			seg, rest, err = lexBracket(s)
+		default:
+			return nil, fmt.Errorf("%w: unexpected character %q in %q", ErrInvalidPathExpr, s[0], expr)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if seg.Wildcard {
+			wildcards++
+		}
+		if seg.RecursiveDescent {
+			recursiveDescents++
+		}
+		segments = append(segments, seg)
+		s = rest
+
+		if len(segments) > MaxPathDepth {
+			return nil, ErrPathTooDeep
+		}
+		if wildcards > MaxNestedWildcards {
+			return nil, ErrTooManyWildcards
+		}
+		if recursiveDescents > MaxRecursiveDescents {
+			return nil, ErrTooManyRecursiveDescents
+		}
+	}
+
+	return segments, nil
+}
+
+// lexRecursiveDescent reads the field name following ".." - e.g. $..status
+// searches the entire payload for a "status" key at any depth, not just a
+// direct child. Only a bare name or a quoted ["name"] may follow; wildcards,
+// indices, and filters aren't supported directly after "..".
+func lexRecursiveDescent(s string) (PathSegment, string, error) {
+	if len(s) == 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: empty field name after '..'", ErrInvalidPathExpr)
+	}
+	if s[0] == '[' {
+		end := strings.IndexByte(s, ']')
+		if end < 2 || s[1] != '"' || s[end-1] != '"' {
+			return PathSegment{}, "", fmt.Errorf("%w: recursive descent requires a quoted field name in %q", ErrInvalidPathExpr, s)
+		}
+		return PathSegment{Key: s[2 : end-1], RecursiveDescent: true}, s[end+1:], nil
+	}
+
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '[' {
+		i++
+	}
+	if i == 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: empty field name after '..'", ErrInvalidPathExpr)
+	}
+	return PathSegment{Key: s[:i], RecursiveDescent: true}, s[i:], nil
+}
+
+// lexDotName reads a bare field name following a '.' up to the next '.' or '['.
+func lexDotName(s string) (PathSegment, string, error) {
+	i := 0
+	for i < len(s) && s[i] != '.' && s[i] != '[' {
+		i++
+	}
+	if i == 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: empty field name after '.'", ErrInvalidPathExpr)
+	}
+	return PathSegment{Key: s[:i]}, s[i:], nil
+}
+
+// lexBracket parses a ["key"], [N], [-N], [*], or [?(...)] segment.
+func lexBracket(s string) (PathSegment, string, error) {
+	if strings.HasPrefix(s, "[?(") {
+		return lexFilterBracket(s)
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: unterminated '[' in %q", ErrInvalidPathExpr, s)
+	}
+	inner := s[1:end]
+	rest := s[end+1:]
+
+	switch {
+	case inner == "*":
+		return PathSegment{Wildcard: true}, rest, nil
+	case len(inner) >= 2 && inner[0] == '"' && inner[len(inner)-1] == '"':
+		return PathSegment{Key: inner[1 : len(inner)-1]}, rest, nil
+	default:
+		idx, err := strconv.Atoi(inner)
+		if err != nil {
+			return PathSegment{}, "", fmt.Errorf("%w: invalid index %q", ErrInvalidPathExpr, inner)
+		}
+		return PathSegment{Index: idx, IsIndex: true}, rest, nil
+	}
+}
+
+// String renders the path back to canonical JSONPath syntax, e.g.
+// $.orders[*].items[3].price or $["weird key"].
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	writePathSegments(&b, p)
+	return b.String()
+}
+
+// atPathString renders segs relative to '@' instead of '$', for filter
+// expression LHS paths (see FilterExpr.String in filter.go).
+func atPathString(segs []PathSegment) string {
+	var b strings.Builder
+	b.WriteByte('@')
+	writePathSegments(&b, segs)
+	return b.String()
+}
+
+// writePathSegments renders segs' canonical syntax, shared by Path.String
+// (anchored at '$') and atPathString (anchored at '@').
+func writePathSegments(b *strings.Builder, segs []PathSegment) {
+	for _, seg := range segs {
+		switch {
+		case seg.Filter != nil:
+			b.WriteString("[?(")
+			b.WriteString(seg.Filter.String())
+			b.WriteString(")]")
+		case seg.Wildcard:
+			b.WriteString("[*]")
+		case seg.IsIndex:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(seg.Index))
+			b.WriteByte(']')
+		case seg.RecursiveDescent && isPlainIdentifier(seg.Key):
+			b.WriteString("..")
+			b.WriteString(seg.Key)
+		case seg.RecursiveDescent:
+			b.WriteString(`..["`)
+			b.WriteString(seg.Key)
+			b.WriteString(`"]`)
+		case isPlainIdentifier(seg.Key):
+			b.WriteByte('.')
+			b.WriteString(seg.Key)
+		default:
+			b.WriteString(`["`)
+			b.WriteString(seg.Key)
+			b.WriteString(`"]`)
+		}
+	}
+}
+
+// isPlainIdentifier reports whether key can be rendered as .key rather than
+// the quoted ["key"] form.
+func isPlainIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// lexFilterBracket parses a [?(...)] segment. s starts with "[?(".
+func lexFilterBracket(s string) (PathSegment, string, error) {
+	body := s[3:]
+	end := findFilterClose(body)
+	if end < 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: unterminated filter expression in %q", ErrInvalidPathExpr, s)
+	}
+
+	rest := body[end+1:]
+	if len(rest) == 0 || rest[0] != ']' {
+		return PathSegment{}, "", fmt.Errorf("%w: filter expression not closed with ']' in %q", ErrInvalidPathExpr, s)
+	}
+
+	expr, err := parseFilterExpr(body[:end])
+	if err != nil {
+		return PathSegment{}, "", err
+	}
+	return PathSegment{Filter: expr}, rest[1:], nil
+}
+
+// findFilterClose returns the index in body of the ')' matching the '('
+// implied by the leading "[?(", tracking nesting so a comparison like
+// (@.a==1) doesn't close early, and skipping string literal contents so a
+// ')' inside a quoted string doesn't either. Returns -1 if unterminated.
+func findFilterClose(body string) int {
+	depth := 1
+	inString := false
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case inString:
+			if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parseFilterExpr parses the predicate inside [?(...)]. Grammar:
+//
+//	expr       := orExpr
+//	orExpr     := andExpr ('||' andExpr)*
+//	andExpr    := unary ('&&' unary)*
+//	unary      := '!' unary | primary
+//	primary    := '(' orExpr ')' | comparison
+//	comparison := atPath (('==' | '!=' | '<=' | '>=' | '^=' | '<' | '>') literal
+//	              | 'in' '[' literal (',' literal)* ']')?
+//
+// A comparison with no operator is an existence check on atPath.
+func parseFilterExpr(s string) (*FilterExpr, error) {
+	expr, rest, err := parseFilterOr(s)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("%w: unexpected trailing input %q in filter expression", ErrInvalidPathExpr, rest)
+	}
+	return expr, nil
+}
+
+func parseFilterOr(s string) (*FilterExpr, string, error) {
+	left, rest, err := parseFilterAnd(s)
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		trimmed := strings.TrimSpace(rest)
+		if !strings.HasPrefix(trimmed, "||") {
+			return left, rest, nil
+		}
+		right, next, err := parseFilterAnd(trimmed[2:])
+		if err != nil {
+			return nil, "", err
+		}
+		left = &FilterExpr{Op: FilterOpOr, Children: []*FilterExpr{left, right}}
+		rest = next
+	}
+}
+
+func parseFilterAnd(s string) (*FilterExpr, string, error) {
+	left, rest, err := parseFilterUnary(s)
+	if err != nil {
+		return nil, "", err
+	}
+	for {
+		trimmed := strings.TrimSpace(rest)
+		if !strings.HasPrefix(trimmed, "&&") {
+			return left, rest, nil
+		}
+		right, next, err := parseFilterUnary(trimmed[2:])
+		if err != nil {
+			return nil, "", err
+		}
+		left = &FilterExpr{Op: FilterOpAnd, Children: []*FilterExpr{left, right}}
+		rest = next
+	}
+}
+
+func parseFilterUnary(s string) (*FilterExpr, string, error) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "!") {
+		child, rest, err := parseFilterUnary(trimmed[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		return &FilterExpr{Op: FilterOpNot, Children: []*FilterExpr{child}}, rest, nil
+	}
+	return parseFilterPrimary(trimmed)
+}
+
+func parseFilterPrimary(s string) (*FilterExpr, string, error) {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "(") {
+		inner, rest, err := parseFilterOr(trimmed[1:])
+		if err != nil {
+			return nil, "", err
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, ")") {
+			return nil, "", fmt.Errorf("%w: expected ')' in filter expression", ErrInvalidPathExpr)
+		}
+		return inner, rest[1:], nil
+	}
+	return parseFilterComparison(trimmed)
+}
+
+// filterComparisonOps lists tokens in match priority order; two-character
+// tokens must be tried before their one-character prefixes (<=/>= before </>).
+var filterComparisonOps = []struct {
+	token string
+	op    FilterOp
+}{
+	{"==", FilterOpEq},
+	{"!=", FilterOpNeq},
+	{"<=", FilterOpLte},
+	{">=", FilterOpGte},
+	{"^=", FilterOpPrefix},
+	{"<", FilterOpLt},
+	{">", FilterOpGt},
+}
+
+func parseFilterComparison(s string) (*FilterExpr, string, error) {
+	lhs, rest, err := parseFilterAtPath(s)
+	if err != nil {
+		return nil, "", err
+	}
+
+	trimmed := strings.TrimSpace(rest)
+
+	if strings.HasPrefix(trimmed, "in ") {
+		values, after, err := parseFilterInLiteral(trimmed[len("in "):])
+		if err != nil {
+			return nil, "", err
+		}
+		return &FilterExpr{Op: FilterOpIn, LHS: lhs, RHS: values}, after, nil
+	}
+
+	for _, fo := range filterComparisonOps {
+		if strings.HasPrefix(trimmed, fo.token) {
+			value, after, err := parseFilterLiteral(trimmed[len(fo.token):])
+			if err != nil {
+				return nil, "", err
+			}
+			return &FilterExpr{Op: fo.op, LHS: lhs, RHS: value}, after, nil
+		}
+	}
+
+	// No operator: existence check.
+	return &FilterExpr{Op: FilterOpExists, LHS: lhs}, rest, nil
+}
+
+// parseFilterInLiteral parses an "in" comparison's RHS: a bracketed,
+// comma-separated list of literals, e.g. ["a","b"] or [1,2,3].
+func parseFilterInLiteral(s string) ([]any, string, error) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "[") {
+		return nil, "", fmt.Errorf("%w: expected '[' after 'in' in filter expression", ErrInvalidPathExpr)
+	}
+	rest := trimmed[1:]
+
+	var values []any
+	for {
+		rest = strings.TrimSpace(rest)
+		if strings.HasPrefix(rest, "]") {
+			return values, rest[1:], nil
+		}
+		if len(values) > 0 {
+			if !strings.HasPrefix(rest, ",") {
+				return nil, "", fmt.Errorf("%w: expected ',' or ']' in 'in' literal list", ErrInvalidPathExpr)
+			}
+			rest = strings.TrimSpace(rest[1:])
+		}
+
+		value, after, err := parseFilterLiteral(rest)
+		if err != nil {
+			return nil, "", err
+		}
+		values = append(values, value)
+		rest = after
+	}
+}
+
+// parseFilterAtPath parses a path relative to '@', reusing the same
+// dot/bracket lexing as the top-level path (so @.items[0]["k"] works the
+// same way $.items[0]["k"] does), stopping at the first character that
+// isn't a '.' or '[' segment starter.
+func parseFilterAtPath(s string) ([]PathSegment, string, error) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "@") {
+		return nil, "", fmt.Errorf("%w: filter comparison must start with '@'", ErrInvalidPathExpr)
+	}
+	rest := trimmed[1:]
+
+	var segments []PathSegment
+	for len(rest) > 0 {
+		var seg PathSegment
+		var err error
+		switch rest[0] {
+		case '.':
+			seg, rest, err = lexFilterDotName(rest[1:])
+		case '[':
+			seg, rest, err = lexBracket(rest)
+		default:
+			return segments, rest, nil
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		segments = append(segments, seg)
+	}
+	return segments, rest, nil
+}
+
+// lexFilterDotName reads a bare field name following '.' inside a filter's
+// @-path, stopping at the first non-identifier character (unlike the
+// top-level lexDotName, which only stops at '.' or '[' since nothing else
+// can follow a full path).
+func lexFilterDotName(s string) (PathSegment, string, error) {
+	i := 0
+	for i < len(s) && isIdentChar(s[i]) {
+		i++
+	}
+	if i == 0 {
+		return PathSegment{}, "", fmt.Errorf("%w: empty field name after '.' in filter expression", ErrInvalidPathExpr)
+	}
+	return PathSegment{Key: s[:i]}, s[i:], nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseFilterLiteral parses a comparison RHS literal: a quoted string,
+// number, true/false, or null.
+func parseFilterLiteral(s string) (any, string, error) {
+	trimmed := strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(trimmed, `"`):
+		end := strings.IndexByte(trimmed[1:], '"')
+		if end < 0 {
+			return nil, "", fmt.Errorf("%w: unterminated string literal in filter expression", ErrInvalidPathExpr)
+		}
+		return trimmed[1 : end+1], trimmed[end+2:], nil
+	case strings.HasPrefix(trimmed, "true"):
+		return true, trimmed[4:], nil
+	case strings.HasPrefix(trimmed, "false"):
+		return false, trimmed[5:], nil
+	case strings.HasPrefix(trimmed, "null"):
+		return nil, trimmed[4:], nil
+	default:
+		i := 0
+		for i < len(trimmed) && (trimmed[i] == '-' || trimmed[i] == '.' || (trimmed[i] >= '0' && trimmed[i] <= '9')) {
+			i++
+		}
+		if i == 0 {
+			return nil, "", fmt.Errorf("%w: invalid literal in filter expression at %q", ErrInvalidPathExpr, trimmed)
+		}
+		f, err := strconv.ParseFloat(trimmed[:i], 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: invalid numeric literal %q", ErrInvalidPathExpr, trimmed[:i])
+		}
+		return f, trimmed[i:], nil
+	}
+}
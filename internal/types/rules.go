@@ -14,6 +14,7 @@ package types
  *   - OrGroup: AND group (all conditions must match)
  *   - Condition: Single comparison with field path and operator
  *   - PathSegment: One component of a JSON path (key, index, or wildcard)
+ *   - QuantifierClause: Per-element comparison for ANY_OF/ALL_OF/COUNT/NONE_OF conditions
  *
  * Dependencies: None (zero external dependencies, encoding/json only)
  */
@@ -21,10 +22,26 @@ package types
 // PathSegment represents one component of a field path.
 // String for object keys, int for array indices, wildcard for array expansion.
 type PathSegment struct {
-	Key      string // object key (mutually exclusive with Index/Wildcard)
-	Index    int    // array index (mutually exclusive with Key/Wildcard)
+	Key      string // object key (mutually exclusive with Index/Wildcard/Filter; also holds the sought name when RecursiveDescent is set)
+	Index    int    // array index (mutually exclusive with Key/Wildcard/Filter); negative is relative from end
 	IsIndex  bool   // disambiguates Index=0 from unset
-	Wildcard bool   // true = wildcard segment
+	Wildcard bool   // true = wildcard segment (mutually exclusive with Key/Index/Filter)
+
+	// Filter holds a [?(...)] predicate (mutually exclusive with
+	// Key/Index/Wildcard). Resolve/ResolveAll iterate the children of the
+	// array/object at this position, keeping only those satisfying Filter,
+	// and continue descent on each match - the same shape as Wildcard but
+	// with a predicate instead of "every child".
+	Filter *FilterExpr
+
+	// RecursiveDescent marks a ".." segment (mutually exclusive with
+	// Index/Wildcard/Filter): instead of stepping into a single named
+	// child, search every node in the subtree rooted here, at any depth,
+	// for one whose key is Key - e.g. $..status matches "status" wherever
+	// it appears, not just as a direct child. Bounded by
+	// Limits.MaxRecursiveDescents (segments per path) and
+	// Limits.MaxRecursiveDescentNodes (nodes visited per segment).
+	RecursiveDescent bool
 }
 
 // Condition represents a single condition in a rule expression.
@@ -37,6 +54,27 @@ type Condition struct {
 	Values         []any         // for IN operator
 	OnMissingField int           // policy enum value
 	OnCoercionFail int           // policy enum value
+
+	// PreserveIntegers requests precision-preserving numeric coercion
+	// (rules.NumberModeAutoInt) instead of the default float64 collapse, so
+	// int64 IDs beyond 2^53 compare exactly under EQ/IN/etc.
+	PreserveIntegers bool
+
+	// Quantifier configures ANY_OF/ALL_OF/COUNT/NONE_OF evaluation over every leaf
+	// FieldPath resolves to (via ResolveAll). Ignored for other operators.
+	Quantifier *QuantifierClause
+}
+
+// QuantifierClause holds the per-element comparison for ANY_OF/ALL_OF/COUNT/NONE_OF.
+type QuantifierClause struct {
+	Operator int // per-element comparison operator enum value, e.g. GT for "any item over 100"
+	Value    any // per-element comparison target
+
+	// CountOperator/CountValue apply only to COUNT: the number of elements
+	// satisfying Operator/Value above is itself compared against CountValue
+	// using CountOperator, e.g. "count of items over 100" GTE 3.
+	CountOperator int
+	CountValue    any
 }
 
 // OrGroup represents an AND group in DNF (all conditions must match).
@@ -51,4 +89,17 @@ type Rule struct {
 	SampleRate float64   // [0.0, 1.0] sampling rate
 	OrGroups   []OrGroup // DNF: OR of AND groups
 	Action     int       // action enum value
+
+	// SamplingStrategy selects how SampleRate's threshold is applied:
+	// rules.SamplingRandom (the default, zero value) draws fresh randomness
+	// per event; rules.SamplingDeterministic hashes (RuleID, event key)
+	// instead, so the same event sampled twice - replay, A/B comparison,
+	// a reproducible test - always lands on the same side of the threshold.
+	SamplingStrategy int
+
+	// SampleKeyPath is the event key SamplingDeterministic hashes against,
+	// resolved from the payload (e.g. a path to "event.id"). Ignored for
+	// SamplingRandom. If it resolves to nothing, Evaluate falls back to
+	// SamplingRandom for that event rather than refusing to sample.
+	SampleKeyPath []PathSegment
 }
@@ -78,4 +78,56 @@ const (
 	// MaxInOperatorValues limits IN operator list size to prevent quadratic comparison cost.
 	// 64 values supports typical enum-style checks without degrading to O(n^2) behavior.
 	MaxInOperatorValues = 64
+
+	// MaxWildcardMatches limits the number of leaves ResolveAll yields for a
+	// single path, preventing combinatorial blowup from deeply nested wildcards.
+	MaxWildcardMatches = 1024
+
+	// MaxFilterPredicates caps the number of comparison/combinator nodes
+	// reachable from a single filter segment ([?(...)]), keeping predicate
+	// evaluation linear in payload size even under nested AND/OR/NOT.
+	MaxFilterPredicates = 16
+
+	// MaxFilterDepth forbids nesting a filter segment inside another
+	// filter's LHS sub-path, which would make evaluation cost exponential
+	// in payload size.
+	MaxFilterDepth = 1
+
+	// MaxGroupConditions caps the number of conditions in a single OrGroup
+	// that CompileSet will track via its per-group bitset (see
+	// internal/rules/planner.go), which packs one bit per condition into a
+	// uint64. 64 conditions in one AND group is already far beyond any
+	// realistic rule.
+	MaxGroupConditions = 64
+
+	// MaxRegexSize caps an OpRegex/OpGlob condition's pattern length.
+	// RE2 (what Go's regexp package compiles to) already guarantees
+	// linear-time matching with no catastrophic backtracking regardless of
+	// pattern shape, so this isn't a backtracking defense - it bounds the
+	// compile-time cost and automaton size of the pattern itself, which can
+	// still grow large for a sufficiently long one.
+	MaxRegexSize = 256
+
+	// MaxRecursiveDescents limits the number of ".." segments a single path
+	// may contain. Each one searches the entire subtree at that position,
+	// so more than one compounds into a search of the cross product of
+	// subtrees - 1 permits $..status but not $..a..b.
+	MaxRecursiveDescents = 1
+
+	// MaxRecursiveDescentNodes bounds how many nodes a ".." segment may
+	// visit while searching a payload for a match. This is independent of
+	// MaxWildcardMatches (which bounds results, not search cost) - a large
+	// payload with no matching key would otherwise let a single recursive
+	// descent scan the entire tree for nothing.
+	MaxRecursiveDescentNodes = 10000
+
+	// MaxCachedSubtrees bounds how many lazily-decoded subtrees a
+	// PayloadView keeps memoized at once (see internal/rules/payload_view.go).
+	// Without a cap, a CompiledPath with a wildcard or filter segment walking
+	// a payload with thousands of siblings would memoize every one of them
+	// even though only a handful are ever revisited by another rule sharing
+	// the same path prefix - the LRU eviction this bounds trades a few
+	// re-decodes for keeping memory proportional to rule count, not payload
+	// size.
+	MaxCachedSubtrees = 256
 )